@@ -1,94 +1,41 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
+	"RWB_L0/internal/dto"
 	"RWB_L0/pkg/logger"
-
-	"github.com/nats-io/stan.go"
+	pkgnats "RWB_L0/pkg/nats"
 )
 
-// Order - тестовая структура заказа
-type Order struct {
-	OrderUID          string    `json:"order_uid"`
-	TrackNumber       string    `json:"track_number"`
-	Entry             string    `json:"entry"`
-	Delivery          Delivery  `json:"delivery"`
-	Payment           Payment   `json:"payment"`
-	Items             []Item    `json:"items"`
-	Locale            string    `json:"locale"`
-	InternalSignature string    `json:"internal_signature"`
-	CustomerID        string    `json:"customer_id"`
-	DeliveryService   string    `json:"delivery_service"`
-	Shardkey          string    `json:"shardkey"`
-	SmID              int       `json:"sm_id"`
-	DateCreated       time.Time `json:"date_created"`
-	OofShard          string    `json:"oof_shard"`
-}
-
-type Delivery struct {
-	Name    string `json:"name"`
-	Phone   string `json:"phone"`
-	Zip     string `json:"zip"`
-	City    string `json:"city"`
-	Address string `json:"address"`
-	Region  string `json:"region"`
-	Email   string `json:"email"`
-}
-
-type Payment struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDt    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
-}
-
-type Item struct {
-	ChrtID      int    `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	Rid         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NmID        int    `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
-}
-
 func main() {
 	log := logger.New("info")
 
 	log.Info("Starting NATS Publisher...")
 
 	// Подключаемся к NATS
-	sc, err := stan.Connect(
-		"test-cluster",
-		"publisher-1",
-		stan.NatsURL("nats://localhost:4222"),
-	)
+	js, err := pkgnats.NewJetStream(context.Background(), &pkgnats.JetStreamConfig{
+		URL:      "nats://localhost:4222",
+		Name:     "publisher-1",
+		Stream:   "ORDERS",
+		Subjects: []string{"orders", "orders.dead-letter", "order.created", "order.updated"},
+	})
 	if err != nil {
 		log.Error("Failed to connect to NATS: %v", err)
 		return
 	}
 	defer func() {
-		if err := sc.Close(); err != nil {
+		if err := js.Close(); err != nil {
 			log.Error("Failed to close NATS connection: %v", err)
 		}
 	}()
 
 	log.Info("Connected to NATS successfully")
 
-	// Создаём тестовый заказ
-	order := Order{
+	// Создаём тестовый заказ (контракт - dto.CreateOrderInput, схема - /api/v1/schema/order)
+	order := dto.CreateOrderInput{
 		OrderUID:          "b563feb7b2b84b6test",
 		TrackNumber:       "WBILMTESTTRACK",
 		Entry:             "WBIL",
@@ -100,7 +47,7 @@ func main() {
 		SmID:              99,
 		DateCreated:       time.Now(),
 		OofShard:          "1",
-		Delivery: Delivery{
+		Delivery: dto.DeliveryInput{
 			Name:    "Test Testov",
 			Phone:   "+9720000000",
 			Zip:     "2639809",
@@ -109,7 +56,7 @@ func main() {
 			Region:  "Kraiot",
 			Email:   "test@gmail.com",
 		},
-		Payment: Payment{
+		Payment: dto.PaymentInput{
 			Transaction:  "b563feb7b2b84b6test",
 			RequestID:    "",
 			Currency:     "USD",
@@ -121,7 +68,7 @@ func main() {
 			GoodsTotal:   317,
 			CustomFee:    0,
 		},
-		Items: []Item{
+		Items: []dto.ItemInput{
 			{
 				ChrtID:      9934930,
 				TrackNumber: "WBILMTESTTRACK",
@@ -148,7 +95,7 @@ func main() {
 	log.Info("Publishing order: %s", order.OrderUID)
 
 	// Отправляем в NATS
-	if err := sc.Publish("orders", data); err != nil {
+	if err := pkgnats.NewJetStreamPublisher(js).PublishBytes("orders", data); err != nil {
 		log.Error("Failed to publish: %v", err)
 		return
 	}
@@ -0,0 +1,83 @@
+// backfill-rid дозаполняет rid у товаров, сохранённых до миграции 000004.
+//
+// На вход подаются исходные сообщения с заказами в формате JSON lines:
+//
+//	go run ./cmd/backfill-rid -file orders.jsonl
+//
+// Обновляются только товары с пустым rid, поэтому повторный запуск безопасен.
+// Работающий сервис увидит новые rid после истечения TTL кэша или перезапуска
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"RWB_L0/config"
+	"RWB_L0/internal/adapters/postgres"
+	"RWB_L0/internal/usecase"
+	"RWB_L0/pkg/logger"
+	pkgpostgres "RWB_L0/pkg/postgres"
+)
+
+func main() {
+	file := flag.String("file", "-", "JSON lines with orders (\"-\" for stdin)")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	appLog := logger.NewWithConfig(logger.Config{
+		Level:  cfg.Logging.Level,
+		Format: cfg.Logging.Format,
+	})
+
+	db, err := pkgpostgres.New(&pkgpostgres.Config{
+		Host:         cfg.Database.Host,
+		Port:         cfg.Database.Port,
+		User:         cfg.Database.User,
+		Password:     cfg.Database.Password,
+		DBName:       cfg.Database.DBName,
+		SSLMode:      cfg.Database.SSLMode,
+		MaxOpenConns: cfg.Database.MaxOpenConns,
+		MaxIdleConns: cfg.Database.MaxIdleConns,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", *file, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		input = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	backfill := usecase.NewRidBackfillUseCase(postgres.NewOrderRepository(db), appLog)
+	report, err := backfill.Run(ctx, input)
+	if report != nil {
+		appLog.Info("Backfill finished: %d orders, %d items updated, %d lines skipped",
+			report.Orders, report.Items, report.Skipped)
+	}
+	if err != nil {
+		appLog.Error("Backfill failed: %v", err)
+		os.Exit(1)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"RWB_L0/internal/domain"
 )
 
 // Config - главная структура конфигурации
@@ -15,6 +17,8 @@ type Config struct {
 	Database DatabaseConfig
 	NATS     NATSConfig
 	Cache    CacheConfig
+	Orders   OrdersConfig
+	Outbox   OutboxConfig
 	Logging  LoggingConfig
 }
 
@@ -38,20 +42,58 @@ type DatabaseConfig struct {
 	MaxIdleConns int
 }
 
-// NATSConfig - настройки NATS Streaming
+// NATSConfig - настройки NATS JetStream
 type NATSConfig struct {
 	URL         string
-	ClusterID   string
-	ClientID    string
+	ClientID    string // Имя соединения
+	Stream      string // Stream с заказами, dead letters и событиями о заказах
 	Subject     string
-	DurableName string
+	DurableName string // Durable consumer
+
+	// Лимиты хранения stream (0 - без лимита): при превышении удаляются самые старые сообщения
+	StreamMaxAge   time.Duration // Сколько хранить сообщение
+	StreamMaxBytes int64         // Сколько байт хранить
+	StreamMaxMsgs  int64         // Сколько сообщений хранить
+
+	ProcessedRetention time.Duration // Сколько хранить отметки об обработанных сообщениях (0 - всегда)
+
+	StatusSubject     string // Сообщения о смене статуса заказа
+	StatusDurableName string // Durable consumer сообщений о статусе
+
+	MaxRedeliveries   int             // Повторы временной ошибки до переноса в dead letters
+	AckWait           time.Duration   // Через сколько неподтверждённое сообщение доставляется снова
+	MaxDeliver        int             // Сколько раз сервер доставляет сообщение (0 - без лимита)
+	Backoff           []time.Duration // Паузы перед повторами временных ошибок
+	DeadLetterSubject string          // Куда публикуются необработанные сообщения
+
+	Workers     int // Сколько заказов обрабатывать параллельно
+	MaxInflight int // Сколько неподтверждённых сообщений NATS отдаёт сразу
+
+	BatchSize int           // Сколько заказов сохранять в БД одним пакетом
+	BatchWait time.Duration // Сколько ждать заполнения пакета после первого сообщения
+
+	SchemaValidation string // off, lenient или strict - проверка сообщений по JSON Schema заказа
 }
 
 // CacheConfig - настройки кэша
 type CacheConfig struct {
-	Enabled bool
-	MaxSize int           // ✅ ДОБАВЛЕНО
-	TTL     time.Duration // ✅ ДОБАВЛЕНО
+	Enabled         bool
+	MaxSize         int           // ✅ ДОБАВЛЕНО
+	TTL             time.Duration // ✅ ДОБАВЛЕНО
+	WarmupBatchSize int           // Размер пачки при прогреве кэша из БД
+	Shards          int           // Количество сегментов кэша
+}
+
+// OrdersConfig - настройки обработки заказов
+type OrdersConfig struct {
+	UpsertPolicy   string // replace, reject или newer (по номеру сообщения) - что делать с повторно присланным order_uid
+	ValidationMode string // strict или lenient - сверять ли суммы платежа и товаров
+}
+
+// OutboxConfig - настройки публикации событий о заказах
+type OutboxConfig struct {
+	BatchSize    int           // Сколько событий публиковать за один проход
+	PollInterval time.Duration // Пауза между проходами, если публиковать нечего
 }
 
 // LoggingConfig - настройки логирования
@@ -83,15 +125,42 @@ func Load() (*Config, error) {
 		},
 		NATS: NATSConfig{
 			URL:         getEnv("NATS_URL", "nats://localhost:4222"),
-			ClusterID:   getEnv("NATS_CLUSTER_ID", "test-cluster"),
 			ClientID:    getEnv("NATS_CLIENT_ID", "order-service-1"),
+			Stream:      getEnv("NATS_STREAM", "ORDERS"),
 			Subject:     getEnv("NATS_SUBJECT", "orders"),
 			DurableName: getEnv("NATS_DURABLE_NAME", "order-service-durable"),
+
+			StreamMaxAge:   getEnvAsDuration("NATS_STREAM_MAX_AGE", 7*24*time.Hour),
+			StreamMaxBytes: getEnvAsInt64("NATS_STREAM_MAX_BYTES", 1<<30),
+			StreamMaxMsgs:  getEnvAsInt64("NATS_STREAM_MAX_MSGS", 1000000),
+
+			MaxRedeliveries: getEnvAsInt("NATS_MAX_REDELIVERIES", 5),
+			AckWait:         getEnvAsDuration("NATS_ACK_WAIT", 30*time.Second),
+			MaxDeliver:      getEnvAsInt("NATS_MAX_DELIVER", 0),
+			Backoff:         getEnvAsDurations("NATS_BACKOFF", []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}),
+
+			Workers:     getEnvAsInt("NATS_WORKERS", 8),
+			MaxInflight: getEnvAsInt("NATS_MAX_INFLIGHT", 1024),
+
+			BatchSize: getEnvAsInt("NATS_BATCH_SIZE", 100),
+			BatchWait: getEnvAsDuration("NATS_BATCH_WAIT", 20*time.Millisecond),
+
+			SchemaValidation: getEnv("NATS_SCHEMA_VALIDATION", "off"),
 		},
 		Cache: CacheConfig{
-			Enabled: getEnvAsBool("CACHE_ENABLED", true),
-			MaxSize: getEnvAsInt("CACHE_MAX_SIZE", 1000),                               // ✅ ДОБАВЛЕНО
-			TTL:     time.Duration(getEnvAsInt("CACHE_TTL_MINUTES", 60)) * time.Minute, // ✅ ДОБАВЛЕНО
+			Enabled:         getEnvAsBool("CACHE_ENABLED", true),
+			MaxSize:         getEnvAsInt("CACHE_MAX_SIZE", 1000),                               // ✅ ДОБАВЛЕНО
+			TTL:             time.Duration(getEnvAsInt("CACHE_TTL_MINUTES", 60)) * time.Minute, // ✅ ДОБАВЛЕНО
+			WarmupBatchSize: getEnvAsInt("CACHE_WARMUP_BATCH_SIZE", 500),
+			Shards:          getEnvAsInt("CACHE_SHARDS", 16),
+		},
+		Orders: OrdersConfig{
+			UpsertPolicy:   getEnv("ORDER_UPSERT_POLICY", "replace"),
+			ValidationMode: getEnv("ORDER_VALIDATION_MODE", string(domain.DefaultValidationMode)),
+		},
+		Outbox: OutboxConfig{
+			BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			PollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -99,6 +168,16 @@ func Load() (*Config, error) {
 		},
 	}
 
+	// По умолчанию dead letters публикуются рядом с основным subject
+	cfg.NATS.DeadLetterSubject = getEnv("NATS_DEAD_LETTER_SUBJECT", cfg.NATS.Subject+".dead-letter")
+
+	// Отметки об обработке нужны, пока сообщение может быть доставлено снова - пока оно в stream
+	cfg.NATS.ProcessedRetention = getEnvAsDuration("NATS_PROCESSED_RETENTION", cfg.NATS.StreamMaxAge)
+
+	// Сообщения о статусе - тоже рядом с основным subject, со своим durable consumer
+	cfg.NATS.StatusSubject = getEnv("NATS_STATUS_SUBJECT", cfg.NATS.Subject+".status")
+	cfg.NATS.StatusDurableName = getEnv("NATS_STATUS_DURABLE_NAME", cfg.NATS.DurableName+"-status")
+
 	return cfg, nil
 }
 
@@ -138,6 +217,19 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
+// getEnvAsInt64 - получить переменную как int64
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 // getEnvAsBool - получить переменную как bool
 func getEnvAsBool(key string, defaultValue bool) bool {
 	valueStr := os.Getenv(key)
@@ -164,6 +256,24 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return value
 }
 
+// getEnvAsDurations - получить переменную как список time.Duration через запятую
+func getEnvAsDurations(key string, defaultValue []time.Duration) []time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	parts := strings.Split(valueStr, ",")
+	values := make([]time.Duration, 0, len(parts))
+	for _, part := range parts {
+		value, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
 // loadEnvFile - загружает .env файл
 func loadEnvFile(filename string) error {
 	file, err := os.Open(filename)
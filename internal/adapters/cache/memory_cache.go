@@ -1,38 +1,60 @@
 package cache
 
 import (
-	"errors"
-	"sync"
 	"time"
 
 	"RWB_L0/internal/domain"
+	"RWB_L0/internal/usecase"
 )
 
-// cacheEntry представляет запись в кэше с временем истечения
-type cacheEntry struct {
-	order      *domain.Order
-	expiresAt  time.Time
-	lastAccess time.Time // Для LRU
-}
+// Проверка на этапе компиляции, что MemoryCache реализует usecase.Cache
+var _ usecase.Cache = (*MemoryCache)(nil)
+
+// DefaultShards - количество сегментов кэша по умолчанию
+const DefaultShards = 16
 
-// MemoryCache - in-memory кэш с TTL и лимитами
+// MemoryCache - in-memory кэш с TTL и LRU-вытеснением, разбитый на сегменты.
+//
+// Заказ попадает в сегмент по хэшу OrderUID; у каждого сегмента своя блокировка
+// и своя доля общего лимита, поэтому операции с разными заказами не конкурируют.
+// LRU-вытеснение работает внутри сегмента. Count, GetAll и Clear блокируют
+// все сегменты сразу и видят согласованный снимок
 type MemoryCache struct {
-	mu       sync.RWMutex
-	data     map[string]*cacheEntry
+	shards   []*shard
 	maxSize  int
-	ttl      time.Duration
 	stopChan chan struct{}
 }
 
-// NewMemoryCacheWithConfig создаёт кэш с кастомными настройками
+// NewMemoryCacheWithConfig создаёт кэш с кастомными настройками (один сегмент, точный LRU)
 func NewMemoryCacheWithConfig(maxSize int, ttl time.Duration) *MemoryCache {
+	return NewShardedMemoryCache(maxSize, ttl, 1)
+}
+
+// NewShardedMemoryCache создаёт кэш из shardCount сегментов.
+// Лимит maxSize делится между сегментами так, чтобы их сумма была равна maxSize
+func NewShardedMemoryCache(maxSize int, ttl time.Duration, shardCount int) *MemoryCache {
+	if shardCount <= 0 {
+		shardCount = DefaultShards
+	}
+	// Сегмент без места бесполезен
+	if maxSize > 0 && shardCount > maxSize {
+		shardCount = maxSize
+	}
+
 	cache := &MemoryCache{
-		data:     make(map[string]*cacheEntry),
+		shards:   make([]*shard, shardCount),
 		maxSize:  maxSize,
-		ttl:      ttl,
 		stopChan: make(chan struct{}),
 	}
 
+	for i := range cache.shards {
+		shardSize := maxSize / shardCount
+		if i < maxSize%shardCount {
+			shardSize++
+		}
+		cache.shards[i] = newShard(shardSize, ttl)
+	}
+
 	// Запускаем фоновую очистку устаревших записей каждые 5 минут
 	go cache.cleanupExpired()
 
@@ -41,76 +63,39 @@ func NewMemoryCacheWithConfig(maxSize int, ttl time.Duration) *MemoryCache {
 
 // Set добавляет заказ в кэш
 func (c *MemoryCache) Set(orderUID string, order *domain.Order) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Проверяем лимит размера
-	if len(c.data) >= c.maxSize {
-		// Если достигнут лимит, удаляем самую старую запись (LRU)
-		c.evictOldest()
-	}
-
-	// Добавляем запись с TTL
-	c.data[orderUID] = &cacheEntry{
-		order:      order,
-		expiresAt:  time.Now().Add(c.ttl),
-		lastAccess: time.Now(),
-	}
-
-	return nil
+	return c.shardFor(orderUID).Set(orderUID, order)
 }
 
 // Get получает заказ из кэша
 func (c *MemoryCache) Get(orderUID string) (*domain.Order, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	entry, exists := c.data[orderUID]
-	if !exists {
-		return nil, errors.New("order not found in cache")
-	}
-
-	// Проверяем, не истёк ли TTL
-	if time.Now().After(entry.expiresAt) {
-		// Удаляем устаревшую запись
-		delete(c.data, orderUID)
-		return nil, errors.New("order expired in cache")
-	}
-
-	// Обновляем время последнего доступа (для LRU)
-	entry.lastAccess = time.Now()
-
-	return entry.order, nil
+	return c.shardFor(orderUID).Get(orderUID)
 }
 
 // Delete удаляет заказ из кэша
 func (c *MemoryCache) Delete(orderUID string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if _, exists := c.data[orderUID]; !exists {
-		return errors.New("order not found in cache")
-	}
-
-	delete(c.data, orderUID)
-	return nil
+	return c.shardFor(orderUID).Delete(orderUID)
 }
 
-// LoadAll загружает все заказы в кэш
+// LoadAll загружает заказы в кэш, пока не будут заполнены их сегменты.
+// Уже закэшированные заказы не перезаписываются, порядок orders внутри
+// каждого сегмента сохраняется (от более нужных к менее нужным)
 func (c *MemoryCache) LoadAll(orders []*domain.Order) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	if len(c.shards) == 1 {
+		return c.shards[0].LoadAll(orders)
+	}
 
+	perShard := make([][]*domain.Order, len(c.shards))
 	for _, order := range orders {
-		// Проверяем лимит при загрузке
-		if len(c.data) >= c.maxSize {
-			break
-		}
+		idx := c.shardIndex(order.OrderUID)
+		perShard[idx] = append(perShard[idx], order)
+	}
 
-		c.data[order.OrderUID] = &cacheEntry{
-			order:      order,
-			expiresAt:  time.Now().Add(c.ttl),
-			lastAccess: time.Now(),
+	for i, shardOrders := range perShard {
+		if len(shardOrders) == 0 {
+			continue
+		}
+		if err := c.shards[i].LoadAll(shardOrders); err != nil {
+			return err
 		}
 	}
 
@@ -119,18 +104,18 @@ func (c *MemoryCache) LoadAll(orders []*domain.Order) error {
 
 // GetAll возвращает все заказы из кэша
 func (c *MemoryCache) GetAll() ([]*domain.Order, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.rlockAll()
+	defer c.runlockAll()
 
-	orders := make([]*domain.Order, 0, len(c.data))
-	now := time.Now()
+	size := 0
+	for _, s := range c.shards {
+		size += len(s.data)
+	}
 
-	for _, entry := range c.data {
-		// Пропускаем устаревшие записи
-		if now.After(entry.expiresAt) {
-			continue
-		}
-		orders = append(orders, entry.order)
+	orders := make([]*domain.Order, 0, size)
+	now := time.Now()
+	for _, s := range c.shards {
+		orders = s.appendValid(orders, now)
 	}
 
 	return orders, nil
@@ -138,47 +123,95 @@ func (c *MemoryCache) GetAll() ([]*domain.Order, error) {
 
 // Count возвращает количество записей в кэше
 func (c *MemoryCache) Count() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.rlockAll()
+	defer c.runlockAll()
 
 	// Считаем только не устаревшие записи
 	count := 0
 	now := time.Now()
-
-	for _, entry := range c.data {
-		if now.Before(entry.expiresAt) {
-			count++
-		}
+	for _, s := range c.shards {
+		count += s.countValid(now)
 	}
 
 	return count
 }
 
+// Capacity возвращает максимальное количество записей в кэше
+func (c *MemoryCache) Capacity() int {
+	return c.maxSize
+}
+
+// Stats возвращает счётчики кэша (сумма по сегментам)
+func (c *MemoryCache) Stats() usecase.CacheStats {
+	stats := usecase.CacheStats{
+		Size:     c.Count(),
+		Capacity: c.maxSize,
+	}
+
+	for _, s := range c.shards {
+		stats.Hits += s.stats.hits.Load()
+		stats.Misses += s.stats.misses.Load()
+		stats.Expirations += s.stats.expirations.Load()
+		stats.Evictions += s.stats.evictions.Load()
+		stats.Sets += s.stats.sets.Load()
+		stats.MemoryBytes += s.stats.memoryBytes.Load()
+	}
+
+	return stats
+}
+
 // Clear очищает весь кэш
 func (c *MemoryCache) Clear() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	for _, s := range c.shards {
+		s.mu.Lock()
+	}
+	defer func() {
+		for _, s := range c.shards {
+			s.mu.Unlock()
+		}
+	}()
 
-	c.data = make(map[string]*cacheEntry)
+	for _, s := range c.shards {
+		s.reset()
+	}
 	return nil
 }
 
-// evictOldest удаляет самую старую запись (LRU)
-func (c *MemoryCache) evictOldest() {
-	var oldestKey string
-	var oldestTime time.Time
-	first := true
+// shardFor возвращает сегмент, в котором хранится заказ
+func (c *MemoryCache) shardFor(orderUID string) *shard {
+	return c.shards[c.shardIndex(orderUID)]
+}
 
-	for key, entry := range c.data {
-		if first || entry.lastAccess.Before(oldestTime) {
-			oldestKey = key
-			oldestTime = entry.lastAccess
-			first = false
-		}
+// shardIndex - номер сегмента по FNV-1a хэшу OrderUID (без аллокаций)
+func (c *MemoryCache) shardIndex(orderUID string) int {
+	if len(c.shards) == 1 {
+		return 0
+	}
+
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := 0; i < len(orderUID); i++ {
+		hash ^= uint32(orderUID[i])
+		hash *= prime32
+	}
+
+	return int(hash % uint32(len(c.shards)))
+}
+
+// rlockAll блокирует все сегменты на чтение (всегда в одном порядке)
+func (c *MemoryCache) rlockAll() {
+	for _, s := range c.shards {
+		s.mu.RLock()
 	}
+}
 
-	if oldestKey != "" {
-		delete(c.data, oldestKey)
+// runlockAll снимает блокировки rlockAll
+func (c *MemoryCache) runlockAll() {
+	for _, s := range c.shards {
+		s.mu.RUnlock()
 	}
 }
 
@@ -190,40 +223,16 @@ func (c *MemoryCache) cleanupExpired() {
 	for {
 		select {
 		case <-ticker.C:
-			c.removeExpired()
+			// Сегменты чистятся по очереди, чтобы не блокировать весь кэш
+			for _, s := range c.shards {
+				s.removeExpired()
+			}
 		case <-c.stopChan:
 			return
 		}
 	}
 }
 
-// removeExpired удаляет все устаревшие записи
-func (c *MemoryCache) removeExpired() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	now := time.Now()
-	for key, entry := range c.data {
-		if now.After(entry.expiresAt) {
-			delete(c.data, key)
-		}
-	}
-}
-
-// countValid подсчитывает количество действительных (не устаревших) записей
-func (c *MemoryCache) countValid() int {
-	count := 0
-	now := time.Now()
-
-	for _, entry := range c.data {
-		if now.Before(entry.expiresAt) {
-			count++
-		}
-	}
-
-	return count
-}
-
 // Close закрывает кэш и останавливает фоновую очистку
 func (c *MemoryCache) Close() {
 	close(c.stopChan)
@@ -0,0 +1,325 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"RWB_L0/internal/domain"
+)
+
+func newOrder(uid string) *domain.Order {
+	order, _ := domain.NewOrder(uid, "TRACK", "WBIL")
+	return order
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCacheWithConfig(3, time.Hour)
+	defer cache.Close()
+
+	_ = cache.Set("a", newOrder("a"))
+	_ = cache.Set("b", newOrder("b"))
+	_ = cache.Set("c", newOrder("c"))
+
+	// "a" самый старый, но к нему обращались - вытеснен должен быть "b"
+	if _, err := cache.Get("a"); err != nil {
+		t.Fatalf("Get(a) error = %v", err)
+	}
+	_ = cache.Set("d", newOrder("d"))
+
+	if _, err := cache.Get("b"); err == nil {
+		t.Error("Expected b to be evicted")
+	}
+	for _, uid := range []string{"a", "c", "d"} {
+		if _, err := cache.Get(uid); err != nil {
+			t.Errorf("Expected %s in cache, got %v", uid, err)
+		}
+	}
+	if cache.Count() != 3 {
+		t.Errorf("Expected 3 orders in cache, got %d", cache.Count())
+	}
+}
+
+func TestMemoryCache_SetExistingRefreshesEntry(t *testing.T) {
+	cache := NewMemoryCacheWithConfig(2, time.Hour)
+	defer cache.Close()
+
+	_ = cache.Set("a", newOrder("a"))
+	_ = cache.Set("b", newOrder("b"))
+
+	// Повторный Set не увеличивает размер и делает запись самой свежей
+	updated := newOrder("a")
+	updated.Entry = "UPDATED"
+	_ = cache.Set("a", updated)
+	_ = cache.Set("c", newOrder("c"))
+
+	got, err := cache.Get("a")
+	if err != nil {
+		t.Fatalf("Get(a) error = %v", err)
+	}
+	if got.Entry != "UPDATED" {
+		t.Errorf("Expected updated order, got entry %q", got.Entry)
+	}
+	if _, err := cache.Get("b"); err == nil {
+		t.Error("Expected b to be evicted")
+	}
+}
+
+func TestMemoryCache_Expiration(t *testing.T) {
+	cache := NewMemoryCacheWithConfig(10, 20*time.Millisecond)
+	defer cache.Close()
+
+	_ = cache.Set("a", newOrder("a"))
+	time.Sleep(30 * time.Millisecond)
+
+	if _, err := cache.Get("a"); err == nil {
+		t.Error("Expected expired entry to be missing")
+	}
+	if cache.Count() != 0 {
+		t.Errorf("Expected 0 orders in cache, got %d", cache.Count())
+	}
+}
+
+func TestMemoryCache_LoadAll(t *testing.T) {
+	cache := NewMemoryCacheWithConfig(3, time.Hour)
+	defer cache.Close()
+
+	existing := newOrder("b")
+	existing.Entry = "LIVE"
+	_ = cache.Set("b", existing)
+
+	_ = cache.LoadAll([]*domain.Order{newOrder("a"), newOrder("b"), newOrder("c"), newOrder("d")})
+
+	// Лимит соблюдён, уже закэшированный заказ не перезаписан
+	if cache.Count() != 3 {
+		t.Fatalf("Expected 3 orders in cache, got %d", cache.Count())
+	}
+	got, _ := cache.Get("b")
+	if got == nil || got.Entry != "LIVE" {
+		t.Error("LoadAll must not overwrite cached orders")
+	}
+	if _, err := cache.Get("d"); err == nil {
+		t.Error("Expected d to be skipped: cache is full")
+	}
+}
+
+func TestShardedMemoryCache_CapacityIsSplitExactly(t *testing.T) {
+	cache := NewShardedMemoryCache(10, time.Hour, 4)
+	defer cache.Close()
+
+	total := 0
+	for _, s := range cache.shards {
+		total += s.maxSize
+	}
+	if total != 10 {
+		t.Errorf("Expected shard capacities to sum to 10, got %d", total)
+	}
+	if cache.Capacity() != 10 {
+		t.Errorf("Expected Capacity() = 10, got %d", cache.Capacity())
+	}
+
+	// Сегментов не больше, чем мест в кэше
+	small := NewShardedMemoryCache(3, time.Hour, 16)
+	defer small.Close()
+	if len(small.shards) != 3 {
+		t.Errorf("Expected 3 shards, got %d", len(small.shards))
+	}
+}
+
+func TestShardedMemoryCache_CrossShardOperations(t *testing.T) {
+	cache := NewShardedMemoryCache(1000, time.Hour, 8)
+	defer cache.Close()
+
+	orders := make([]*domain.Order, 100)
+	for i := range orders {
+		orders[i] = newOrder(fmt.Sprintf("order-%d", i))
+	}
+	_ = cache.LoadAll(orders[:50])
+	for _, order := range orders[50:] {
+		_ = cache.Set(order.OrderUID, order)
+	}
+
+	// Заказы распределены по нескольким сегментам
+	used := 0
+	for _, s := range cache.shards {
+		if len(s.data) > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		t.Errorf("Expected orders in several shards, got %d", used)
+	}
+
+	if cache.Count() != 100 {
+		t.Errorf("Expected Count() = 100, got %d", cache.Count())
+	}
+	all, _ := cache.GetAll()
+	if len(all) != 100 {
+		t.Errorf("Expected GetAll() to return 100 orders, got %d", len(all))
+	}
+	for _, order := range orders {
+		if _, err := cache.Get(order.OrderUID); err != nil {
+			t.Fatalf("Get(%s) error = %v", order.OrderUID, err)
+		}
+	}
+
+	_ = cache.Clear()
+	if cache.Count() != 0 {
+		t.Errorf("Expected empty cache after Clear(), got %d", cache.Count())
+	}
+}
+
+func TestShardedMemoryCache_Concurrent(t *testing.T) {
+	cache := NewShardedMemoryCache(500, time.Hour, 8)
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				uid := fmt.Sprintf("order-%d-%d", w, i%200)
+				_ = cache.Set(uid, newOrder(uid))
+				_, _ = cache.Get(uid)
+				if i%100 == 0 {
+					_ = cache.Count()
+					_, _ = cache.GetAll()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if cache.Count() > cache.Capacity() {
+		t.Errorf("Count() = %d exceeds Capacity() = %d", cache.Count(), cache.Capacity())
+	}
+}
+
+func TestMemoryCache_Stats(t *testing.T) {
+	cache := NewShardedMemoryCache(2, 20*time.Millisecond, 1)
+	defer cache.Close()
+
+	_ = cache.Set("a", newOrder("a"))
+	_ = cache.Set("b", newOrder("b"))
+	_ = cache.Set("c", newOrder("c")) // вытесняет "a"
+
+	_, _ = cache.Get("b")       // hit
+	_, _ = cache.Get("a")       // miss
+	_, _ = cache.Get("missing") // miss
+
+	stats := cache.Stats()
+	if stats.Sets != 3 || stats.Hits != 1 || stats.Misses != 2 || stats.Evictions != 1 {
+		t.Errorf("Unexpected counters: %+v", stats)
+	}
+	if stats.Size != 2 || stats.Capacity != 2 {
+		t.Errorf("Expected size 2 of 2, got %d of %d", stats.Size, stats.Capacity)
+	}
+	if stats.MemoryBytes <= 0 {
+		t.Errorf("Expected positive memory estimate, got %d", stats.MemoryBytes)
+	}
+
+	// Устаревшие записи считаются как expirations, память освобождается
+	time.Sleep(30 * time.Millisecond)
+	_, _ = cache.Get("b")
+	_, _ = cache.Get("c")
+
+	stats = cache.Stats()
+	if stats.Expirations != 2 {
+		t.Errorf("Expected 2 expirations, got %d", stats.Expirations)
+	}
+	if stats.MemoryBytes != 0 {
+		t.Errorf("Expected memory estimate 0 for empty cache, got %d", stats.MemoryBytes)
+	}
+}
+
+// Бенчмарки: время Set/Get не должно зависеть от заполненности кэша
+
+var benchSizes = []int{1_000, 100_000, 1_000_000}
+
+func fillCache(size int) (*MemoryCache, []string) {
+	cache := NewMemoryCacheWithConfig(size, time.Hour)
+	order := newOrder("bench")
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("order-%d", i)
+		_ = cache.Set(keys[i], order)
+	}
+	return cache, keys
+}
+
+func BenchmarkMemoryCache_SetFull(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			cache, _ := fillCache(size)
+			defer cache.Close()
+			order := newOrder("bench")
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Каждая вставка в заполненный кэш вызывает вытеснение
+				_ = cache.Set(fmt.Sprintf("new-%d", i), order)
+			}
+		})
+	}
+}
+
+func BenchmarkMemoryCache_Get(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			cache, keys := fillCache(size)
+			defer cache.Close()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = cache.Get(keys[i%len(keys)])
+			}
+		})
+	}
+}
+
+func BenchmarkMemoryCache_GetParallel(b *testing.B) {
+	cache, keys := fillCache(100_000)
+	defer cache.Close()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_, _ = cache.Get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+// BenchmarkMemoryCache_Mixed - 90% чтений и 10% записей из многих горутин
+func BenchmarkMemoryCache_Mixed(b *testing.B) {
+	for _, shards := range []int{1, DefaultShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			cache := NewShardedMemoryCache(100_000, time.Hour, shards)
+			defer cache.Close()
+
+			order := newOrder("bench")
+			keys := make([]string, 100_000)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("order-%d", i)
+				_ = cache.Set(keys[i], order)
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := keys[i%len(keys)]
+					if i%10 == 0 {
+						_ = cache.Set(key, order)
+					} else {
+						_, _ = cache.Get(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"RWB_L0/internal/domain"
+)
+
+// cacheEntry представляет запись в кэше с временем истечения
+type cacheEntry struct {
+	key       string
+	order     *domain.Order
+	expiresAt time.Time
+	size      int64       // Оценка занимаемой памяти (estimateSize)
+	touched   atomic.Bool // Был Get после последнего перемещения в начало LRU-списка
+}
+
+// shard - сегмент MemoryCache со своей блокировкой, лимитом и LRU-списком.
+//
+// Записи хранятся в двусвязном списке (в начале - недавно использованные)
+// и в map для поиска за O(1). Get выполняется под RLock и только помечает
+// запись как использованную; в начало списка она переносится лениво, когда
+// при вытеснении доходит до его конца (second chance). Так Set и Get
+// остаются O(1) (вытеснение - амортизированно), а чтения не блокируют друг друга
+type shard struct {
+	mu      sync.RWMutex
+	data    map[string]*list.Element
+	lru     *list.List
+	maxSize int
+	ttl     time.Duration
+	stats   shardStats
+}
+
+// newShard создаёт сегмент кэша
+func newShard(maxSize int, ttl time.Duration) *shard {
+	return &shard{
+		data:    make(map[string]*list.Element),
+		lru:     list.New(),
+		maxSize: maxSize,
+		ttl:     ttl,
+	}
+}
+
+// Set добавляет заказ в сегмент
+func (c *shard) Set(orderUID string, order *domain.Order) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.sets.Add(1)
+	size := estimateSize(orderUID, order)
+
+	// Обновление существующей записи продлевает TTL и делает её самой свежей
+	if elem, exists := c.data[orderUID]; exists {
+		entry := elem.Value.(*cacheEntry)
+		c.stats.memoryBytes.Add(size - entry.size)
+		entry.order = order
+		entry.size = size
+		entry.expiresAt = time.Now().Add(c.ttl)
+		entry.touched.Store(false)
+		c.lru.MoveToFront(elem)
+		return nil
+	}
+
+	// Проверяем лимит размера
+	if len(c.data) >= c.maxSize {
+		// Если достигнут лимит, удаляем давно не использованную запись (LRU)
+		c.evictOldest()
+	}
+
+	// Добавляем запись с TTL
+	c.data[orderUID] = c.lru.PushFront(&cacheEntry{
+		key:       orderUID,
+		order:     order,
+		expiresAt: time.Now().Add(c.ttl),
+		size:      size,
+	})
+	c.stats.memoryBytes.Add(size)
+
+	return nil
+}
+
+// Get получает заказ из сегмента
+func (c *shard) Get(orderUID string) (*domain.Order, error) {
+	c.mu.RLock()
+	elem, exists := c.data[orderUID]
+	if !exists {
+		c.mu.RUnlock()
+		c.stats.misses.Add(1)
+		return nil, errors.New("order not found in cache")
+	}
+
+	entry := elem.Value.(*cacheEntry)
+
+	// Проверяем, не истёк ли TTL
+	if time.Now().After(entry.expiresAt) {
+		c.mu.RUnlock()
+		c.stats.misses.Add(1)
+		c.removeIfExpired(orderUID)
+		return nil, errors.New("order expired in cache")
+	}
+
+	// Отмечаем доступ (для LRU); перенос в начало списка - при вытеснении
+	entry.touched.Store(true)
+	order := entry.order
+	c.mu.RUnlock()
+	c.stats.hits.Add(1)
+
+	return order, nil
+}
+
+// Delete удаляет заказ из сегмента
+func (c *shard) Delete(orderUID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.data[orderUID]
+	if !exists {
+		return errors.New("order not found in cache")
+	}
+
+	c.removeElement(elem)
+	return nil
+}
+
+// LoadAll загружает заказы в сегмент, пока не будет достигнут его лимит.
+// Уже закэшированные заказы не перезаписываются: они не старше загружаемых.
+// Заказы добавляются в конец LRU-списка, поэтому порядок orders должен быть
+// от более нужных к менее нужным
+func (c *shard) LoadAll(orders []*domain.Order) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	for _, order := range orders {
+		// Проверяем лимит при загрузке
+		if len(c.data) >= c.maxSize {
+			break
+		}
+		if _, exists := c.data[order.OrderUID]; exists {
+			continue
+		}
+
+		size := estimateSize(order.OrderUID, order)
+		c.data[order.OrderUID] = c.lru.PushBack(&cacheEntry{
+			key:       order.OrderUID,
+			order:     order,
+			expiresAt: expiresAt,
+			size:      size,
+		})
+		c.stats.sets.Add(1)
+		c.stats.memoryBytes.Add(size)
+	}
+
+	return nil
+}
+
+// appendValid добавляет в dst не устаревшие заказы сегмента (под c.mu)
+func (c *shard) appendValid(dst []*domain.Order, now time.Time) []*domain.Order {
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*cacheEntry)
+		// Пропускаем устаревшие записи
+		if now.After(entry.expiresAt) {
+			continue
+		}
+		dst = append(dst, entry.order)
+	}
+	return dst
+}
+
+// reset очищает сегмент (под c.mu)
+func (c *shard) reset() {
+	c.data = make(map[string]*list.Element)
+	c.lru.Init()
+	c.stats.memoryBytes.Store(0)
+}
+
+// evictOldest удаляет давно не использованную запись (LRU).
+// Записи с отметкой о доступе получают второй шанс и переносятся в начало списка;
+// каждая отметка снимается один раз, поэтому вытеснение амортизированно O(1)
+func (c *shard) evictOldest() {
+	now := time.Now()
+
+	for elem := c.lru.Back(); elem != nil; elem = c.lru.Back() {
+		entry := elem.Value.(*cacheEntry)
+
+		expired := now.After(entry.expiresAt)
+		if !expired && entry.touched.Swap(false) {
+			c.lru.MoveToFront(elem)
+			continue
+		}
+
+		c.removeElement(elem)
+		if expired {
+			c.stats.expirations.Add(1)
+		} else {
+			c.stats.evictions.Add(1)
+		}
+		return
+	}
+}
+
+// removeIfExpired удаляет запись, если она всё ещё в кэше и устарела
+func (c *shard) removeIfExpired(orderUID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.data[orderUID]
+	if exists && time.Now().After(elem.Value.(*cacheEntry).expiresAt) {
+		c.removeElement(elem)
+		c.stats.expirations.Add(1)
+	}
+}
+
+// removeElement удаляет запись из map и LRU-списка (под c.mu)
+func (c *shard) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.data, entry.key)
+	c.stats.memoryBytes.Add(-entry.size)
+}
+
+// removeExpired удаляет все устаревшие записи
+func (c *shard) removeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if now.After(elem.Value.(*cacheEntry).expiresAt) {
+			c.removeElement(elem)
+			c.stats.expirations.Add(1)
+		}
+		elem = next
+	}
+}
+
+// countValid подсчитывает количество действительных (не устаревших) записей (под c.mu)
+func (c *shard) countValid(now time.Time) int {
+	count := 0
+
+	for _, elem := range c.data {
+		if now.Before(elem.Value.(*cacheEntry).expiresAt) {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"RWB_L0/internal/domain"
+)
+
+// shardStats - счётчики сегмента. Только атомики: обновляются и под RLock,
+// и под Lock, и читаются без блокировок
+type shardStats struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	expirations atomic.Uint64
+	evictions   atomic.Uint64
+	sets        atomic.Uint64
+	memoryBytes atomic.Int64
+}
+
+// Фиксированные размеры структур (без содержимого строк и слайсов)
+var (
+	entrySize = int64(unsafe.Sizeof(cacheEntry{}))
+	orderSize = int64(unsafe.Sizeof(domain.Order{}))
+	itemSize  = int64(unsafe.Sizeof(domain.Item{}))
+)
+
+// estimateSize - примерный объём памяти, занимаемой записью кэша с заказом
+func estimateSize(key string, order *domain.Order) int64 {
+	size := entrySize + orderSize + int64(len(key))
+
+	size += int64(len(order.OrderUID) + len(order.TrackNumber) + len(order.Entry) +
+		len(order.Locale) + len(order.InternalSignature) + len(order.CustomerID) +
+		len(order.DeliveryService) + len(order.Shardkey) + len(order.OofShard))
+
+	d := &order.Delivery
+	size += int64(len(d.Name) + len(d.Phone) + len(d.Zip) + len(d.City) +
+		len(d.Address) + len(d.Region) + len(d.Email))
+
+	p := &order.Payment
+	size += int64(len(p.Transaction) + len(p.RequestID) + len(p.Currency) +
+		len(p.Provider) + len(p.Bank))
+
+	size += int64(cap(order.Items)) * itemSize
+	for i := range order.Items {
+		item := &order.Items[i]
+		size += int64(len(item.TrackNumber) + len(item.Rid) + len(item.Name) +
+			len(item.Size) + len(item.Brand))
+	}
+
+	return size
+}
@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/usecase"
+)
+
+// Проверка на этапе компиляции, что DeadLetterRepository подходит use case
+var _ usecase.DeadLetterRepository = (*DeadLetterRepository)(nil)
+
+// DeadLetterRepository - хранилище необработанных сообщений
+type DeadLetterRepository struct {
+	db *sql.DB
+}
+
+// NewDeadLetterRepository - создание репозитория dead letters
+func NewDeadLetterRepository(db *sql.DB) *DeadLetterRepository {
+	return &DeadLetterRepository{db: db}
+}
+
+// Save - сохранить сообщение. Повторное сохранение того же сообщения
+// (subject, sequence) заменяет запись целиком: содержимое, ошибку, счётчик попыток и время
+func (r *DeadLetterRepository) Save(ctx context.Context, letter *domain.DeadLetter) error {
+	var violations []byte
+	if len(letter.Violations) > 0 {
+		data, err := json.Marshal(letter.Violations)
+		if err != nil {
+			return fmt.Errorf("failed to marshal violations: %w", err)
+		}
+		violations = data
+	}
+
+	query := `
+		INSERT INTO dead_letters (subject, sequence, payload, error, permanent, attempts, violations)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (subject, sequence) DO UPDATE SET
+			payload = EXCLUDED.payload,
+			error = EXCLUDED.error,
+			permanent = EXCLUDED.permanent,
+			attempts = EXCLUDED.attempts,
+			violations = EXCLUDED.violations,
+			created_at = NOW()
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		letter.Subject, letter.Sequence, letter.Payload,
+		letter.Error, letter.Permanent, letter.Attempts, violations,
+	).Scan(&letter.ID, &letter.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// deadLetterColumns - колонки dead_letters в порядке scanDeadLetter
+const deadLetterColumns = `id, subject, sequence, payload, error, permanent, attempts, created_at, violations`
+
+// List - страница сообщений, новые первыми. beforeID > 0 - только записи с меньшим id
+func (r *DeadLetterRepository) List(ctx context.Context, beforeID int64, limit int) ([]*domain.DeadLetter, error) {
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM dead_letters
+		WHERE ($1::BIGINT = 0 OR id < $1::BIGINT)
+		ORDER BY id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+		}
+	}(rows)
+
+	letters := make([]*domain.DeadLetter, 0, limit)
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		letters = append(letters, letter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	return letters, nil
+}
+
+// GetByID - получить сообщение по id
+func (r *DeadLetterRepository) GetByID(ctx context.Context, id int64) (*domain.DeadLetter, error) {
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters WHERE id = $1`
+
+	letter, err := scanDeadLetter(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrDeadLetterNotFound
+		}
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	return letter, nil
+}
+
+// Delete - удалить сообщение
+func (r *DeadLetterRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrDeadLetterNotFound
+	}
+
+	return nil
+}
+
+// rowScanner - общее у *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDeadLetter - чтение строки dead_letters
+func scanDeadLetter(row rowScanner) (*domain.DeadLetter, error) {
+	letter := &domain.DeadLetter{}
+	var violations []byte
+	err := row.Scan(
+		&letter.ID, &letter.Subject, &letter.Sequence, &letter.Payload,
+		&letter.Error, &letter.Permanent, &letter.Attempts, &letter.CreatedAt, &violations,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if violations != nil {
+		if err := json.Unmarshal(violations, &letter.Violations); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal violations: %w", err)
+		}
+	}
+	return letter, nil
+}
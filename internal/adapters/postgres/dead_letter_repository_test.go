@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"RWB_L0/internal/domain"
+)
+
+// TestDeadLetterRepository_Violations - нарушения правил заказа сохраняются вместе
+// с сообщением; сообщение без нарушений хранит NULL
+func TestDeadLetterRepository_Violations(t *testing.T) {
+	repo := NewDeadLetterRepository(openTestDB(t))
+	ctx := context.Background()
+
+	violations := []domain.Violation{
+		{Field: "delivery.name", Code: domain.ViolationRequired, Message: "delivery name cannot be empty"},
+		{Field: "items[2].price", Code: domain.ViolationOutOfRange, Message: "item price must be greater than or equal to 0"},
+	}
+	invalid := &domain.DeadLetter{Subject: "orders", Sequence: 1, Payload: []byte(`{}`), Error: "invalid order",
+		Permanent: true, Attempts: 1, Violations: violations}
+	broken := &domain.DeadLetter{Subject: "orders", Sequence: 2, Payload: []byte(`{`), Error: "invalid JSON",
+		Permanent: true, Attempts: 1}
+
+	for _, letter := range []*domain.DeadLetter{invalid, broken} {
+		if err := repo.Save(ctx, letter); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	got, err := repo.GetByID(ctx, invalid.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Violations, violations) {
+		t.Errorf("Expected violations %+v, got %+v", violations, got.Violations)
+	}
+
+	got, err = repo.GetByID(ctx, broken.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Violations != nil {
+		t.Errorf("Expected no violations, got %+v", got.Violations)
+	}
+}
+
+// TestDeadLetterRepository_SaveReplaces - dead letter с тем же (subject, sequence)
+// заменяет прежний целиком: повторная отправка уйдёт с новым содержимым
+func TestDeadLetterRepository_SaveReplaces(t *testing.T) {
+	repo := NewDeadLetterRepository(openTestDB(t))
+	ctx := context.Background()
+
+	old := &domain.DeadLetter{Subject: "orders", Sequence: 1, Payload: []byte(`{"order_uid":"old"}`),
+		Error: "db is down", Attempts: 3}
+	if err := repo.Save(ctx, old); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	replaced := &domain.DeadLetter{Subject: "orders", Sequence: 1, Payload: []byte(`{"order_uid":"new"}`),
+		Error: "invalid order", Permanent: true, Attempts: 1}
+	if err := repo.Save(ctx, replaced); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, old.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if replaced.ID != old.ID || string(got.Payload) != `{"order_uid":"new"}` ||
+		got.Error != "invalid order" || !got.Permanent || got.Attempts != 1 {
+		t.Errorf("Expected the dead letter to be replaced, got %+v", got)
+	}
+	if got.CreatedAt.Before(old.CreatedAt) {
+		t.Errorf("Expected created_at to move forward, got %v before %v", got.CreatedAt, old.CreatedAt)
+	}
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"RWB_L0/internal/domain"
+)
+
+// Колонки order_history: historyColumns - в порядке scanOrderChange, historyInsertColumns - historyRow
+const (
+	historyColumns       = `id, order_uid, action, source, sequence, version, snapshot, created_at`
+	historyInsertColumns = `order_uid, action, source, sequence, version, snapshot`
+)
+
+// historyRow - строка order_history со снимком заказа (колонки historyInsertColumns)
+func historyRow(order *domain.Order, action domain.ChangeAction, origin domain.ChangeOrigin) ([]interface{}, error) {
+	snapshot, err := json.Marshal(order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal order snapshot: %w", err)
+	}
+
+	// Номер сообщения есть только у изменений из NATS
+	var sequence sql.NullInt64
+	if origin.Sequence != 0 {
+		sequence = sql.NullInt64{Int64: int64(origin.Sequence), Valid: true}
+	}
+
+	return []interface{}{order.OrderUID, action, origin.Source, sequence, order.Version, snapshot}, nil
+}
+
+// insertHistory - добавление снимков заказов в order_history
+func insertHistory(ctx context.Context, tx *sql.Tx, rows [][]interface{}) error {
+	head := `INSERT INTO order_history (` + historyInsertColumns + `)`
+	if err := insertRows(ctx, tx, head, "", rows, nil); err != nil {
+		return fmt.Errorf("failed to save order history: %w", err)
+	}
+	return nil
+}
+
+// History - история изменений заказа, от старых к новым.
+// Пустой результат - заказ никогда не сохранялся (или сохранён до появления истории)
+func (r *OrderRepository) History(ctx context.Context, orderUID string) ([]*domain.OrderChange, error) {
+	query := `SELECT ` + historyColumns + ` FROM order_history WHERE order_uid = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order history: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+		}
+	}(rows)
+
+	changes := make([]*domain.OrderChange, 0)
+	for rows.Next() {
+		change, err := scanOrderChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order history: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get order history: %w", err)
+	}
+
+	return changes, nil
+}
+
+// scanOrderChange - чтение строки order_history
+func scanOrderChange(row rowScanner) (*domain.OrderChange, error) {
+	change := &domain.OrderChange{}
+	var sequence sql.NullInt64
+	var snapshot []byte
+	err := row.Scan(
+		&change.ID, &change.OrderUID, &change.Action, &change.Source,
+		&sequence, &change.Version, &snapshot, &change.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if sequence.Valid {
+		change.Sequence = uint64(sequence.Int64)
+	}
+	change.Snapshot = snapshot
+	return change, nil
+}
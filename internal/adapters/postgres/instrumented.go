@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/usecase"
+)
+
+// Проверка на этапе компиляции, что обе реализации подходят use case
+var (
+	_ usecase.OrderRepository   = (*OrderRepository)(nil)
+	_ usecase.OrderRepository   = (*InstrumentedOrderRepository)(nil)
+	_ usecase.ItemRidRepository = (*OrderRepository)(nil)
+)
+
+// QueryObserver - получатель длительности операций репозитория (например, метрики)
+type QueryObserver interface {
+	ObserveDBQuery(operation string, duration time.Duration, err error)
+}
+
+// InstrumentedOrderRepository - OrderRepository с замером длительности каждой операции
+type InstrumentedOrderRepository struct {
+	repo     *OrderRepository
+	observer QueryObserver
+}
+
+// NewInstrumentedOrderRepository - обёртка над репозиторием заказов
+func NewInstrumentedOrderRepository(repo *OrderRepository, observer QueryObserver) *InstrumentedOrderRepository {
+	return &InstrumentedOrderRepository{
+		repo:     repo,
+		observer: observer,
+	}
+}
+
+// Save - см. OrderRepository.Save
+func (r *InstrumentedOrderRepository) Save(ctx context.Context, order *domain.Order, opts domain.SaveOptions) (domain.SaveOutcome, error) {
+	started := time.Now()
+	outcome, err := r.repo.Save(ctx, order, opts)
+	r.observer.ObserveDBQuery("save", time.Since(started), err)
+	return outcome, err
+}
+
+// SaveBatch - см. OrderRepository.SaveBatch
+func (r *InstrumentedOrderRepository) SaveBatch(ctx context.Context, batch []domain.SaveRequest) ([]domain.SaveResult, error) {
+	started := time.Now()
+	results, err := r.repo.SaveBatch(ctx, batch)
+	r.observer.ObserveDBQuery("save_batch", time.Since(started), err)
+	return results, err
+}
+
+// GetByID - см. OrderRepository.GetByID
+func (r *InstrumentedOrderRepository) GetByID(ctx context.Context, orderUID string) (*domain.Order, error) {
+	started := time.Now()
+	order, err := r.repo.GetByID(ctx, orderUID)
+	r.observer.ObserveDBQuery("get_by_id", time.Since(started), ignoreNotFound(err))
+	return order, err
+}
+
+// GetAll - см. OrderRepository.GetAll
+func (r *InstrumentedOrderRepository) GetAll(ctx context.Context) ([]*domain.Order, error) {
+	started := time.Now()
+	orders, err := r.repo.GetAll(ctx)
+	r.observer.ObserveDBQuery("get_all", time.Since(started), err)
+	return orders, err
+}
+
+// List - см. OrderRepository.List
+func (r *InstrumentedOrderRepository) List(ctx context.Context, query *domain.OrderListQuery) (*domain.OrderPage, error) {
+	started := time.Now()
+	page, err := r.repo.List(ctx, query)
+	r.observer.ObserveDBQuery("list", time.Since(started), err)
+	return page, err
+}
+
+// Delete - см. OrderRepository.Delete
+func (r *InstrumentedOrderRepository) Delete(ctx context.Context, orderUID string, expectedVersion int64, origin domain.ChangeOrigin) error {
+	started := time.Now()
+	err := r.repo.Delete(ctx, orderUID, expectedVersion, origin)
+	r.observer.ObserveDBQuery("delete", time.Since(started), ignoreNotFound(err))
+	return err
+}
+
+// History - см. OrderRepository.History
+func (r *InstrumentedOrderRepository) History(ctx context.Context, orderUID string) ([]*domain.OrderChange, error) {
+	started := time.Now()
+	changes, err := r.repo.History(ctx, orderUID)
+	r.observer.ObserveDBQuery("history", time.Since(started), err)
+	return changes, err
+}
+
+// ChangeStatus - см. OrderRepository.ChangeStatus
+func (r *InstrumentedOrderRepository) ChangeStatus(ctx context.Context, orderUID string, change domain.StatusChange) (*domain.Order, domain.SaveOutcome, error) {
+	started := time.Now()
+	order, outcome, err := r.repo.ChangeStatus(ctx, orderUID, change)
+	r.observer.ObserveDBQuery("change_status", time.Since(started), ignoreNotFound(err))
+	return order, outcome, err
+}
+
+// StatusHistory - см. OrderRepository.StatusHistory
+func (r *InstrumentedOrderRepository) StatusHistory(ctx context.Context, orderUID string) ([]*domain.StatusTransition, error) {
+	started := time.Now()
+	transitions, err := r.repo.StatusHistory(ctx, orderUID)
+	r.observer.ObserveDBQuery("status_history", time.Since(started), err)
+	return transitions, err
+}
+
+// Count - см. OrderRepository.Count
+func (r *InstrumentedOrderRepository) Count(ctx context.Context) (int, error) {
+	started := time.Now()
+	count, err := r.repo.Count(ctx)
+	r.observer.ObserveDBQuery("count", time.Since(started), err)
+	return count, err
+}
+
+// ignoreNotFound - отсутствие заказа не считается ошибкой БД
+func ignoreNotFound(err error) error {
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		return nil
+	}
+	return err
+}
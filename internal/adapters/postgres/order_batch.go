@@ -0,0 +1,508 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"RWB_L0/internal/domain"
+)
+
+// maxQueryParams - предел PostgreSQL на число параметров одного запроса
+const maxQueryParams = 65535
+
+// errBatchExpectedVersion - пакетное сохранение не поддерживает compare-and-swap
+var errBatchExpectedVersion = errors.New("expected version is not supported in batch save")
+
+// savedOrder - записанная строка orders, для которой осталось записать связанные данные
+type savedOrder struct {
+	order   *domain.Order
+	outcome domain.SaveOutcome
+	origin  domain.ChangeOrigin
+}
+
+// SaveBatch - сохранить пакет заказов в одной транзакции многострочными INSERT
+// (несколько запросов на пакет вместо нескольких на каждый заказ и товар).
+// Правила те же, что у Save, результаты - в порядке batch. Заказы с одинаковым
+// order_uid сохраняются по очереди, как при последовательных вызовах Save.
+//
+// Если пакетная транзакция не удалась из-за данных одного из заказов (isRowError),
+// заказы сохраняются по одному через Save: ошибка одного заказа не мешает сохранить
+// остальные. Остальные ошибки (соединение, взаимоблокировка) возвращаются для всего
+// пакета - по одному заказы упали бы так же. ExpectedVersion не поддерживается
+func (r *OrderRepository) SaveBatch(ctx context.Context, batch []domain.SaveRequest) ([]domain.SaveResult, error) {
+	for _, req := range batch {
+		if req.Options.ExpectedVersion != 0 {
+			return nil, errBatchExpectedVersion
+		}
+	}
+	if len(batch) == 0 {
+		return []domain.SaveResult{}, nil
+	}
+
+	results, err := r.saveBatch(ctx, batch)
+	if err == nil {
+		return results, nil
+	}
+	if ctx.Err() != nil || !isRowError(err) {
+		return nil, err
+	}
+
+	// Пакет откатился целиком - находим заказы, из-за которых это произошло
+	results = make([]domain.SaveResult, len(batch))
+	for i, req := range batch {
+		outcome, err := r.Save(ctx, req.Order, req.Options)
+		results[i] = domain.SaveResult{Outcome: outcome, Err: err}
+	}
+	return results, nil
+}
+
+// isRowError - ошибка из-за данных отдельной строки: недопустимое значение (класс 22)
+// или нарушение ограничения (класс 23). Такую ошибку даст и Save одного заказа
+func isRowError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code.Class() {
+	case "22", "23":
+		return true
+	default:
+		return false
+	}
+}
+
+// saveBatch - пакетное сохранение в одной транзакции; любая ошибка откатывает весь пакет
+func (r *OrderRepository) saveBatch(ctx context.Context, batch []domain.SaveRequest) ([]domain.SaveResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+		}
+	}(tx)
+
+	results := make([]domain.SaveResult, len(batch))
+
+	// В одном раунде order_uid не повторяются: повторный заказ сохраняется
+	// следующим раундом поверх предыдущего
+	pending := make([]int, len(batch))
+	for i := range pending {
+		pending[i] = i
+	}
+	for len(pending) > 0 {
+		var round []int
+		round, pending = splitRound(batch, pending)
+		if err := saveRound(ctx, tx, batch, round, results); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return results, nil
+}
+
+// splitRound - первое вхождение каждого order_uid из pending и остаток в исходном порядке
+func splitRound(batch []domain.SaveRequest, pending []int) ([]int, []int) {
+	seen := make(map[string]bool, len(pending))
+	round := make([]int, 0, len(pending))
+	var rest []int
+	for _, i := range pending {
+		uid := batch[i].Order.OrderUID
+		if seen[uid] {
+			rest = append(rest, i)
+			continue
+		}
+		seen[uid] = true
+		round = append(round, i)
+	}
+	return round, rest
+}
+
+// saveRound - сохранение заказов с разными order_uid (шаги saveTx, но для всех сразу)
+func saveRound(ctx context.Context, tx *sql.Tx, batch []domain.SaveRequest, round []int, results []domain.SaveResult) error {
+	// 0. Повторно доставленные сообщения NATS уже сохранены
+	round, err := markProcessedBatch(ctx, tx, batch, round, results)
+	if err != nil {
+		return err
+	}
+
+	// 1. Вставляем новые заказы, существующие блокируем и решаем по политике
+	created, err := insertOrders(ctx, tx, batch, round)
+	if err != nil {
+		return err
+	}
+
+	var existing []string
+	for _, i := range round {
+		if uid := batch[i].Order.OrderUID; !created[uid] {
+			existing = append(existing, uid)
+		}
+	}
+	stored, err := lockOrders(ctx, tx, existing)
+	if err != nil {
+		return err
+	}
+
+	saved := make([]savedOrder, 0, len(round))
+	for _, i := range round {
+		order, opts := batch[i].Order, batch[i].Options
+
+		outcome := domain.SaveCreated
+		if created[order.OrderUID] {
+			order.Version = 1
+			order.Status = domain.StatusCreated
+		} else {
+			prev, ok := stored[order.OrderUID]
+			if !ok {
+				return fmt.Errorf("failed to lock order: %w", sql.ErrNoRows)
+			}
+
+			outcome = opts.Policy.Resolve(prev.sourceSequence, opts.Origin)
+			if outcome == domain.SaveIgnored {
+				order.Version = prev.Version
+				results[i] = domain.SaveResult{Outcome: outcome}
+				continue
+			}
+
+			if err := updateOrder(ctx, tx, order, prev.Version, opts.Origin); err != nil {
+				return err
+			}
+			order.Version = prev.Version + 1
+			order.Status = prev.Status
+		}
+
+		results[i] = domain.SaveResult{Outcome: outcome}
+		saved = append(saved, savedOrder{order: order, outcome: outcome, origin: opts.Origin})
+	}
+
+	// 2-5. Связанные данные всех записанных заказов
+	return writeOrderData(ctx, tx, saved)
+}
+
+// markProcessedBatch - отметка об обработке сообщений NATS; уже обработанные
+// получают domain.SaveDuplicate. Возвращает заказы, которые нужно сохранить
+func markProcessedBatch(ctx context.Context, tx *sql.Tx, batch []domain.SaveRequest, round []int, results []domain.SaveResult) ([]int, error) {
+	var rows [][]interface{}
+	for _, i := range round {
+		if origin := batch[i].Options.Origin; origin.Subject != "" {
+			rows = append(rows, []interface{}{origin.Subject, int64(origin.Sequence)})
+		}
+	}
+	if len(rows) == 0 {
+		return round, nil
+	}
+
+	type messageKey struct {
+		subject  string
+		sequence int64
+	}
+	first := make(map[messageKey]bool, len(rows))
+
+	head := `INSERT INTO processed_messages (subject, sequence)`
+	tail := `ON CONFLICT (subject, sequence) DO NOTHING RETURNING subject, sequence`
+	err := insertRows(ctx, tx, head, tail, rows, func(rows *sql.Rows) error {
+		var key messageKey
+		if err := rows.Scan(&key.subject, &key.sequence); err != nil {
+			return err
+		}
+		first[key] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark message processed: %w", err)
+	}
+
+	remaining := make([]int, 0, len(round))
+	for _, i := range round {
+		origin := batch[i].Options.Origin
+		if origin.Subject != "" {
+			key := messageKey{subject: origin.Subject, sequence: int64(origin.Sequence)}
+			if !first[key] {
+				results[i] = domain.SaveResult{Outcome: domain.SaveDuplicate}
+				continue
+			}
+			// Одно сообщение дважды в пакете - второе тоже повторная доставка
+			delete(first, key)
+		}
+		remaining = append(remaining, i)
+	}
+	return remaining, nil
+}
+
+// insertOrders - вставка строк orders (версия 1, статус created); возвращает order_uid вставленных
+func insertOrders(ctx context.Context, tx *sql.Tx, batch []domain.SaveRequest, round []int) (map[string]bool, error) {
+	rows := make([][]interface{}, 0, len(round))
+	for _, i := range round {
+		order := batch[i].Order
+		rows = append(rows, []interface{}{
+			order.OrderUID, order.TrackNumber, order.Entry, order.Locale,
+			order.InternalSignature, order.CustomerID, order.DeliveryService,
+			order.Shardkey, order.SmID, order.DateCreated, order.OofShard, domain.StatusCreated, 1,
+			sourceSequence(batch[i].Options.Origin),
+		})
+	}
+
+	created := make(map[string]bool, len(rows))
+	head := `INSERT INTO orders (` + orderColumns + `, source_sequence)`
+	tail := `ON CONFLICT (order_uid) DO NOTHING RETURNING order_uid`
+	err := insertRows(ctx, tx, head, tail, rows, func(rows *sql.Rows) error {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return err
+		}
+		created[uid] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save order: %w", err)
+	}
+	return created, nil
+}
+
+// lockOrders - сохранённые версии заказов (только поля orders) с блокировкой строк.
+// Строки блокируются в порядке order_uid, чтобы пакеты не ждали друг друга по кругу
+func lockOrders(ctx context.Context, tx *sql.Tx, orderUIDs []string) (map[string]*lockedOrder, error) {
+	stored := make(map[string]*lockedOrder, len(orderUIDs))
+	if len(orderUIDs) == 0 {
+		return stored, nil
+	}
+
+	query := `
+		SELECT order_uid, date_created, status, version, source_sequence FROM orders
+		WHERE order_uid = ANY($1)
+		ORDER BY order_uid
+		FOR UPDATE
+	`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(orderUIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock order: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+		}
+	}(rows)
+
+	for rows.Next() {
+		order := &lockedOrder{Order: &domain.Order{}}
+		var sequence sql.NullInt64
+		if err := rows.Scan(&order.OrderUID, &order.DateCreated, &order.Status, &order.Version, &sequence); err != nil {
+			return nil, fmt.Errorf("failed to lock order: %w", err)
+		}
+		order.sourceSequence = uint64(sequence.Int64)
+		stored[order.OrderUID] = order
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock order: %w", err)
+	}
+
+	return stored, nil
+}
+
+// writeOrderData - доставка, платёж и товары (всегда целиком), история и событие
+// для внешних систем - в той же транзакции, что и сами заказы.
+// order_uid в saved не должны повторяться
+func writeOrderData(ctx context.Context, tx *sql.Tx, saved []savedOrder) error {
+	if len(saved) == 0 {
+		return nil
+	}
+
+	var (
+		deliveries = make([][]interface{}, 0, len(saved))
+		payments   = make([][]interface{}, 0, len(saved))
+		items      [][]interface{}
+		history    = make([][]interface{}, 0, len(saved))
+		events     = make([][]interface{}, 0, len(saved))
+		updated    []string
+	)
+	for _, s := range saved {
+		order := s.order
+
+		deliveries = append(deliveries, []interface{}{
+			order.OrderUID, order.Delivery.Name, order.Delivery.Phone,
+			order.Delivery.Zip, order.Delivery.City, order.Delivery.Address,
+			order.Delivery.Region, order.Delivery.Email,
+		})
+		payments = append(payments, []interface{}{
+			order.OrderUID, order.Payment.Transaction, order.Payment.RequestID,
+			order.Payment.Currency, order.Payment.Provider, order.Payment.Amount,
+			order.Payment.PaymentDt, order.Payment.Bank, order.Payment.DeliveryCost,
+			order.Payment.GoodsTotal, order.Payment.CustomFee,
+		})
+		for _, item := range order.Items {
+			items = append(items, []interface{}{
+				order.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.Rid,
+				item.Name, item.Sale, item.Size, item.TotalPrice,
+				item.NmID, item.Brand, item.Status,
+			})
+		}
+
+		action, event := domain.ChangeUpdated, domain.OrderEventUpdated
+		if s.outcome == domain.SaveCreated {
+			action, event = domain.ChangeCreated, domain.OrderEventCreated
+		} else {
+			updated = append(updated, order.OrderUID)
+		}
+
+		row, err := historyRow(order, action, s.origin)
+		if err != nil {
+			return err
+		}
+		history = append(history, row)
+
+		row, err = outboxRow(order, event)
+		if err != nil {
+			return err
+		}
+		events = append(events, row)
+	}
+
+	if err := upsertDeliveries(ctx, tx, deliveries); err != nil {
+		return err
+	}
+	if err := upsertPayments(ctx, tx, payments); err != nil {
+		return err
+	}
+	if err := replaceItems(ctx, tx, updated, items); err != nil {
+		return err
+	}
+	if err := insertHistory(ctx, tx, history); err != nil {
+		return err
+	}
+	return insertOutbox(ctx, tx, events)
+}
+
+// upsertDeliveries - вставка или полная замена доставок
+func upsertDeliveries(ctx context.Context, tx *sql.Tx, rows [][]interface{}) error {
+	head := `INSERT INTO deliveries (order_uid, ` + deliveryColumns + `)`
+	tail := `
+		ON CONFLICT (order_uid) DO UPDATE SET
+			name = EXCLUDED.name,
+			phone = EXCLUDED.phone,
+			zip = EXCLUDED.zip,
+			city = EXCLUDED.city,
+			address = EXCLUDED.address,
+			region = EXCLUDED.region,
+			email = EXCLUDED.email
+	`
+	if err := insertRows(ctx, tx, head, tail, rows, nil); err != nil {
+		return fmt.Errorf("failed to save delivery: %w", err)
+	}
+	return nil
+}
+
+// upsertPayments - вставка или полная замена платежей
+func upsertPayments(ctx context.Context, tx *sql.Tx, rows [][]interface{}) error {
+	head := `INSERT INTO payments (order_uid, ` + paymentColumns + `)`
+	tail := `
+		ON CONFLICT (order_uid) DO UPDATE SET
+			transaction = EXCLUDED.transaction,
+			request_id = EXCLUDED.request_id,
+			currency = EXCLUDED.currency,
+			provider = EXCLUDED.provider,
+			amount = EXCLUDED.amount,
+			payment_dt = EXCLUDED.payment_dt,
+			bank = EXCLUDED.bank,
+			delivery_cost = EXCLUDED.delivery_cost,
+			goods_total = EXCLUDED.goods_total,
+			custom_fee = EXCLUDED.custom_fee
+	`
+	if err := insertRows(ctx, tx, head, tail, rows, nil); err != nil {
+		return fmt.Errorf("failed to save payment: %w", err)
+	}
+	return nil
+}
+
+// replaceItems - удаление старых товаров обновлённых заказов и вставка присланных
+func replaceItems(ctx context.Context, tx *sql.Tx, updated []string, rows [][]interface{}) error {
+	if len(updated) > 0 {
+		_, err := tx.ExecContext(ctx, "DELETE FROM items WHERE order_uid = ANY($1)", pq.Array(updated))
+		if err != nil {
+			return fmt.Errorf("failed to delete old items: %w", err)
+		}
+	}
+
+	head := `INSERT INTO items (order_uid, ` + itemColumns + `)`
+	if err := insertRows(ctx, tx, head, "", rows, nil); err != nil {
+		return fmt.Errorf("failed to save item: %w", err)
+	}
+	return nil
+}
+
+// insertRows - многострочный INSERT: head VALUES (...), (...) tail.
+// Строки делятся на запросы так, чтобы не превысить maxQueryParams.
+// Если задан scan, он вызывается для каждой строки RETURNING
+func insertRows(ctx context.Context, tx *sql.Tx, head, tail string, rows [][]interface{}, scan func(*sql.Rows) error) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := len(rows[0])
+	chunkSize := maxQueryParams / columns
+	for start := 0; start < len(rows); start += chunkSize {
+		chunk := rows[start:min(start+chunkSize, len(rows))]
+
+		var query strings.Builder
+		query.WriteString(head)
+		query.WriteString(" VALUES ")
+		args := make([]interface{}, 0, len(chunk)*columns)
+		for i, row := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteByte('(')
+			for j, value := range row {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+				args = append(args, value)
+				query.WriteByte('$')
+				query.WriteString(strconv.Itoa(len(args)))
+			}
+			query.WriteByte(')')
+		}
+		query.WriteString(" ")
+		query.WriteString(tail)
+
+		if err := execRows(ctx, tx, query.String(), args, scan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execRows - выполнение запроса insertRows с чтением RETURNING, если задан scan
+func execRows(ctx context.Context, tx *sql.Tx, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	if scan == nil {
+		_, err := tx.ExecContext(ctx, query, args...)
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+		}
+	}(rows)
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
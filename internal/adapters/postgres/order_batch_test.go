@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"RWB_L0/internal/domain"
+)
+
+// TestOrderRepository_SaveBatch - пакет даёт те же результаты, что и Save по очереди:
+// новые заказы, замена, повтор заказа в пакете и повторно доставленное сообщение
+func TestOrderRepository_SaveBatch(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	seed := 0
+	newOrder := func() *domain.Order {
+		order := &domain.Order{}
+		fillValue(reflect.ValueOf(order).Elem(), &seed)
+		return order
+	}
+	origin := func(seq uint64) domain.ChangeOrigin {
+		return domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders", Sequence: seq}
+	}
+
+	stored := newOrder()
+	if _, err := repo.Save(ctx, stored, domain.SaveOptions{Origin: origin(1)}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	replacement := newOrder()
+	replacement.OrderUID = stored.OrderUID
+	replacement.Items = replacement.Items[:1]
+
+	created := newOrder()
+	resent := newOrder()
+	resent.OrderUID = created.OrderUID
+
+	replace := domain.SaveOptions{Policy: domain.UpsertReplace}
+	batch := []domain.SaveRequest{
+		{Order: replacement, Options: domain.SaveOptions{Policy: domain.UpsertReplace, Origin: origin(2)}},
+		{Order: created, Options: domain.SaveOptions{Policy: domain.UpsertReplace, Origin: origin(3)}},
+		{Order: resent, Options: replace},
+		{Order: newOrder(), Options: domain.SaveOptions{Origin: origin(1)}},
+	}
+
+	results, err := repo.SaveBatch(ctx, batch)
+	if err != nil {
+		t.Fatalf("SaveBatch() error = %v", err)
+	}
+
+	want := []domain.SaveOutcome{domain.SaveUpdated, domain.SaveCreated, domain.SaveUpdated, domain.SaveDuplicate}
+	for i, res := range results {
+		if res.Err != nil || res.Outcome != want[i] {
+			t.Errorf("Result %d = %q, %v; want %q", i, res.Outcome, res.Err, want[i])
+		}
+	}
+
+	for _, wantOrder := range []*domain.Order{replacement, resent} {
+		got, err := repo.GetByID(ctx, wantOrder.OrderUID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		var diffs []string
+		diffValues("order", reflect.ValueOf(wantOrder).Elem(), reflect.ValueOf(got).Elem(), &diffs)
+		for _, diff := range diffs {
+			t.Error(diff)
+		}
+	}
+	if replacement.Version != 2 || created.Version != 1 || resent.Version != 2 {
+		t.Errorf("Unexpected versions: %d, %d, %d", replacement.Version, created.Version, resent.Version)
+	}
+
+	var events int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM outbox`).Scan(&events); err != nil {
+		t.Fatalf("Failed to count outbox events: %v", err)
+	}
+	if events != 4 {
+		t.Errorf("Expected 4 outbox events (1 from Save, 3 from the batch), got %d", events)
+	}
+}
+
+// TestOrderRepository_SaveBatchFallback - заказ, который не удаётся записать,
+// не мешает сохранить остальные заказы пакета
+func TestOrderRepository_SaveBatchFallback(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	seed := 0
+	batch := make([]domain.SaveRequest, 3)
+	for i := range batch {
+		order := &domain.Order{}
+		fillValue(reflect.ValueOf(order).Elem(), &seed)
+		batch[i] = domain.SaveRequest{Order: order}
+	}
+	// locale - VARCHAR(10)
+	batch[1].Order.Locale = strings.Repeat("x", 100)
+
+	results, err := repo.SaveBatch(ctx, batch)
+	if err != nil {
+		t.Fatalf("SaveBatch() error = %v", err)
+	}
+
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("Valid orders failed: %v, %v", results[0].Err, results[2].Err)
+	}
+	if results[1].Err == nil {
+		t.Error("Expected an error for the order that does not fit the schema")
+	}
+	if _, err := repo.GetByID(ctx, batch[1].Order.OrderUID); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("Failed order must not be saved, got %v", err)
+	}
+	if count, err := repo.Count(ctx); err != nil || count != 2 {
+		t.Errorf("Count() = %d, %v; want 2", count, err)
+	}
+
+	if _, err := repo.SaveBatch(ctx, []domain.SaveRequest{{Order: batch[0].Order, Options: domain.SaveOptions{ExpectedVersion: 1}}}); err == nil {
+		t.Error("Expected SaveBatch to reject ExpectedVersion")
+	}
+}
+
+// TestOrderRepository_SaveBatchConnectionError - без соединения заказы по одному
+// не сохраняются: ошибка возвращается для всего пакета
+func TestOrderRepository_SaveBatchConnectionError(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+
+	_ = db.Close()
+	results, err := repo.SaveBatch(context.Background(), []domain.SaveRequest{{Order: order}})
+	if err == nil || results != nil {
+		t.Errorf("SaveBatch() = %v, %v; want a batch error", results, err)
+	}
+}
+
+func TestIsRowError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"value too long", &pq.Error{Code: "22001"}, true},
+		{"unique violation", fmt.Errorf("failed to save order: %w", &pq.Error{Code: "23505"}), true},
+		{"deadlock", &pq.Error{Code: "40P01"}, false},
+		{"connection", driver.ErrBadConn, false},
+		{"closed database", sql.ErrConnDone, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRowError(tt.err); got != tt.want {
+				t.Errorf("isRowError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"RWB_L0/internal/domain"
+)
+
+// relationsBatchSize - сколько заказов догружается одним запросом к связанным таблицам
+// (переменная - для тестов)
+var relationsBatchSize = 1000
+
+// Колонки таблиц в порядке сканирования (и вставки в Save).
+// Названия совпадают с json-тегами полей доменных моделей - это проверяет тест
+const (
+	orderColumns = `
+	order_uid, track_number, entry, locale, internal_signature,
+	customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, status, version
+`
+	deliveryColumns = `name, phone, zip, city, address, region, email`
+	paymentColumns  = `transaction, request_id, currency, provider, amount, payment_dt,
+		bank, delivery_cost, goods_total, custom_fee`
+	itemColumns = `chrt_id, track_number, price, rid, name, sale, size,
+		total_price, nm_id, brand, status`
+)
+
+// querier - *sql.DB или *sql.Tx: заказ читается и вне транзакции, и внутри неё
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryOrders - выполняет запрос к orders (SELECT orderColumns ...) и догружает
+// доставку, платёж и товары за постоянное число запросов, а не по 3 на заказ
+func queryOrders(ctx context.Context, q querier, query string, args ...interface{}) ([]*domain.Order, error) {
+	orders, err := scanOrders(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadOrderRelations(ctx, q, orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// scanOrders - только строки orders, без связанных таблиц (их догружает loadOrderRelations)
+func scanOrders(ctx context.Context, q querier, query string, args ...interface{}) ([]*domain.Order, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	orders := make([]*domain.Order, 0)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return orders, nil
+}
+
+// loadOrderRelations - доставка, платёж и товары заказов: по 3 запроса на каждые
+// relationsBatchSize заказов
+func loadOrderRelations(ctx context.Context, q querier, orders []*domain.Order) error {
+	for start := 0; start < len(orders); start += relationsBatchSize {
+		end := min(start+relationsBatchSize, len(orders))
+		if err := loadRelations(ctx, q, orders[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanOrder - сканирует строку orders в доменную модель
+func scanOrder(rows *sql.Rows) (*domain.Order, error) {
+	order := &domain.Order{Items: make([]domain.Item, 0)}
+	err := rows.Scan(
+		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale,
+		&order.InternalSignature, &order.CustomerID, &order.DeliveryService,
+		&order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard,
+		&order.Status, &order.Version,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan order: %w", err)
+	}
+	return order, nil
+}
+
+// loadRelations - догружает deliveries, payments и items для набора заказов (3 запроса)
+func loadRelations(ctx context.Context, q querier, orders []*domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byUID := make(map[string]*domain.Order, len(orders))
+	uids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		byUID[order.OrderUID] = order
+		uids = append(uids, order.OrderUID)
+	}
+
+	if err := loadDeliveries(ctx, q, uids, byUID); err != nil {
+		return err
+	}
+	if err := loadPayments(ctx, q, uids, byUID); err != nil {
+		return err
+	}
+	return loadItems(ctx, q, uids, byUID)
+}
+
+// loadDeliveries - доставки для набора заказов
+func loadDeliveries(ctx context.Context, q querier, uids []string, byUID map[string]*domain.Order) error {
+	query := `
+		SELECT order_uid, ` + deliveryColumns + `
+		FROM deliveries
+		WHERE order_uid = ANY($1)
+	`
+	rows, err := q.QueryContext(ctx, query, pq.Array(uids))
+	if err != nil {
+		return fmt.Errorf("failed to get deliveries: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	for rows.Next() {
+		var uid string
+		var delivery domain.Delivery
+		err = rows.Scan(
+			&uid, &delivery.Name, &delivery.Phone, &delivery.Zip,
+			&delivery.City, &delivery.Address, &delivery.Region, &delivery.Email,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan delivery: %w", err)
+		}
+		if order, ok := byUID[uid]; ok {
+			order.Delivery = delivery
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("deliveries rows error: %w", err)
+	}
+	return nil
+}
+
+// loadPayments - платежи для набора заказов
+func loadPayments(ctx context.Context, q querier, uids []string, byUID map[string]*domain.Order) error {
+	query := `
+		SELECT order_uid, ` + paymentColumns + `
+		FROM payments
+		WHERE order_uid = ANY($1)
+	`
+	rows, err := q.QueryContext(ctx, query, pq.Array(uids))
+	if err != nil {
+		return fmt.Errorf("failed to get payments: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	for rows.Next() {
+		var uid string
+		var payment domain.Payment
+		err = rows.Scan(
+			&uid, &payment.Transaction, &payment.RequestID, &payment.Currency,
+			&payment.Provider, &payment.Amount, &payment.PaymentDt,
+			&payment.Bank, &payment.DeliveryCost, &payment.GoodsTotal,
+			&payment.CustomFee,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan payment: %w", err)
+		}
+		if order, ok := byUID[uid]; ok {
+			order.Payment = payment
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("payments rows error: %w", err)
+	}
+	return nil
+}
+
+// loadItems - товары для набора заказов (в порядке вставки)
+func loadItems(ctx context.Context, q querier, uids []string, byUID map[string]*domain.Order) error {
+	query := `
+		SELECT order_uid, ` + itemColumns + `
+		FROM items
+		WHERE order_uid = ANY($1)
+		ORDER BY order_uid, id
+	`
+	rows, err := q.QueryContext(ctx, query, pq.Array(uids))
+	if err != nil {
+		return fmt.Errorf("failed to get items: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+
+		}
+	}(rows)
+
+	for rows.Next() {
+		var uid string
+		var item domain.Item
+		err = rows.Scan(
+			&uid, &item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid,
+			&item.Name, &item.Sale, &item.Size, &item.TotalPrice, &item.NmID,
+			&item.Brand, &item.Status,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan item: %w", err)
+		}
+		if order, ok := byUID[uid]; ok {
+			order.Items = append(order.Items, item)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("items rows error: %w", err)
+	}
+	return nil
+}
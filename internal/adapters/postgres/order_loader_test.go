@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"RWB_L0/internal/domain"
+)
+
+// recordingQuerier - querier, запоминающий order_uid каждого запроса к связанным таблицам
+type recordingQuerier struct {
+	q       querier
+	batches [][]string
+}
+
+func (r *recordingQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if len(args) == 1 {
+		if uids, ok := args[0].(*pq.StringArray); ok {
+			r.batches = append(r.batches, append([]string(nil), *uids...))
+		}
+	}
+	return r.q.QueryContext(ctx, query, args...)
+}
+
+// loaded - все order_uid, для которых читались связанные таблицы, по алфавиту
+func (r *recordingQuerier) loaded() []string {
+	seen := make(map[string]bool)
+	var uids []string
+	for _, batch := range r.batches {
+		for _, uid := range batch {
+			if !seen[uid] {
+				seen[uid] = true
+				uids = append(uids, uid)
+			}
+		}
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+// saveFilledOrders - n сохранённых заказов со всеми заполненными полями
+func saveFilledOrders(t *testing.T, repo *OrderRepository, n int) map[string]*domain.Order {
+	t.Helper()
+
+	saved := make(map[string]*domain.Order, n)
+	seed := 0
+	for i := 0; i < n; i++ {
+		order := &domain.Order{}
+		fillValue(reflect.ValueOf(order).Elem(), &seed)
+		if _, err := repo.Save(context.Background(), order, domain.SaveOptions{}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		saved[order.OrderUID] = order
+	}
+	return saved
+}
+
+// checkOrders - прочитанные заказы совпадают с сохранёнными во всех полях
+func checkOrders(t *testing.T, saved map[string]*domain.Order, got []*domain.Order) {
+	t.Helper()
+
+	for _, order := range got {
+		var diffs []string
+		diffValues(order.OrderUID, reflect.ValueOf(saved[order.OrderUID]).Elem(), reflect.ValueOf(order).Elem(), &diffs)
+		for _, diff := range diffs {
+			t.Error(diff)
+		}
+	}
+}
+
+// TestLoadOrderRelations_Batches - связанные таблицы читаются пачками по relationsBatchSize
+// заказов (3 запроса на пачку), и каждый заказ получает свои доставку, платёж и товары
+func TestLoadOrderRelations_Batches(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	prev := relationsBatchSize
+	relationsBatchSize = 2
+	t.Cleanup(func() { relationsBatchSize = prev })
+
+	saved := saveFilledOrders(t, repo, 5)
+
+	orders, err := scanOrders(ctx, db, `SELECT `+orderColumns+` FROM orders`)
+	if err != nil {
+		t.Fatalf("scanOrders() error = %v", err)
+	}
+	recorder := &recordingQuerier{q: db}
+	if err := loadOrderRelations(ctx, recorder, orders); err != nil {
+		t.Fatalf("loadOrderRelations() error = %v", err)
+	}
+
+	// 5 заказов - 3 пачки (2, 2, 1), по запросу к deliveries, payments и items
+	if len(recorder.batches) != 9 {
+		t.Errorf("Expected 9 queries, got %d: %v", len(recorder.batches), recorder.batches)
+	}
+	for _, batch := range recorder.batches {
+		if len(batch) > 2 {
+			t.Errorf("Batch of %d orders exceeds relationsBatchSize: %v", len(batch), batch)
+		}
+	}
+	if len(recorder.loaded()) != len(saved) {
+		t.Errorf("Expected relations of %d orders, got %v", len(saved), recorder.loaded())
+	}
+	checkOrders(t, saved, orders)
+}
+
+// TestListOrders_SkipsLookAheadRelations - запись сверх Limit нужна только для курсора:
+// связанные таблицы читаются лишь для заказов страницы
+func TestListOrders_SkipsLookAheadRelations(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	saved := saveFilledOrders(t, repo, 3)
+
+	recorder := &recordingQuerier{q: db}
+	page, err := listOrders(ctx, recorder, &domain.OrderListQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("listOrders() error = %v", err)
+	}
+	if len(page.Orders) != 2 || page.NextCursor == nil {
+		t.Fatalf("Expected 2 orders and a next cursor, got %d orders, cursor %v", len(page.Orders), page.NextCursor)
+	}
+
+	var onPage []string
+	for _, order := range page.Orders {
+		onPage = append(onPage, order.OrderUID)
+	}
+	sort.Strings(onPage)
+	if loaded := recorder.loaded(); !reflect.DeepEqual(loaded, onPage) {
+		t.Errorf("Expected relations only for %v, got %v", onPage, loaded)
+	}
+	checkOrders(t, saved, page.Orders)
+}
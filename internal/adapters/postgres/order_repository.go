@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
 
 	"RWB_L0/internal/domain"
 )
@@ -19,12 +21,17 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-// Save - сохранить заказ в БД (нормализованная структура - 4 таблицы)
-func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
+// Save - сохранить заказ в БД (нормализованная структура - 4 таблицы).
+// Если заказ с таким order_uid уже есть, решение принимает opts.Policy:
+// заказ заменяется целиком (все 4 таблицы) либо остаётся как есть.
+// Строка заказа блокируется до конца транзакции, поэтому параллельные Save
+// одного заказа выполняются по очереди. После сохранения order.Version - версия в БД.
+// Сообщение NATS (opts.Origin.Subject), которое уже сохранялось, даёт domain.SaveDuplicate
+func (r *OrderRepository) Save(ctx context.Context, order *domain.Order, opts domain.SaveOptions) (domain.SaveOutcome, error) {
 	// Начинаем транзакцию
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func(tx *sql.Tx) {
 		err := tx.Rollback()
@@ -32,251 +39,348 @@ func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
 		}
 	}(tx)
 
-	// 1. Сохраняем основную информацию о заказе
-	queryOrder := `
-		INSERT INTO orders (
-			order_uid, track_number, entry, locale, internal_signature,
-			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		ON CONFLICT (order_uid) DO UPDATE SET
-			track_number = EXCLUDED.track_number,
-			entry = EXCLUDED.entry,
-			locale = EXCLUDED.locale
-	`
-	_, err = tx.ExecContext(ctx, queryOrder,
-		order.OrderUID, order.TrackNumber, order.Entry, order.Locale,
-		order.InternalSignature, order.CustomerID, order.DeliveryService,
-		order.Shardkey, order.SmID, order.DateCreated, order.OofShard,
-	)
+	outcome, err := r.saveTx(ctx, tx, order, opts)
 	if err != nil {
-		return fmt.Errorf("failed to save order: %w", err)
-	}
-
-	// 2. Сохраняем информацию о доставке
-	queryDelivery := `
-		INSERT INTO deliveries (
-			order_uid, name, phone, zip, city, address, region, email
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (order_uid) DO UPDATE SET
-			name = EXCLUDED.name,
-			phone = EXCLUDED.phone,
-			zip = EXCLUDED.zip,
-			city = EXCLUDED.city,
-			address = EXCLUDED.address,
-			region = EXCLUDED.region,
-			email = EXCLUDED.email
-	`
-	_, err = tx.ExecContext(ctx, queryDelivery,
-		order.OrderUID, order.Delivery.Name, order.Delivery.Phone,
-		order.Delivery.Zip, order.Delivery.City, order.Delivery.Address,
-		order.Delivery.Region, order.Delivery.Email,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to save delivery: %w", err)
-	}
-
-	// 3. Сохраняем информацию о платеже
-	queryPayment := `
-		INSERT INTO payments (
-			order_uid, transaction, request_id, currency, provider,
-			amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		ON CONFLICT (order_uid) DO UPDATE SET
-			transaction = EXCLUDED.transaction,
-			amount = EXCLUDED.amount,
-			payment_dt = EXCLUDED.payment_dt
-	`
-	_, err = tx.ExecContext(ctx, queryPayment,
-		order.OrderUID, order.Payment.Transaction, order.Payment.RequestID,
-		order.Payment.Currency, order.Payment.Provider, order.Payment.Amount,
-		order.Payment.PaymentDt, order.Payment.Bank, order.Payment.DeliveryCost,
-		order.Payment.GoodsTotal, order.Payment.CustomFee,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to save payment: %w", err)
+		return "", err
+	}
+
+	// Коммитим транзакцию
+	if err = tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// 4. Удаляем старые товары (для обновления)
-	_, err = tx.ExecContext(ctx, "DELETE FROM items WHERE order_uid = $1", order.OrderUID)
+	return outcome, nil
+}
+
+// saveTx - сохранение заказа внутри транзакции
+func (r *OrderRepository) saveTx(ctx context.Context, tx *sql.Tx, order *domain.Order, opts domain.SaveOptions) (domain.SaveOutcome, error) {
+	// 0. Повторно доставленное сообщение NATS уже сохранено - ничего не делаем
+	if opts.Origin.Subject != "" {
+		first, err := markProcessed(ctx, tx, opts.Origin)
+		if err != nil {
+			return "", err
+		}
+		if !first {
+			return domain.SaveDuplicate, nil
+		}
+	}
+
+	// 1. Пробуем вставить заказ; если он уже есть, блокируем строку до конца транзакции
+	created, err := insertOrder(ctx, tx, order, opts.Origin)
 	if err != nil {
-		return fmt.Errorf("failed to delete old items: %w", err)
+		return "", err
 	}
 
-	// 5. Сохраняем товары
-	queryItem := `
-		INSERT INTO items (
-			order_uid, chrt_id, track_number, price, name, sale,
-			size, total_price, nm_id, brand, status
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`
-	for _, item := range order.Items {
-		_, err = tx.ExecContext(ctx, queryItem,
-			order.OrderUID, item.ChrtID, item.TrackNumber, item.Price,
-			item.Name, item.Sale, item.Size, item.TotalPrice,
-			item.NmID, item.Brand, item.Status,
-		)
+	outcome := domain.SaveCreated
+	if created {
+		if opts.ExpectedVersion != 0 {
+			// Ожидалось обновление существующего заказа
+			return "", domain.ErrOrderNotFound
+		}
+		order.Version = 1
+		order.Status = domain.StatusCreated
+	} else {
+		stored, err := lockOrder(ctx, tx, order.OrderUID)
 		if err != nil {
-			return fmt.Errorf("failed to save item: %w", err)
+			return "", err
+		}
+		if opts.ExpectedVersion != 0 && stored.Version != opts.ExpectedVersion {
+			return "", domain.ErrVersionConflict
+		}
+
+		outcome = opts.Policy.Resolve(stored.sourceSequence, opts.Origin)
+		if outcome == domain.SaveIgnored {
+			order.Version = stored.Version
+			return outcome, nil
 		}
+
+		if err := updateOrder(ctx, tx, order, stored.Version, opts.Origin); err != nil {
+			return "", err
+		}
+		order.Version = stored.Version + 1
+		order.Status = stored.Status // Замена заказа статус не меняет
 	}
 
-	// Коммитим транзакцию
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	// 2-5. Доставка, платёж, товары, история и событие для внешних систем
+	saved := []savedOrder{{order: order, outcome: outcome, origin: opts.Origin}}
+	if err := writeOrderData(ctx, tx, saved); err != nil {
+		return "", err
 	}
 
-	return nil
+	return outcome, nil
 }
 
-// GetByID - получить заказ по ID (JOIN 4 таблиц)
-func (r *OrderRepository) GetByID(ctx context.Context, orderUID string) (*domain.Order, error) {
-	// Получаем основную информацию о заказе
-	queryOrder := `
-		SELECT 
-			order_uid, track_number, entry, locale, internal_signature,
-			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-		FROM orders
-		WHERE order_uid = $1
+// markProcessed - отметка об обработке сообщения NATS; false, если оно уже обработано.
+// Отметка откатывается вместе с транзакцией, если сохранить заказ не удалось
+func markProcessed(ctx context.Context, tx *sql.Tx, origin domain.ChangeOrigin) (bool, error) {
+	query := `
+		INSERT INTO processed_messages (subject, sequence)
+		VALUES ($1, $2)
+		ON CONFLICT (subject, sequence) DO NOTHING
 	`
+	result, err := tx.ExecContext(ctx, query, origin.Subject, int64(origin.Sequence))
+	if err != nil {
+		return false, fmt.Errorf("failed to mark message processed: %w", err)
+	}
 
-	var order domain.Order
-	err := r.db.QueryRowContext(ctx, queryOrder, orderUID).Scan(
-		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale,
-		&order.InternalSignature, &order.CustomerID, &order.DeliveryService,
-		&order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard,
-	)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, domain.ErrOrderNotFound
-		}
-		return nil, fmt.Errorf("failed to get order: %w", err)
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
 	}
+	return rowsAffected == 1, nil
+}
 
-	// Получаем информацию о доставке
-	queryDelivery := `
-		SELECT name, phone, zip, city, address, region, email
-		FROM deliveries
-		WHERE order_uid = $1
+// insertOrder - вставка строки orders (версия 1, статус created); false, если заказ уже существует
+func insertOrder(ctx context.Context, tx *sql.Tx, order *domain.Order, origin domain.ChangeOrigin) (bool, error) {
+	query := `
+		INSERT INTO orders (` + orderColumns + `, source_sequence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, 1, $13)
+		ON CONFLICT (order_uid) DO NOTHING
 	`
-	err = r.db.QueryRowContext(ctx, queryDelivery, orderUID).Scan(
-		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip,
-		&order.Delivery.City, &order.Delivery.Address, &order.Delivery.Region,
-		&order.Delivery.Email,
+	result, err := tx.ExecContext(ctx, query,
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale,
+		order.InternalSignature, order.CustomerID, order.DeliveryService,
+		order.Shardkey, order.SmID, order.DateCreated, order.OofShard, domain.StatusCreated,
+		sourceSequence(origin),
 	)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("failed to get delivery: %w", err)
+	if err != nil {
+		return false, fmt.Errorf("failed to save order: %w", err)
 	}
 
-	// Получаем информацию о платеже
-	queryPayment := `
-		SELECT transaction, request_id, currency, provider, amount, payment_dt,
-		       bank, delivery_cost, goods_total, custom_fee
-		FROM payments
-		WHERE order_uid = $1
-	`
-	err = r.db.QueryRowContext(ctx, queryPayment, orderUID).Scan(
-		&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency,
-		&order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDt,
-		&order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal,
-		&order.Payment.CustomFee,
-	)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("failed to get payment: %w", err)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	// Получаем товары
-	queryItems := `
-		SELECT chrt_id, track_number, price, name, sale, size,
-		       total_price, nm_id, brand, status
-		FROM items
-		WHERE order_uid = $1
+	return rowsAffected == 1, nil
+}
+
+// lockedOrder - сохранённая версия заказа (только поля orders) и номер сообщения NATS,
+// последним записавшего его данные (0 - не из NATS)
+type lockedOrder struct {
+	*domain.Order
+	sourceSequence uint64
+}
+
+// lockOrder - сохранённая версия заказа с блокировкой строки
+func lockOrder(ctx context.Context, tx *sql.Tx, orderUID string) (*lockedOrder, error) {
+	query := `SELECT date_created, status, version, source_sequence FROM orders WHERE order_uid = $1 FOR UPDATE`
+
+	stored := &lockedOrder{Order: &domain.Order{OrderUID: orderUID}}
+	var sequence sql.NullInt64
+	err := tx.QueryRowContext(ctx, query, orderUID).Scan(&stored.DateCreated, &stored.Status, &stored.Version, &sequence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock order: %w", err)
+	}
+	stored.sourceSequence = uint64(sequence.Int64)
+
+	return stored, nil
+}
+
+// sourceSequence - номер сообщения NATS для orders.source_sequence (NULL - изменение не из NATS)
+func sourceSequence(origin domain.ChangeOrigin) sql.NullInt64 {
+	if origin.Sequence == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(origin.Sequence), Valid: true}
+}
+
+// updateOrder - полная замена строки orders, если её версия всё ещё version (compare-and-swap).
+// Изменение не из NATS номер сообщения, записавшего заказ, не сбрасывает
+func updateOrder(ctx context.Context, tx *sql.Tx, order *domain.Order, version int64, origin domain.ChangeOrigin) error {
+	query := `
+		UPDATE orders SET
+			track_number = $2,
+			entry = $3,
+			locale = $4,
+			internal_signature = $5,
+			customer_id = $6,
+			delivery_service = $7,
+			shardkey = $8,
+			sm_id = $9,
+			date_created = $10,
+			oof_shard = $11,
+			source_sequence = COALESCE($13, source_sequence),
+			version = version + 1
+		WHERE order_uid = $1 AND version = $12
 	`
-	rows, err := r.db.QueryContext(ctx, queryItems, orderUID)
+	result, err := tx.ExecContext(ctx, query,
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale,
+		order.InternalSignature, order.CustomerID, order.DeliveryService,
+		order.Shardkey, order.SmID, order.DateCreated, order.OofShard,
+		version, sourceSequence(origin),
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get items: %w", err)
+		return fmt.Errorf("failed to update order: %w", err)
 	}
-	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
 
-		}
-	}(rows)
-
-	order.Items = make([]domain.Item, 0)
-	for rows.Next() {
-		var item domain.Item
-		err = rows.Scan(
-			&item.ChrtID, &item.TrackNumber, &item.Price, &item.Name,
-			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID,
-			&item.Brand, &item.Status,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan item: %w", err)
-		}
-		order.Items = append(order.Items, item)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
+	if rowsAffected == 0 {
+		return domain.ErrVersionConflict
+	}
+
+	return nil
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("items rows error: %w", err)
+// GetByID - получить заказ по ID (4 таблицы)
+func (r *OrderRepository) GetByID(ctx context.Context, orderUID string) (*domain.Order, error) {
+	return getOrder(ctx, r.db, orderUID)
+}
+
+// getOrder - заказ со связанными таблицами; в транзакции - как его видит транзакция
+func getOrder(ctx context.Context, q querier, orderUID string) (*domain.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE order_uid = $1`
+
+	orders, err := queryOrders(ctx, q, query, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if len(orders) == 0 {
+		return nil, domain.ErrOrderNotFound
 	}
 
-	return &order, nil
+	return orders[0], nil
 }
 
-// GetAll - получить все заказы
+// GetAll - получить все заказы (4 запроса на каждые relationsBatchSize заказов)
 func (r *OrderRepository) GetAll(ctx context.Context) ([]*domain.Order, error) {
-	query := `
-		SELECT order_uid, track_number, entry, locale, internal_signature,
-		       customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+	query := `SELECT ` + orderColumns + ` FROM orders ORDER BY date_created DESC, order_uid DESC`
+
+	orders, err := queryOrders(ctx, r.db, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// List - получить страницу заказов (keyset-пагинация по date_created, order_uid)
+func (r *OrderRepository) List(ctx context.Context, query *domain.OrderListQuery) (*domain.OrderPage, error) {
+	return listOrders(ctx, r.db, query)
+}
+
+// listOrders - страница заказов; связанные таблицы догружаются только для заказов страницы
+func listOrders(ctx context.Context, q querier, query *domain.OrderListQuery) (*domain.OrderPage, error) {
+	where, args := buildListFilter(query)
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	args = append(args, query.Limit+1)
+	sqlQuery := fmt.Sprintf(`
+		SELECT %s
 		FROM orders
-		ORDER BY date_created DESC
-	`
+		%s
+		ORDER BY date_created DESC, order_uid DESC
+		LIMIT $%d
+	`, orderColumns, where, len(args))
 
-	rows, err := r.db.QueryContext(ctx, query)
+	orders, err := scanOrders(ctx, q, sqlQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query orders: %w", err)
+		return nil, fmt.Errorf("failed to list orders: %w", err)
 	}
-	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
 
-		}
-	}(rows)
+	// Лишняя запись нужна только для курсора: её связанные таблицы не читаем
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > query.Limit {
+		page.Orders = orders[:query.Limit]
+		page.NextCursor = domain.CursorOf(page.Orders[query.Limit-1])
+	}
+	if err := loadOrderRelations(ctx, q, page.Orders); err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
 
-	var orders []*domain.Order
+	return page, nil
+}
 
-	for rows.Next() {
-		var order domain.Order
-		err = rows.Scan(
-			&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale,
-			&order.InternalSignature, &order.CustomerID, &order.DeliveryService,
-			&order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan order: %w", err)
-		}
+// buildListFilter - формирует WHERE для List и список аргументов
+func buildListFilter(query *domain.OrderListQuery) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(expr string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(expr, len(args)))
+	}
+
+	filter := query.Filter
+	if filter.CustomerID != "" {
+		addCondition("customer_id = $%d", filter.CustomerID)
+	}
+	if filter.TrackNumber != "" {
+		addCondition("track_number = $%d", filter.TrackNumber)
+	}
+	if filter.DeliveryService != "" {
+		addCondition("delivery_service = $%d", filter.DeliveryService)
+	}
+	if filter.Entry != "" {
+		addCondition("entry = $%d", filter.Entry)
+	}
+	if filter.Locale != "" {
+		addCondition("locale = $%d", filter.Locale)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		addCondition("date_created >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		addCondition("date_created < $%d", filter.CreatedTo)
+	}
 
-		// Получаем связанные данные для каждого заказа
-		fullOrder, err := r.GetByID(ctx, order.OrderUID)
+	// Keyset: всё, что идёт после курсора в порядке (date_created DESC, order_uid DESC)
+	if query.After != nil {
+		args = append(args, query.After.DateCreated, query.After.OrderUID)
+		conditions = append(conditions,
+			fmt.Sprintf("(date_created, order_uid) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Delete - удалить заказ (каскадное удаление из всех связанных таблиц), если его
+// версия равна expectedVersion (0 - любая). Заказ перед удалением записывается в историю;
+// если версия другая или заказ изменился между чтением и удалением, возвращается
+// domain.ErrVersionConflict, если удалён - ErrOrderNotFound
+func (r *OrderRepository) Delete(ctx context.Context, orderUID string, expectedVersion int64, origin domain.ChangeOrigin) error {
+	// Снимок читается до транзакции: удаление проверяет, что версия не изменилась
+	order, err := r.GetByID(ctx, orderUID)
+	if err != nil {
+		return err
+	}
+	if expectedVersion > 0 && order.Version != expectedVersion {
+		return domain.ErrVersionConflict
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
 		if err != nil {
-			return nil, err
 		}
+	}(tx)
 
-		orders = append(orders, fullOrder)
+	if err := deleteOrder(ctx, tx, order, origin); err != nil {
+		return err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return orders, nil
+	return nil
 }
 
-// Delete - удалить заказ (каскадное удаление из всех связанных таблиц)
-func (r *OrderRepository) Delete(ctx context.Context, orderUID string) error {
-	query := `DELETE FROM orders WHERE order_uid = $1`
+// deleteOrder - удаление заказа, прочитанного как order, если его версия не изменилась.
+// ErrOrderNotFound - заказ уже удалён (например, параллельным запросом), ErrVersionConflict - изменён
+func deleteOrder(ctx context.Context, tx *sql.Tx, order *domain.Order, origin domain.ChangeOrigin) error {
+	query := `DELETE FROM orders WHERE order_uid = $1 AND version = $2`
 
-	result, err := r.db.ExecContext(ctx, query, orderUID)
+	result, err := tx.ExecContext(ctx, query, order.OrderUID, order.Version)
 	if err != nil {
 		return fmt.Errorf("failed to delete order: %w", err)
 	}
@@ -287,10 +391,62 @@ func (r *OrderRepository) Delete(ctx context.Context, orderUID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return domain.ErrOrderNotFound
+		// Заказ либо изменён, либо уже удалён параллельным запросом
+		var exists bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE order_uid = $1)`, order.OrderUID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check order: %w", err)
+		}
+		if !exists {
+			return domain.ErrOrderNotFound
+		}
+		return domain.ErrVersionConflict
 	}
 
-	return nil
+	row, err := historyRow(order, domain.ChangeDeleted, origin)
+	if err != nil {
+		return err
+	}
+	return insertHistory(ctx, tx, [][]interface{}{row})
+}
+
+// BackfillItemRids - дозаполняет пустой rid у уже сохранённых товаров заказа
+// (товары, записанные до появления колонки rid). Товары сопоставляются по позиции
+// в заказе и chrt_id, непустой rid не перезаписывается. Возвращает число обновлённых товаров
+func (r *OrderRepository) BackfillItemRids(ctx context.Context, order *domain.Order) (int64, error) {
+	chrtIDs := make([]int64, 0, len(order.Items))
+	rids := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		chrtIDs = append(chrtIDs, int64(item.ChrtID))
+		rids = append(rids, item.Rid)
+	}
+
+	query := `
+		WITH existing AS (
+			SELECT id, chrt_id, row_number() OVER (ORDER BY id) AS pos
+			FROM items
+			WHERE order_uid = $1
+		)
+		UPDATE items
+		SET rid = incoming.rid
+		FROM existing
+		JOIN unnest($2::BIGINT[], $3::TEXT[]) WITH ORDINALITY AS incoming(chrt_id, rid, pos)
+			ON incoming.pos = existing.pos AND incoming.chrt_id = existing.chrt_id
+		WHERE items.id = existing.id
+			AND items.rid = ''
+			AND incoming.rid <> ''
+	`
+	result, err := r.db.ExecContext(ctx, query, order.OrderUID, pq.Array(chrtIDs), pq.Array(rids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to backfill item rids: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return updated, nil
 }
 
 // Count - получить количество заказов
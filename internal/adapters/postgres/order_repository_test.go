@@ -0,0 +1,490 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"RWB_L0/internal/domain"
+)
+
+// Каждое поле доменной модели должно иметь колонку с тем же именем, что и json-тег,
+// и наоборот. Поле, добавленное в модель без колонки, молча терялось бы при сохранении
+func TestColumnsCoverDomainFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   interface{}
+		columns string
+	}{
+		{"orders", domain.Order{}, orderColumns},
+		{"deliveries", domain.Delivery{}, deliveryColumns},
+		{"payments", domain.Payment{}, paymentColumns},
+		{"items", domain.Item{}, itemColumns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns := make(map[string]bool)
+			for _, column := range strings.Split(tt.columns, ",") {
+				columns[strings.TrimSpace(column)] = true
+			}
+
+			fields := scalarJSONFields(reflect.TypeOf(tt.model))
+			for _, field := range fields {
+				if !columns[field] {
+					t.Errorf("Field %q has no column in %s", field, tt.name)
+				}
+				delete(columns, field)
+			}
+			for column := range columns {
+				t.Errorf("Column %q has no field in the domain model", column)
+			}
+		})
+	}
+}
+
+// TestOrderRepository_RoundTrip - заказ, у которого заполнены все поля,
+// должен читаться из БД в точности таким, каким был сохранён
+func TestOrderRepository_RoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	want := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(want).Elem(), &seed)
+
+	if _, err := repo.Save(ctx, want, domain.SaveOptions{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := repo.GetByID(ctx, want.OrderUID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+
+	var diffs []string
+	diffValues("order", reflect.ValueOf(want).Elem(), reflect.ValueOf(got).Elem(), &diffs)
+	for _, diff := range diffs {
+		t.Error(diff)
+	}
+}
+
+// TestOrderRepository_SaveReplacesEverything - повторная доставка заменяет
+// все поля всех четырёх таблиц, а политики reject и newer (для более старого сообщения)
+// её отбрасывают
+func TestOrderRepository_SaveReplacesEverything(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	first := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(first).Elem(), &seed)
+
+	// Тот же заказ, но с другими значениями всех полей и одним товаром
+	second := &domain.Order{}
+	fillValue(reflect.ValueOf(second).Elem(), &seed)
+	second.OrderUID = first.OrderUID
+	second.Items = second.Items[:1]
+
+	// Заказ записан сообщением NATS с номером 10; newer сравнивает номера сообщений
+	nats := func(seq uint64) domain.ChangeOrigin {
+		return domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Sequence: seq}
+	}
+	if outcome, err := repo.Save(ctx, first, domain.SaveOptions{Origin: nats(10)}); err != nil || outcome != domain.SaveCreated {
+		t.Fatalf("Save(first) = %q, %v", outcome, err)
+	}
+
+	// Сообщение новее, но reject всё равно отбрасывает
+	if outcome, err := repo.Save(ctx, second, domain.SaveOptions{Policy: domain.UpsertReject, Origin: nats(11)}); err != nil || outcome != domain.SaveIgnored {
+		t.Fatalf("Save(second, reject) = %q, %v", outcome, err)
+	}
+	if outcome, err := repo.Save(ctx, second, domain.SaveOptions{Policy: domain.UpsertNewer, Origin: nats(9)}); err != nil || outcome != domain.SaveIgnored {
+		t.Fatalf("Save(second, newer, older message) = %q, %v", outcome, err)
+	}
+
+	if outcome, err := repo.Save(ctx, second, domain.SaveOptions{Policy: domain.UpsertNewer, Origin: nats(11)}); err != nil || outcome != domain.SaveUpdated {
+		t.Fatalf("Save(second, newer, newer message) = %q, %v", outcome, err)
+	}
+
+	got, err := repo.GetByID(ctx, first.OrderUID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	var diffs []string
+	diffValues("order", reflect.ValueOf(second).Elem(), reflect.ValueOf(got).Elem(), &diffs)
+	for _, diff := range diffs {
+		t.Error(diff)
+	}
+}
+
+// TestOrderRepository_NewerAfterSourceSequenceMigration - миграция 000014 не переносит
+// номера из order_history (там могут быть номера NATS Streaming): первое сообщение
+// JetStream с меньшим номером заменяет заказ, записанный до миграции
+func TestOrderRepository_NewerAfterSourceSequenceMigration(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+	stan := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Sequence: 500}
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{Origin: stan}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Заказ и его история - как до миграции 000014
+	migration, err := os.ReadFile(filepath.Join("..", "..", "..", "migrations", "000014_add_orders_source_sequence.up.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if _, err := db.Exec(`ALTER TABLE orders DROP COLUMN source_sequence`); err != nil {
+		t.Fatalf("Failed to drop source_sequence: %v", err)
+	}
+	if _, err := db.Exec(string(migration)); err != nil {
+		t.Fatalf("Failed to apply migration: %v", err)
+	}
+
+	jetStream := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Sequence: 1}
+	outcome, err := repo.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertNewer, Origin: jetStream})
+	if err != nil || outcome != domain.SaveUpdated {
+		t.Fatalf("Save(newer, sequence 1) = %q, %v, want %q", outcome, err, domain.SaveUpdated)
+	}
+	outcome, err = repo.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertNewer, Origin: jetStream})
+	if err != nil || outcome != domain.SaveIgnored {
+		t.Fatalf("Save(newer, same sequence) = %q, %v, want %q", outcome, err, domain.SaveIgnored)
+	}
+}
+
+// TestOrderRepository_SaveExpectedVersion - обновление с устаревшей версией
+// отклоняется и не меняет заказ
+func TestOrderRepository_SaveExpectedVersion(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+
+	missing := *order
+	missing.OrderUID = "missing"
+	if _, err := repo.Save(ctx, &missing, domain.SaveOptions{ExpectedVersion: 1}); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Fatalf("Save(missing, version 1) error = %v, want ErrOrderNotFound", err)
+	}
+
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if order.Version != 1 {
+		t.Fatalf("Expected version 1 after create, got %d", order.Version)
+	}
+
+	order.Entry = "updated"
+	opts := domain.SaveOptions{Policy: domain.UpsertReplace, ExpectedVersion: 1}
+	if outcome, err := repo.Save(ctx, order, opts); err != nil || outcome != domain.SaveUpdated {
+		t.Fatalf("Save(version 1) = %q, %v", outcome, err)
+	}
+	if order.Version != 2 {
+		t.Fatalf("Expected version 2 after update, got %d", order.Version)
+	}
+
+	order.Entry = "stale"
+	if _, err := repo.Save(ctx, order, opts); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("Save(stale version) error = %v, want ErrVersionConflict", err)
+	}
+
+	got, err := repo.GetByID(ctx, order.OrderUID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Entry != "updated" || got.Version != 2 {
+		t.Errorf("Expected entry %q at version 2, got %q at %d", "updated", got.Entry, got.Version)
+	}
+}
+
+// TestOrderRepository_History - создание, изменение и удаление пишутся в историю,
+// которая переживает удаление заказа
+func TestOrderRepository_History(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+
+	nats := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Sequence: 42}
+	admin := domain.ChangeOrigin{Source: domain.ChangeSourceAdmin}
+
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{Origin: nats}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// Отброшенный дубликат в историю не попадает
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertReject, Origin: nats}); err != nil {
+		t.Fatalf("Save(reject) error = %v", err)
+	}
+	order.Entry = "updated"
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertReplace, Origin: admin}); err != nil {
+		t.Fatalf("Save(replace) error = %v", err)
+	}
+	if err := repo.Delete(ctx, order.OrderUID, 1, admin); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("Delete(stale version) error = %v, want ErrVersionConflict", err)
+	}
+	if err := repo.Delete(ctx, order.OrderUID, 2, admin); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	changes, err := repo.History(ctx, order.OrderUID)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	want := []domain.OrderChange{
+		{Action: domain.ChangeCreated, Source: domain.ChangeSourceNATS, Sequence: 42, Version: 1},
+		{Action: domain.ChangeUpdated, Source: domain.ChangeSourceAdmin, Version: 2},
+		{Action: domain.ChangeDeleted, Source: domain.ChangeSourceAdmin, Version: 2},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %d", len(want), len(changes))
+	}
+	for i, w := range want {
+		got := changes[i]
+		if got.Action != w.Action || got.Source != w.Source || got.Sequence != w.Sequence || got.Version != w.Version {
+			t.Errorf("changes[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+
+	var snapshot domain.Order
+	if err := json.Unmarshal(changes[2].Snapshot, &snapshot); err != nil {
+		t.Fatalf("Failed to unmarshal snapshot: %v", err)
+	}
+	var diffs []string
+	diffValues("snapshot", reflect.ValueOf(*order), reflect.ValueOf(snapshot), &diffs)
+	for _, diff := range diffs {
+		t.Error(diff)
+	}
+}
+
+// TestDeleteOrder_StaleSnapshot - снимок, прочитанный до удаления: заказ, удалённый
+// параллельным запросом, - ErrOrderNotFound, изменённый - ErrVersionConflict
+func TestDeleteOrder_StaleSnapshot(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+	admin := domain.ChangeOrigin{Source: domain.ChangeSourceAdmin}
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	stale, err := repo.GetByID(ctx, order.OrderUID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+
+	deleteStale := func() error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("BeginTx() error = %v", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		return deleteOrder(ctx, tx, stale, admin)
+	}
+
+	// Заказ изменён после чтения снимка
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertReplace, Origin: admin}); err != nil {
+		t.Fatalf("Save(replace) error = %v", err)
+	}
+	if err := deleteStale(); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("deleteOrder(changed) error = %v, want ErrVersionConflict", err)
+	}
+
+	// Заказ удалён после чтения снимка
+	if err := repo.Delete(ctx, order.OrderUID, 0, admin); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := deleteStale(); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("deleteOrder(deleted) error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+// TestOrderRepository_SaveRedelivery - сообщение NATS сохраняется один раз,
+// а отметка об обработке откатывается вместе с неудачным сохранением
+func TestOrderRepository_SaveRedelivery(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+	origin := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders", Sequence: 7}
+
+	// Ожидаемая версия не совпадает - транзакция откатывается целиком
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{ExpectedVersion: 5, Origin: origin}); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Fatalf("Save(failing) error = %v, want ErrOrderNotFound", err)
+	}
+
+	if outcome, err := repo.Save(ctx, order, domain.SaveOptions{Origin: origin}); err != nil || outcome != domain.SaveCreated {
+		t.Fatalf("Save() = %q, %v", outcome, err)
+	}
+	if outcome, err := repo.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertReplace, Origin: origin}); err != nil || outcome != domain.SaveDuplicate {
+		t.Fatalf("Save(redelivery) = %q, %v; want %q", outcome, err, domain.SaveDuplicate)
+	}
+
+	got, err := repo.GetByID(ctx, order.OrderUID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Version != 1 {
+		t.Errorf("Expected redelivery to leave version 1, got %d", got.Version)
+	}
+}
+
+func TestOrderRepository_BackfillItemRids(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+
+	// Сохраняем как до миграции: без rid
+	rids := make([]string, len(order.Items))
+	for i := range order.Items {
+		rids[i] = order.Items[i].Rid
+		order.Items[i].Rid = ""
+	}
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	for i := range order.Items {
+		order.Items[i].Rid = rids[i]
+	}
+	updated, err := repo.BackfillItemRids(ctx, order)
+	if err != nil {
+		t.Fatalf("BackfillItemRids() error = %v", err)
+	}
+	if updated != int64(len(order.Items)) {
+		t.Errorf("Expected %d items updated, got %d", len(order.Items), updated)
+	}
+
+	// Повторный запуск ничего не меняет
+	if updated, _ := repo.BackfillItemRids(ctx, order); updated != 0 {
+		t.Errorf("Expected backfill to be idempotent, got %d updates", updated)
+	}
+
+	got, err := repo.GetByID(ctx, order.OrderUID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	for i, item := range got.Items {
+		if item.Rid != rids[i] {
+			t.Errorf("items[%d].rid = %q, want %q", i, item.Rid, rids[i])
+		}
+	}
+}
+
+// scalarJSONFields - json-теги полей структуры, кроме вложенных структур и срезов
+// (они хранятся в отдельных таблицах)
+func scalarJSONFields(typ reflect.Type) []string {
+	timeType := reflect.TypeOf(time.Time{})
+
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Type.Kind() == reflect.Slice ||
+			(field.Type.Kind() == reflect.Struct && field.Type != timeType) {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// fillValue заполняет все поля уникальными непустыми значениями (срезы - двумя элементами)
+func fillValue(v reflect.Value, seed *int) {
+	*seed++
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(fmt.Sprintf("v%d", *seed))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(*seed))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(*seed))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			// Postgres хранит время с точностью до микросекунд
+			v.Set(reflect.ValueOf(time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC).Add(time.Duration(*seed) * time.Second)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			fillValue(v.Field(i), seed)
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		for i := 0; i < v.Len(); i++ {
+			fillValue(v.Index(i), seed)
+		}
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillValue(v.Elem(), seed)
+	}
+}
+
+// diffValues собирает пути полей, значения которых различаются
+func diffValues(path string, want, got reflect.Value, diffs *[]string) {
+	if want.Type() == reflect.TypeOf(time.Time{}) {
+		if !want.Interface().(time.Time).Equal(got.Interface().(time.Time)) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %v, want %v", path, got, want))
+		}
+		return
+	}
+
+	switch want.Kind() {
+	case reflect.Struct:
+		for i := 0; i < want.NumField(); i++ {
+			name := strings.Split(want.Type().Field(i).Tag.Get("json"), ",")[0]
+			diffValues(path+"."+name, want.Field(i), got.Field(i), diffs)
+		}
+	case reflect.Slice:
+		if want.Len() != got.Len() {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %d elements, want %d", path, got.Len(), want.Len()))
+			return
+		}
+		for i := 0; i < want.Len(); i++ {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), want.Index(i), got.Index(i), diffs)
+		}
+	case reflect.Ptr:
+		if want.IsNil() || got.IsNil() {
+			if want.IsNil() != got.IsNil() {
+				*diffs = append(*diffs, fmt.Sprintf("%s: got %v, want %v", path, got, want))
+			}
+			return
+		}
+		diffValues(path, want.Elem(), got.Elem(), diffs)
+	default:
+		if !reflect.DeepEqual(want.Interface(), got.Interface()) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %v, want %v", path, got, want))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/usecase"
+)
+
+// Проверка на этапе компиляции, что OutboxRepository подходит use case
+var _ usecase.OutboxRepository = (*OutboxRepository)(nil)
+
+// outboxRow - строка outbox с событием о сохранённом заказе
+func outboxRow(order *domain.Order, eventType domain.OrderEventType) ([]interface{}, error) {
+	payload, err := json.Marshal(domain.OrderEvent{
+		Type:       eventType,
+		OrderUID:   order.OrderUID,
+		Version:    order.Version,
+		Order:      order,
+		OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal order event: %w", err)
+	}
+
+	return []interface{}{eventType, order.OrderUID, payload}, nil
+}
+
+// insertOutbox - события о сохранённых заказах; пишутся в транзакции Save
+func insertOutbox(ctx context.Context, tx *sql.Tx, rows [][]interface{}) error {
+	head := `INSERT INTO outbox (event_type, order_uid, payload)`
+	if err := insertRows(ctx, tx, head, "", rows, nil); err != nil {
+		return fmt.Errorf("failed to save outbox event: %w", err)
+	}
+	return nil
+}
+
+// OutboxRepository - чтение и отметка событий transactional outbox
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository - создание репозитория outbox
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// ProcessPending - передаёт publish до limit неопубликованных событий в порядке id
+// и отмечает опубликованные. События блокируются до конца транзакции, поэтому
+// несколько экземпляров сервиса не публикуют одно событие параллельно.
+//
+// На первой ошибке publish обработка останавливается (события одного заказа не
+// обгоняют друг друга), у события увеличивается счётчик попыток. Если процесс упадёт
+// после publish, но до commit, событие будет опубликовано повторно (at-least-once)
+func (r *OutboxRepository) ProcessPending(ctx context.Context, limit int, publish func(*domain.OutboxEvent) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+		}
+	}(tx)
+
+	events, err := lockPendingEvents(ctx, tx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	published := make([]int64, 0, len(events))
+	var publishErr error
+	for _, event := range events {
+		if publishErr = publish(event); publishErr != nil {
+			query := `UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+			if _, err := tx.ExecContext(ctx, query, event.ID, publishErr.Error()); err != nil {
+				return 0, fmt.Errorf("failed to record outbox failure: %w", err)
+			}
+			publishErr = fmt.Errorf("failed to publish outbox event %d: %w", event.ID, publishErr)
+			break
+		}
+		published = append(published, event.ID)
+	}
+
+	if len(published) > 0 {
+		query := `UPDATE outbox SET published_at = NOW() WHERE id = ANY($1)`
+		if _, err := tx.ExecContext(ctx, query, pq.Array(published)); err != nil {
+			return 0, fmt.Errorf("failed to mark outbox events published: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(published), publishErr
+}
+
+// lockPendingEvents - неопубликованные события с блокировкой строк
+func lockPendingEvents(ctx context.Context, tx *sql.Tx, limit int) ([]*domain.OutboxEvent, error) {
+	query := `
+		SELECT id, event_type, order_uid, payload, attempts, created_at
+		FROM outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE
+	`
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+		}
+	}(rows)
+
+	events := make([]*domain.OutboxEvent, 0, limit)
+	for rows.Next() {
+		event := &domain.OutboxEvent{}
+		var payload []byte
+		err := rows.Scan(&event.ID, &event.Type, &event.OrderUID, &payload, &event.Attempts, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"RWB_L0/internal/domain"
+)
+
+// TestOutboxRepository_ProcessPending - Save пишет событие в outbox, событие
+// остаётся неопубликованным, пока publish не завершится успешно
+func TestOutboxRepository_ProcessPending(t *testing.T) {
+	db := openTestDB(t)
+	orders := NewOrderRepository(db)
+	outbox := NewOutboxRepository(db)
+	ctx := context.Background()
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+
+	if _, err := orders.Save(ctx, order, domain.SaveOptions{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := orders.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertReplace}); err != nil {
+		t.Fatalf("Save(replace) error = %v", err)
+	}
+
+	// Брокер недоступен: ничего не опубликовано
+	published, err := outbox.ProcessPending(ctx, 10, func(*domain.OutboxEvent) error {
+		return errors.New("broker unavailable")
+	})
+	if err == nil || published != 0 {
+		t.Fatalf("ProcessPending(failing) = %d, %v", published, err)
+	}
+
+	var events []domain.OrderEvent
+	published, err = outbox.ProcessPending(ctx, 10, func(event *domain.OutboxEvent) error {
+		var body domain.OrderEvent
+		if err := json.Unmarshal(event.Payload, &body); err != nil {
+			return err
+		}
+		events = append(events, body)
+		return nil
+	})
+	if err != nil || published != 2 {
+		t.Fatalf("ProcessPending() = %d, %v; want 2, nil", published, err)
+	}
+	if events[0].Type != domain.OrderEventCreated || events[0].Version != 1 ||
+		events[1].Type != domain.OrderEventUpdated || events[1].Version != 2 {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+	if events[1].Order == nil || events[1].Order.OrderUID != order.OrderUID {
+		t.Errorf("Expected event to carry the order, got %+v", events[1].Order)
+	}
+
+	published, err = outbox.ProcessPending(ctx, 10, func(*domain.OutboxEvent) error {
+		t.Error("Published event must not be delivered again")
+		return nil
+	})
+	if err != nil || published != 0 {
+		t.Errorf("ProcessPending(drained) = %d, %v", published, err)
+	}
+}
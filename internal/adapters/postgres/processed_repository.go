@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"RWB_L0/internal/usecase"
+)
+
+// Проверка на этапе компиляции, что ProcessedMessageRepository подходит use case
+var _ usecase.ProcessedMessageRepository = (*ProcessedMessageRepository)(nil)
+
+// ProcessedMessageRepository - отметки об обработанных сообщениях NATS (processed_messages).
+// Отметки пишутся в транзакции сохранения заказа (markProcessed), здесь - только их очистка
+type ProcessedMessageRepository struct {
+	db *sql.DB
+}
+
+// NewProcessedMessageRepository - создание репозитория отметок
+func NewProcessedMessageRepository(db *sql.DB) *ProcessedMessageRepository {
+	return &ProcessedMessageRepository{db: db}
+}
+
+// DeleteProcessedOlderThan - удаляет до limit отметок старше age. Время считается
+// по часам БД, как и processed_at. Возвращает число удалённых: меньше limit - старых
+// отметок больше нет
+func (r *ProcessedMessageRepository) DeleteProcessedOlderThan(ctx context.Context, age time.Duration, limit int) (int64, error) {
+	query := `
+		DELETE FROM processed_messages
+		WHERE (subject, sequence) IN (
+			SELECT subject, sequence
+			FROM processed_messages
+			WHERE processed_at < NOW() - make_interval(secs => $1)
+			LIMIT $2
+		)
+	`
+	result, err := r.db.ExecContext(ctx, query, age.Seconds(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed messages: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"RWB_L0/internal/domain"
+)
+
+// TestProcessedMessageRepository_DeleteProcessedOlderThan - удаляются только старые
+// отметки; после удаления сообщение с тем же номером снова обрабатывается
+func TestProcessedMessageRepository_DeleteProcessedOlderThan(t *testing.T) {
+	db := openTestDB(t)
+	orders := NewOrderRepository(db)
+	processed := NewProcessedMessageRepository(db)
+	ctx := context.Background()
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+
+	old := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders", Sequence: 1}
+	fresh := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders", Sequence: 2}
+	for _, origin := range []domain.ChangeOrigin{old, fresh} {
+		opts := domain.SaveOptions{Policy: domain.UpsertReplace, Origin: origin}
+		if _, err := orders.Save(ctx, order, opts); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE processed_messages SET processed_at = NOW() - INTERVAL '2 days' WHERE sequence = 1`); err != nil {
+		t.Fatalf("Failed to age processed message: %v", err)
+	}
+
+	deleted, err := processed.DeleteProcessedOlderThan(ctx, 24*time.Hour, 10)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteProcessedOlderThan() = %d, %v; want 1, nil", deleted, err)
+	}
+
+	outcome, err := orders.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertReplace, Origin: old})
+	if err != nil || outcome == domain.SaveDuplicate {
+		t.Errorf("Expected the cleaned message to be processed again, got %v, %v", outcome, err)
+	}
+	outcome, err = orders.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertReplace, Origin: fresh})
+	if err != nil || outcome != domain.SaveDuplicate {
+		t.Errorf("Expected the fresh message to stay processed, got %v, %v", outcome, err)
+	}
+}
@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"RWB_L0/internal/domain"
+)
+
+// statusHistoryColumns - колонки order_status_history в порядке scanStatusTransition
+const statusHistoryColumns = `id, order_uid, from_status, to_status, reason, source, sequence, version, created_at`
+
+// ChangeStatus - перевести заказ в статус change.To. Переход проверяет таблица переходов
+// домена. Строка заказа блокируется до конца транзакции (как в Save), поэтому параллельные
+// изменения заказа выполняются по очереди; domain.ErrVersionConflict - только если задана
+// change.ExpectedVersion и версия заказа другая. В той же транзакции пишутся история
+// статусов, снимок заказа в историю и событие для внешних систем.
+// Заказ уже в статусе change.To - domain.SaveIgnored, повторно доставленное сообщение
+// NATS - domain.SaveDuplicate: в обоих случаях заказ не меняется
+func (r *OrderRepository) ChangeStatus(ctx context.Context, orderUID string, change domain.StatusChange) (*domain.Order, domain.SaveOutcome, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil {
+		}
+	}(tx)
+
+	if change.Origin.Subject != "" {
+		first, err := markProcessed(ctx, tx, change.Origin)
+		if err != nil {
+			return nil, "", err
+		}
+		if !first {
+			order, err := getOrder(ctx, tx, orderUID)
+			if err != nil {
+				return nil, "", err
+			}
+			return order, domain.SaveDuplicate, nil
+		}
+	}
+
+	stored, err := lockOrder(ctx, tx, orderUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", domain.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if change.ExpectedVersion != 0 && stored.Version != change.ExpectedVersion {
+		return nil, "", domain.ErrVersionConflict
+	}
+
+	// Строка заблокирована: снимок заказа до конца транзакции не изменится
+	order, err := getOrder(ctx, tx, orderUID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	outcome := domain.SaveIgnored
+	if order.Status != change.To {
+		if err := order.Status.CheckTransition(change.To); err != nil {
+			return nil, "", err
+		}
+		if err := updateStatus(ctx, tx, order, change.To); err != nil {
+			return nil, "", err
+		}
+
+		from := order.Status
+		order.Status = change.To
+		order.Version++
+		if err := writeStatusChange(ctx, tx, order, from, change); err != nil {
+			return nil, "", err
+		}
+		outcome = domain.SaveUpdated
+	}
+
+	// Коммит нужен и без перехода: отметка об обработке сообщения NATS
+	if err = tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return order, outcome, nil
+}
+
+// updateStatus - смена статуса, если версия заказа всё ещё order.Version (compare-and-swap)
+func updateStatus(ctx context.Context, tx *sql.Tx, order *domain.Order, status domain.OrderStatus) error {
+	query := `
+		UPDATE orders SET status = $2, version = version + 1
+		WHERE order_uid = $1 AND version = $3
+	`
+	result, err := tx.ExecContext(ctx, query, order.OrderUID, status, order.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrVersionConflict
+	}
+
+	return nil
+}
+
+// writeStatusChange - переход в историю статусов, снимок заказа в историю и событие
+func writeStatusChange(ctx context.Context, tx *sql.Tx, order *domain.Order, from domain.OrderStatus, change domain.StatusChange) error {
+	// Номер сообщения есть только у изменений из NATS
+	var sequence sql.NullInt64
+	if change.Origin.Sequence != 0 {
+		sequence = sql.NullInt64{Int64: int64(change.Origin.Sequence), Valid: true}
+	}
+
+	query := `
+		INSERT INTO order_status_history (order_uid, from_status, to_status, reason, source, sequence, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := tx.ExecContext(ctx, query,
+		order.OrderUID, from, order.Status, change.Reason, change.Origin.Source, sequence, order.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save order status history: %w", err)
+	}
+
+	row, err := historyRow(order, domain.ChangeStatusChanged, change.Origin)
+	if err != nil {
+		return err
+	}
+	if err := insertHistory(ctx, tx, [][]interface{}{row}); err != nil {
+		return err
+	}
+
+	row, err = outboxRow(order, domain.OrderEventStatusChanged)
+	if err != nil {
+		return err
+	}
+	return insertOutbox(ctx, tx, [][]interface{}{row})
+}
+
+// StatusHistory - переходы между статусами заказа, от старых к новым.
+// Пустой результат - статус заказа не менялся
+func (r *OrderRepository) StatusHistory(ctx context.Context, orderUID string) ([]*domain.StatusTransition, error) {
+	query := `SELECT ` + statusHistoryColumns + ` FROM order_status_history WHERE order_uid = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order status history: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+		}
+	}(rows)
+
+	transitions := make([]*domain.StatusTransition, 0)
+	for rows.Next() {
+		transition, err := scanStatusTransition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order status history: %w", err)
+		}
+		transitions = append(transitions, transition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get order status history: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// scanStatusTransition - чтение строки order_status_history
+func scanStatusTransition(row rowScanner) (*domain.StatusTransition, error) {
+	transition := &domain.StatusTransition{}
+	var sequence sql.NullInt64
+	err := row.Scan(
+		&transition.ID, &transition.OrderUID, &transition.From, &transition.To, &transition.Reason,
+		&transition.Source, &sequence, &transition.Version, &transition.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if sequence.Valid {
+		transition.Sequence = uint64(sequence.Int64)
+	}
+	return transition, nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"RWB_L0/internal/domain"
+)
+
+// TestOrderRepository_ChangeStatus - переход пишет историю статусов и поднимает версию,
+// недопустимый переход, устаревшая версия и повторное сообщение заказ не меняют
+func TestOrderRepository_ChangeStatus(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewOrderRepository(db)
+	ctx := context.Background()
+
+	order := &domain.Order{}
+	seed := 0
+	fillValue(reflect.ValueOf(order).Elem(), &seed)
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	origin := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders.status", Sequence: 3}
+	paid := domain.StatusChange{To: domain.StatusPaid, Reason: "payment confirmed", ExpectedVersion: 1, Origin: origin}
+	got, outcome, err := repo.ChangeStatus(ctx, order.OrderUID, paid)
+	if err != nil || outcome != domain.SaveUpdated {
+		t.Fatalf("ChangeStatus(paid) = %q, %v", outcome, err)
+	}
+	if got.Status != domain.StatusPaid || got.Version != 2 {
+		t.Errorf("Expected paid at version 2, got %q at %d", got.Status, got.Version)
+	}
+
+	if _, outcome, err := repo.ChangeStatus(ctx, order.OrderUID, paid); err != nil || outcome != domain.SaveDuplicate {
+		t.Errorf("ChangeStatus(redelivery) = %q, %v; want %q", outcome, err, domain.SaveDuplicate)
+	}
+	admin := domain.ChangeOrigin{Source: domain.ChangeSourceAdmin}
+	if _, outcome, err := repo.ChangeStatus(ctx, order.OrderUID, domain.StatusChange{To: domain.StatusPaid, Origin: admin}); err != nil || outcome != domain.SaveIgnored {
+		t.Errorf("ChangeStatus(same status) = %q, %v; want %q", outcome, err, domain.SaveIgnored)
+	}
+	if _, _, err := repo.ChangeStatus(ctx, order.OrderUID, domain.StatusChange{To: domain.StatusDelivered, Origin: admin}); !errors.Is(err, domain.ErrInvalidStatusTransition) {
+		t.Errorf("ChangeStatus(delivered) error = %v, want ErrInvalidStatusTransition", err)
+	}
+	if _, _, err := repo.ChangeStatus(ctx, order.OrderUID, domain.StatusChange{To: domain.StatusAssembling, ExpectedVersion: 1, Origin: admin}); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("ChangeStatus(stale version) error = %v, want ErrVersionConflict", err)
+	}
+	if _, _, err := repo.ChangeStatus(ctx, "missing", domain.StatusChange{To: domain.StatusPaid, Origin: admin}); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("ChangeStatus(missing) error = %v, want ErrOrderNotFound", err)
+	}
+
+	stored, err := repo.GetByID(ctx, order.OrderUID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if stored.Status != domain.StatusPaid || stored.Version != 2 {
+		t.Errorf("Expected stored order paid at version 2, got %q at %d", stored.Status, stored.Version)
+	}
+
+	// Замена заказа статус не сбрасывает
+	if _, err := repo.Save(ctx, order, domain.SaveOptions{Policy: domain.UpsertReplace, Origin: admin}); err != nil {
+		t.Fatalf("Save(replace) error = %v", err)
+	}
+	if stored, _ := repo.GetByID(ctx, order.OrderUID); stored == nil || stored.Status != domain.StatusPaid {
+		t.Errorf("Expected replace to keep status paid, got %+v", stored)
+	}
+
+	transitions, err := repo.StatusHistory(ctx, order.OrderUID)
+	if err != nil {
+		t.Fatalf("StatusHistory() error = %v", err)
+	}
+	if len(transitions) != 1 {
+		t.Fatalf("Expected 1 transition, got %d", len(transitions))
+	}
+	want := domain.StatusTransition{
+		OrderUID: order.OrderUID, From: domain.StatusCreated, To: domain.StatusPaid, Reason: "payment confirmed",
+		Source: domain.ChangeSourceNATS, Sequence: 3, Version: 2,
+	}
+	tr := *transitions[0]
+	tr.ID, tr.CreatedAt = 0, want.CreatedAt
+	if tr != want {
+		t.Errorf("transition = %+v, want %+v", tr, want)
+	}
+
+	changes, err := repo.History(ctx, order.OrderUID)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(changes) < 2 || changes[1].Action != domain.ChangeStatusChanged || changes[1].Version != 2 {
+		t.Errorf("Expected status_changed at version 2 in order history, got %+v", changes)
+	}
+}
@@ -15,6 +15,9 @@ import (
 	httpcontroller "RWB_L0/internal/controllers/http"
 	"RWB_L0/internal/controllers/http/v1"
 	natscontroller "RWB_L0/internal/controllers/nats"
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/metrics"
+	"RWB_L0/internal/schema"
 	"RWB_L0/internal/usecase"
 	"RWB_L0/pkg/logger"
 	pkgnats "RWB_L0/pkg/nats"
@@ -23,13 +26,15 @@ import (
 
 // App - главная структура приложения
 type App struct {
-	cfg          *config.Config
-	log          logger.Logger
-	httpServer   *httpcontroller.Server
-	natsConsumer *natscontroller.Consumer
-	natsClient   *pkgnats.Client
-	db           *sql.DB
-	cache        *cache.MemoryCache
+	cfg            *config.Config
+	log            logger.Logger
+	httpServer     *httpcontroller.Server
+	natsConsumer   *natscontroller.Consumer
+	statusConsumer *natscontroller.Consumer // Сообщения о смене статуса заказа
+	natsClient     *pkgnats.JetStream
+	db             *sql.DB
+	cache          *cache.MemoryCache
+	metrics        *metrics.Metrics
 }
 
 // New - создание приложения
@@ -41,13 +46,21 @@ func New() (*App, error) {
 	}
 
 	// 2. Инициализируем логер
-	log := logger.New(cfg.Logging.Level)
+	log := logger.NewWithConfig(logger.Config{
+		Level:  cfg.Logging.Level,
+		Format: cfg.Logging.Format,
+	})
 	log.Info("Starting Order Service...")
-	log.Info("Configuration loaded: server_port=%s, db_host=%s", cfg.Server.Port, cfg.Database.Host)
+	log.With(
+		logger.F("server_port", cfg.Server.Port),
+		logger.F("db_host", cfg.Database.Host),
+		logger.F("log_format", cfg.Logging.Format),
+	).Info("Configuration loaded")
 
 	return &App{
-		cfg: cfg,
-		log: log,
+		cfg:     cfg,
+		log:     log,
+		metrics: metrics.New(),
 	}, nil
 }
 
@@ -61,39 +74,77 @@ func (a *App) Run() error {
 		return fmt.Errorf("failed to init database: %w", err)
 	}
 
-	if err := a.initNATS(); err != nil {
+	if err := a.initNATS(ctx); err != nil {
 		return fmt.Errorf("failed to init NATS: %w", err)
 	}
 
 	// 2. Создаём Use Cases
-	orderRepo := postgres.NewOrderRepository(a.db)
+	orderRepo := postgres.NewInstrumentedOrderRepository(postgres.NewOrderRepository(a.db), a.metrics)
 
-	orderCache := cache.NewMemoryCacheWithConfig(a.cfg.Cache.MaxSize, a.cfg.Cache.TTL)
+	orderCache := cache.NewShardedMemoryCache(a.cfg.Cache.MaxSize, a.cfg.Cache.TTL, a.cfg.Cache.Shards)
 	a.cache = orderCache
+	a.metrics.RegisterCacheStats(orderCache.Stats)
 
-	orderUseCase := usecase.NewOrderUseCase(orderRepo, orderCache)
+	upsertPolicy, err := domain.ParseUpsertPolicy(a.cfg.Orders.UpsertPolicy)
+	if err != nil {
+		return fmt.Errorf("invalid ORDER_UPSERT_POLICY: %w", err)
+	}
+	validationMode, err := domain.ParseValidationMode(a.cfg.Orders.ValidationMode)
+	if err != nil {
+		return fmt.Errorf("invalid ORDER_VALIDATION_MODE: %w", err)
+	}
 
-	// 3. Восстанавливаем кэш из БД
-	a.log.Info("Restoring cache from database...")
-	if err := orderUseCase.RestoreCache(ctx); err != nil {
-		a.log.Warn("Failed to restore cache: %v", err)
-	} else {
-		stats := orderUseCase.GetCacheStats()
-		a.log.Info("Cache restored successfully: %v orders", stats["cached_orders"])
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, orderCache, a.log, usecase.Config{
+		WarmupBatchSize: a.cfg.Cache.WarmupBatchSize,
+		UpsertPolicy:    upsertPolicy,
+		ValidationMode:  validationMode,
+	})
+
+	deadLetterUseCase := usecase.NewDeadLetterUseCase(postgres.NewDeadLetterRepository(a.db), orderUseCase, usecase.DeadLetterConfig{
+		StatusSubject: a.cfg.NATS.StatusSubject,
+	})
+
+	// 3. Инициализируем HTTP сервер
+	if err := a.initHTTPServer(orderUseCase, deadLetterUseCase); err != nil {
+		return err
 	}
 
-	// 4. Инициализируем HTTP сервер
-	a.initHTTPServer(orderUseCase)
+	// 4. Инициализируем NATS consumers (заказы и смена статуса)
+	if err := a.initNATSConsumer(orderUseCase, deadLetterUseCase); err != nil {
+		return err
+	}
 
-	// 5. Инициализируем NATS consumer
-	a.initNATSConsumer(orderUseCase)
+	// 5. Прогреваем кэш из БД в фоне: пока он не заполнен, запросы идут в БД
+	go a.restoreCache(ctx, orderUseCase)
+
+	// 6. Публикуем события о заказах из outbox в фоне
+	outboxRelay := usecase.NewOutboxRelay(
+		postgres.NewOutboxRepository(a.db),
+		pkgnats.NewJetStreamPublisher(a.natsClient),
+		a.log,
+		usecase.OutboxConfig{
+			BatchSize:    a.cfg.Outbox.BatchSize,
+			PollInterval: a.cfg.Outbox.PollInterval,
+		},
+	)
+	go outboxRelay.Run(ctx)
+
+	// Удаляем отметки об обработке сообщений, которых уже нет в stream
+	if a.cfg.NATS.ProcessedRetention > 0 {
+		processedCleaner := usecase.NewProcessedCleaner(
+			postgres.NewProcessedMessageRepository(a.db),
+			a.log,
+			usecase.ProcessedCleanerConfig{Retention: a.cfg.NATS.ProcessedRetention},
+		)
+		go processedCleaner.Run(ctx)
+	}
 
-	// 6. Запускаем серверы в горутинах
-	errChan := make(chan error, 2)
+	// 7. Запускаем серверы в горутинах
+	errChan := make(chan error, 3)
 
 	// HTTP Server
 	go func() {
-		a.log.Info("Starting HTTP server on %s:%s", a.cfg.Server.Host, a.cfg.Server.Port)
+		a.log.Info("Starting HTTP server on %s", a.cfg.Server.GetServerAddress())
 		if err := a.httpServer.Start(); err != nil {
 			errChan <- fmt.Errorf("HTTP server error: %w", err)
 		}
@@ -105,15 +156,34 @@ func (a *App) Run() error {
 			errChan <- fmt.Errorf("NATS consumer error: %w", err)
 		}
 	}()
+	go func() {
+		if err := a.statusConsumer.Start(ctx, a.cfg.NATS.StatusSubject, a.cfg.NATS.StatusDurableName); err != nil {
+			errChan <- fmt.Errorf("NATS status consumer error: %w", err)
+		}
+	}()
 
-	// 7. Ждём сигнала остановки
+	// 8. Ждём сигнала остановки
 	a.log.Info("Order Service started successfully!")
 	return a.waitForShutdown(ctx, cancel, errChan)
 }
 
+// restoreCache - прогрев кэша из БД
+func (a *App) restoreCache(ctx context.Context, orderUseCase *usecase.OrderUseCase) {
+	a.log.Info("Restoring cache from database...")
+	started := time.Now()
+
+	if err := orderUseCase.RestoreCache(ctx); err != nil {
+		a.log.Warn("Failed to restore cache: %v", err)
+		return
+	}
+
+	stats := orderUseCase.GetCacheStats()
+	a.log.Info("Cache restored successfully: %v orders in %s", stats["cached_orders"], time.Since(started))
+}
+
 // initDatabase - инициализация PostgreSQL
 func (a *App) initDatabase() error {
-	a.log.Info("Connecting to PostgreSQL: %s:%s", a.cfg.Database.Host, a.cfg.Database.Port)
+	a.log.Info("Connecting to PostgreSQL: %s:%d", a.cfg.Database.Host, a.cfg.Database.Port)
 
 	db, err := pkgpostgres.New(&pkgpostgres.Config{
 		Host:         a.cfg.Database.Host,
@@ -134,14 +204,26 @@ func (a *App) initDatabase() error {
 	return nil
 }
 
-// initNATS - инициализация NATS Streaming
-func (a *App) initNATS() error {
+// initNATS - инициализация NATS JetStream. Stream хранит входящие заказы,
+// сообщения о смене статуса, dead letters и события о заказах
+func (a *App) initNATS(ctx context.Context) error {
 	a.log.Info("Connecting to NATS: %s", a.cfg.NATS.URL)
 
-	client, err := pkgnats.New(&pkgnats.Config{
-		URL:       a.cfg.NATS.URL,
-		ClusterID: a.cfg.NATS.ClusterID,
-		ClientID:  a.cfg.NATS.ClientID,
+	client, err := pkgnats.NewJetStream(ctx, &pkgnats.JetStreamConfig{
+		URL:      a.cfg.NATS.URL,
+		Name:     a.cfg.NATS.ClientID,
+		Stream:   a.cfg.NATS.Stream,
+		MaxAge:   a.cfg.NATS.StreamMaxAge,
+		MaxBytes: a.cfg.NATS.StreamMaxBytes,
+		MaxMsgs:  a.cfg.NATS.StreamMaxMsgs,
+		Subjects: []string{
+			a.cfg.NATS.Subject,
+			a.cfg.NATS.StatusSubject,
+			a.cfg.NATS.DeadLetterSubject,
+			string(domain.OrderEventCreated),
+			string(domain.OrderEventUpdated),
+			string(domain.OrderEventStatusChanged),
+		},
 	})
 	if err != nil {
 		return err
@@ -153,16 +235,24 @@ func (a *App) initNATS() error {
 }
 
 // initHTTPServer - инициализация HTTP сервера
-func (a *App) initHTTPServer(orderUseCase *usecase.OrderUseCase) {
+func (a *App) initHTTPServer(orderUseCase *usecase.OrderUseCase, deadLetterUseCase *usecase.DeadLetterUseCase) error {
+	// Тело admin API проверяется по схеме всегда и строго, независимо от NATS_SCHEMA_VALIDATION:
+	// его присылает не внешний источник, а оператор
+	adminSchema, err := schema.NewOrderValidator(schema.ModeStrict)
+	if err != nil {
+		return err
+	}
+
 	// Создаём handlers
-	orderHandler := v1.NewOrderHandler(orderUseCase)
+	orderHandler := v1.NewOrderHandler(orderUseCase, adminSchema)
+	deadLetterHandler := v1.NewDeadLetterHandler(deadLetterUseCase)
 	webHandler := v1.NewWebHandler(orderUseCase)
 
 	// Создаём middleware
-	mw := httpcontroller.NewMiddleware()
+	mw := httpcontroller.NewMiddleware(a.log, a.metrics)
 
 	// Создаём router
-	router := httpcontroller.NewRouter(orderHandler, webHandler, mw)
+	router := httpcontroller.NewRouter(orderHandler, deadLetterHandler, webHandler, mw, a.metrics.Handler())
 
 	// Создаём сервер
 	a.httpServer = httpcontroller.NewServer(
@@ -170,13 +260,67 @@ func (a *App) initHTTPServer(orderUseCase *usecase.OrderUseCase) {
 		a.cfg.Server.Port,
 		router,
 	)
+	return nil
 }
 
-// initNATSConsumer - инициализация NATS consumer
-func (a *App) initNATSConsumer(orderUseCase *usecase.OrderUseCase) {
-	subscriber := pkgnats.NewSubscriber(a.natsClient)
-	handler := natscontroller.NewHandler(orderUseCase, a.log)
-	a.natsConsumer = natscontroller.NewConsumer(subscriber, handler, a.log)
+// initNATSConsumer - инициализация NATS consumers заказов и сообщений о статусе.
+// Необработанные сообщения обоих уходят в одни dead letters
+func (a *App) initNATSConsumer(orderUseCase *usecase.OrderUseCase, deadLetterUseCase *usecase.DeadLetterUseCase) error {
+	schemaMode, err := schema.ParseMode(a.cfg.NATS.SchemaValidation)
+	if err != nil {
+		return fmt.Errorf("invalid NATS_SCHEMA_VALIDATION: %w", err)
+	}
+	var payloadValidator natscontroller.PayloadValidator
+	if schemaMode != schema.ModeOff {
+		validator, err := schema.NewOrderValidator(schemaMode)
+		if err != nil {
+			return err
+		}
+		payloadValidator = validator
+	}
+
+	deadLetters := natscontroller.NewDeadLetterHandler(
+		deadLetterUseCase,
+		pkgnats.NewJetStreamPublisher(a.natsClient),
+		a.cfg.NATS.DeadLetterSubject,
+		a.log,
+	)
+
+	subscriber := pkgnats.NewJetStreamSubscriber(a.natsClient, pkgnats.SubscriberConfig{
+		MaxRedeliveries: a.cfg.NATS.MaxRedeliveries,
+		AckWait:         a.cfg.NATS.AckWait,
+		MaxInflight:     a.cfg.NATS.MaxInflight,
+		DeadLetter:      deadLetters.Handle,
+		Log:             a.log,
+		MaxDeliver:      a.cfg.NATS.MaxDeliver,
+		Backoff:         a.cfg.NATS.Backoff,
+	})
+	// AckWait продлевается дважды за его срок, чтобы продление не опоздало
+	heartbeat := a.cfg.NATS.AckWait / 2
+	if heartbeat <= 0 {
+		heartbeat = pkgnats.DefaultAckWait / 2
+	}
+	handler := natscontroller.NewHandler(orderUseCase, a.log, a.metrics, payloadValidator)
+	a.natsConsumer = natscontroller.NewConsumer(subscriber, handler, a.log, a.metrics, natscontroller.ConsumerConfig{
+		Workers:      a.cfg.NATS.Workers,
+		QueueSize:    a.cfg.NATS.MaxInflight,
+		BatchSize:    a.cfg.NATS.BatchSize,
+		BatchWait:    a.cfg.NATS.BatchWait,
+		MaxRetries:   a.cfg.NATS.MaxRedeliveries,
+		RetryBackoff: a.cfg.NATS.Backoff,
+		Heartbeat:    heartbeat,
+	})
+
+	// Статус меняется по одному сообщению: пакетное сохранение здесь не нужно
+	statusHandler := natscontroller.NewStatusHandler(orderUseCase, a.log)
+	a.statusConsumer = natscontroller.NewStatusConsumer(subscriber, statusHandler, a.log, a.metrics, natscontroller.ConsumerConfig{
+		Workers:      a.cfg.NATS.Workers,
+		QueueSize:    a.cfg.NATS.MaxInflight,
+		MaxRetries:   a.cfg.NATS.MaxRedeliveries,
+		RetryBackoff: a.cfg.NATS.Backoff,
+		Heartbeat:    heartbeat,
+	})
+	return nil
 }
 
 // waitForShutdown - ожидание сигнала остановки
@@ -213,13 +357,19 @@ func (a *App) shutdown() error {
 		}
 	}
 
-	// Останавливаем NATS consumer
+	// Останавливаем NATS consumers
 	if a.natsConsumer != nil {
 		a.log.Info("Stopping NATS consumer...")
 		if err := a.natsConsumer.Stop(); err != nil {
 			a.log.Error("NATS consumer shutdown error: %v", err)
 		}
 	}
+	if a.statusConsumer != nil {
+		a.log.Info("Stopping NATS status consumer...")
+		if err := a.statusConsumer.Stop(); err != nil {
+			a.log.Error("NATS status consumer shutdown error: %v", err)
+		}
+	}
 
 	// Закрываем NATS клиент
 	if a.natsClient != nil {
@@ -4,17 +4,61 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+
+	"RWB_L0/pkg/logger"
 )
 
-type Middleware struct{}
+// RequestObserver - получатель длительности HTTP запросов (например, метрики)
+type RequestObserver interface {
+	ObserveHTTPRequest(method, route string, status int, duration time.Duration)
+}
+
+type Middleware struct {
+	log      logger.Logger
+	observer RequestObserver
+}
+
+func NewMiddleware(log logger.Logger, observer RequestObserver) *Middleware {
+	return &Middleware{
+		log:      log,
+		observer: observer,
+	}
+}
 
-func NewMiddleware() *Middleware {
-	return &Middleware{}
+// RequestID - присваивает запросу ID (или берёт из заголовка X-Request-Id)
+func (m *Middleware) RequestID(next http.Handler) http.Handler {
+	return middleware.RequestID(next)
 }
 
+// Logger - структурированный лог запроса. Логер с request_id кладётся
+// в контекст, чтобы обработчики писали в лог с теми же полями
 func (m *Middleware) Logger(next http.Handler) http.Handler {
-	return middleware.Logger(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		reqLog := m.log.With(
+			logger.F("request_id", middleware.GetReqID(r.Context())),
+			logger.F("method", r.Method),
+			logger.F("path", r.URL.Path),
+		)
+
+		next.ServeHTTP(ww, r.WithContext(logger.WithContext(r.Context(), reqLog)))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		reqLog.With(
+			logger.F("status", status),
+			logger.F("bytes", ww.BytesWritten()),
+			logger.F("duration_ms", time.Since(started).Milliseconds()),
+			logger.F("remote_addr", r.RemoteAddr),
+		).Info("HTTP request completed")
+	})
 }
 
 func (m *Middleware) Recoverer(next http.Handler) http.Handler {
@@ -24,3 +68,26 @@ func (m *Middleware) Recoverer(next http.Handler) http.Handler {
 func (m *Middleware) Timeout(next http.Handler) http.Handler {
 	return middleware.Timeout(30 * time.Second)(next)
 }
+
+// Metrics - замер длительности запроса. Метка route - шаблон маршрута chi
+// (например, /api/v1/orders/{uid}), чтобы не плодить серии на каждый UID
+func (m *Middleware) Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.observer.ObserveHTTPRequest(r.Method, route, status, time.Since(started))
+	})
+}
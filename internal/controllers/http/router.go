@@ -14,24 +14,56 @@ type Router struct {
 }
 
 // NewRouter - создание роутера
-func NewRouter(orderHandler *v1.OrderHandler, webHandler *v1.WebHandler, mw *Middleware) *Router {
+func NewRouter(
+	orderHandler *v1.OrderHandler,
+	deadLetterHandler *v1.DeadLetterHandler,
+	webHandler *v1.WebHandler,
+	mw *Middleware,
+	metricsHandler http.Handler,
+) *Router {
 	r := chi.NewRouter()
 
 	// Глобальные middleware
+	r.Use(mw.RequestID)
+	r.Use(mw.Metrics)
 	r.Use(mw.Logger)
 	r.Use(mw.Recoverer)
 	r.Use(mw.Timeout)
 
 	// API v1
 	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/orders", orderHandler.List)
 		r.Get("/orders/{uid}", orderHandler.GetByUID) // ✅ Исправлено
+		r.Get("/orders/{uid}/history", orderHandler.History)
+		r.Get("/orders/{uid}/status", orderHandler.StatusHistory)
 		r.Get("/health", orderHandler.HealthCheck)
+
+		// Контракт сообщения о заказе
+		r.Get("/schema/order", v1.OrderSchema)
+
+		// Администрирование: правка (If-Match обязателен) и удаление заказа
+		r.Put("/admin/orders/{uid}", orderHandler.Update)
+		r.Delete("/admin/orders/{uid}", orderHandler.Delete)
+
+		// Администрирование: смена статуса заказа по таблице переходов
+		r.Post("/admin/orders/{uid}/status", orderHandler.ChangeStatus)
+
+		// Администрирование: необработанные NATS сообщения
+		r.Route("/admin/dead-letters", func(r chi.Router) {
+			r.Get("/", deadLetterHandler.List)
+			r.Get("/{id}", deadLetterHandler.Get)
+			r.Delete("/{id}", deadLetterHandler.Delete)
+			r.Post("/{id}/replay", deadLetterHandler.Replay)
+		})
 	})
 
 	// Web интерфейс
 	r.Get("/", webHandler.IndexPage)
 	r.Get("/orders/{order_uid}", webHandler.OrderPage)
 
+	// Метрики Prometheus
+	r.Handle("/metrics", metricsHandler)
+
 	// Статика
 	fileServer := http.FileServer(http.Dir("./web/static"))
 	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/dto"
+	"RWB_L0/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DeadLetterHandler обрабатывает admin API для необработанных NATS сообщений
+type DeadLetterHandler struct {
+	deadLetterUseCase usecase.DeadLetterUseCaseInterface
+}
+
+// NewDeadLetterHandler создаёт новый экземпляр DeadLetterHandler
+func NewDeadLetterHandler(deadLetterUseCase usecase.DeadLetterUseCaseInterface) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterUseCase: deadLetterUseCase,
+	}
+}
+
+// List обрабатывает GET /api/v1/admin/dead-letters
+func (h *DeadLetterHandler) List(w http.ResponseWriter, r *http.Request) {
+	input := &dto.ListDeadLettersInput{
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "limit must be a positive integer",
+			})
+			return
+		}
+		input.Limit = limit
+	}
+
+	page, err := h.deadLetterUseCase.List(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) || errors.Is(err, domain.ErrInvalidListLimit) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list dead letters",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// Get обрабатывает GET /api/v1/admin/dead-letters/{id}
+func (h *DeadLetterHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseDeadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	letter, err := h.deadLetterUseCase.Get(r.Context(), id)
+	if err != nil {
+		writeDeadLetterError(w, err, "Failed to get dead letter")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, letter)
+}
+
+// Delete обрабатывает DELETE /api/v1/admin/dead-letters/{id}
+func (h *DeadLetterHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseDeadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetterUseCase.Delete(r.Context(), id); err != nil {
+		writeDeadLetterError(w, err, "Failed to delete dead letter")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Replay обрабатывает POST /api/v1/admin/dead-letters/{id}/replay
+func (h *DeadLetterHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseDeadLetterID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetterUseCase.Replay(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrInvalidOrder) {
+			// Payload по-прежнему невалиден - сообщение остаётся в dead letters
+			writeValidationProblem(w, r, err)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidOrderStatus) || errors.Is(err, domain.ErrInvalidStatusTransition) {
+			// Смена статуса по-прежнему недопустима - сообщение остаётся в dead letters
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		writeDeadLetterError(w, err, "Failed to replay dead letter")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseDeadLetterID читает {id} из пути; при ошибке сам отвечает 400
+func parseDeadLetterID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "Dead letter id must be a positive integer",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeDeadLetterError отвечает 404 для отсутствующего сообщения и 500 для остальных ошибок
+func writeDeadLetterError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, domain.ErrDeadLetterNotFound) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: "Dead letter not found",
+		})
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+		Error: message,
+	})
+}
@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/dto"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDeadLetterUseCase - мок для DeadLetterUseCaseInterface
+type MockDeadLetterUseCase struct {
+	mock.Mock
+}
+
+func (m *MockDeadLetterUseCase) List(ctx context.Context, input *dto.ListDeadLettersInput) (*dto.ListDeadLettersOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.ListDeadLettersOutput), args.Error(1)
+}
+
+func (m *MockDeadLetterUseCase) Get(ctx context.Context, id int64) (*dto.DeadLetterOutput, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.DeadLetterOutput), args.Error(1)
+}
+
+func (m *MockDeadLetterUseCase) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDeadLetterUseCase) Replay(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newDeadLetterRequest(method, target, id string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestDeadLetterHandler_List тестирует выборку с курсором
+func TestDeadLetterHandler_List(t *testing.T) {
+	mockUseCase := new(MockDeadLetterUseCase)
+	handler := NewDeadLetterHandler(mockUseCase)
+
+	expected := &dto.ListDeadLettersOutput{
+		DeadLetters: []*dto.DeadLetterOutput{{ID: 5, Subject: "orders", PayloadBase64: "eyJvcmRlcl91aWQiOg=="}},
+		NextCursor:  "5",
+		HasMore:     true,
+	}
+	mockUseCase.On("List", mock.Anything, &dto.ListDeadLettersInput{Limit: 1, Cursor: "9"}).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/dead-letters?limit=1&cursor=9", nil)
+	w := httptest.NewRecorder()
+
+	handler.List(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response dto.ListDeadLettersOutput
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Len(t, response.DeadLetters, 1)
+	assert.Equal(t, "5", response.NextCursor)
+
+	mockUseCase.AssertExpectations(t)
+}
+
+// TestDeadLetterHandler_Get_NotFound тестирует запрос несуществующего сообщения
+func TestDeadLetterHandler_Get_NotFound(t *testing.T) {
+	mockUseCase := new(MockDeadLetterUseCase)
+	handler := NewDeadLetterHandler(mockUseCase)
+
+	mockUseCase.On("Get", mock.Anything, int64(42)).
+		Return(nil, fmt.Errorf("failed to get dead letter: %w", domain.ErrDeadLetterNotFound))
+
+	w := httptest.NewRecorder()
+	handler.Get(w, newDeadLetterRequest(http.MethodGet, "/api/v1/admin/dead-letters/42", "42"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+// TestDeadLetterHandler_InvalidID тестирует некорректный id в пути
+func TestDeadLetterHandler_InvalidID(t *testing.T) {
+	mockUseCase := new(MockDeadLetterUseCase)
+	handler := NewDeadLetterHandler(mockUseCase)
+
+	w := httptest.NewRecorder()
+	handler.Delete(w, newDeadLetterRequest(http.MethodDelete, "/api/v1/admin/dead-letters/abc", "abc"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockUseCase.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+// TestDeadLetterHandler_Replay тестирует повторную обработку
+func TestDeadLetterHandler_Replay(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"success", nil, http.StatusNoContent},
+		{"still invalid", fmt.Errorf("replay failed: %w", domain.ErrInvalidOrder), http.StatusUnprocessableEntity},
+		{"not found", domain.ErrDeadLetterNotFound, http.StatusNotFound},
+		{"database down", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockDeadLetterUseCase)
+			handler := NewDeadLetterHandler(mockUseCase)
+			mockUseCase.On("Replay", mock.Anything, int64(7)).Return(tt.err)
+
+			w := httptest.NewRecorder()
+			handler.Replay(w, newDeadLetterRequest(http.MethodPost, "/api/v1/admin/dead-letters/7/replay", "7"))
+
+			assert.Equal(t, tt.status, w.Code)
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errInvalidETag - If-Match содержит не ETag заказа
+var errInvalidETag = errors.New("If-Match must be an order ETag such as \"3\"")
+
+// formatETag - ETag заказа: его версия в кавычках (строгий ETag)
+func formatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseETag - версия заказа из ETag. Слабые ETag (W/"3") не принимаются:
+// для compare-and-swap нужна точная версия
+func parseETag(etag string) (int64, error) {
+	etag = strings.TrimSpace(etag)
+	if len(etag) < 3 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, errInvalidETag
+	}
+
+	version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errInvalidETag
+	}
+	return version, nil
+}
+
+// requireIfMatch - версия из обязательного If-Match с ETag заказа.
+// false - ответ об ошибке уже отправлен: 428 без заголовка, 400 с неверным ETag
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		writeJSON(w, http.StatusPreconditionRequired, ErrorResponse{
+			Error: "If-Match header with the order ETag is required",
+		})
+		return 0, false
+	}
+	version, err := parseETag(ifMatch)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// etagMatches - совпадает ли ETag с одним из значений If-None-Match
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -3,24 +3,37 @@ package v1
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"RWB_L0/internal/domain"
+	"RWB_L0/internal/dto"
 	"RWB_L0/internal/usecase"
 
 	"github.com/go-chi/chi/v5"
 )
 
+// PayloadValidator - проверка тела запроса по JSON Schema заказа (schema.Validator).
+// Нарушения - domain.ValidationError
+type PayloadValidator interface {
+	Validate(data []byte) error
+}
+
 // OrderHandler обрабатывает HTTP запросы для работы с заказами
 type OrderHandler struct {
 	orderUseCase usecase.OrderUseCaseInterface
+	schema       PayloadValidator
 }
 
-// NewOrderHandler создаёт новый экземпляр OrderHandler
-func NewOrderHandler(orderUseCase usecase.OrderUseCaseInterface) *OrderHandler {
+// NewOrderHandler создаёт новый экземпляр OrderHandler.
+// schema == nil - тело PUT /api/v1/admin/orders/:uid по схеме не проверяется
+func NewOrderHandler(orderUseCase usecase.OrderUseCaseInterface, schema PayloadValidator) *OrderHandler {
 	return &OrderHandler{
 		orderUseCase: orderUseCase,
+		schema:       schema,
 	}
 }
 
@@ -43,9 +56,261 @@ func (h *OrderHandler) GetByUID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// ETag - версия заказа; её передают в If-Match при обновлении
+	etag := formatETag(order.Version)
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// Update обрабатывает PUT /api/v1/admin/orders/:uid.
+// Требует If-Match с ETag из GET: заказ, изменённый с тех пор, не перезаписывается.
+// Тело проверяется по той же схеме, что и сообщения NATS (order.v1.json)
+func (h *OrderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "uid")
+
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "Failed to read request body",
+		})
+		return
+	}
+	if !json.Valid(body) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+	// Схема - до разбора, как у сообщений NATS: несовпадение типов ("amount": "10")
+	// возвращается нарушением с путём поля, а не ошибкой разбора
+	if h.schema != nil {
+		if err := h.schema.Validate(body); err != nil {
+			writeValidationProblem(w, r, fmt.Errorf("schema validation failed: %w: %w", domain.ErrInvalidOrder, err))
+			return
+		}
+	}
+	var input dto.CreateOrderInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	order, err := h.orderUseCase.Update(r.Context(), orderUID, &input, expectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrVersionConflict):
+			writeJSON(w, http.StatusPreconditionFailed, ErrorResponse{
+				Error: "Order was modified, fetch it again and retry",
+			})
+		case errors.Is(err, domain.ErrOrderNotFound):
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "Order not found",
+			})
+		case errors.Is(err, domain.ErrInvalidOrder):
+			writeValidationProblem(w, r, err)
+		case errors.Is(err, domain.ErrEmptyOrderUID):
+			writeValidationProblem(w, r, domain.NewValidationError("order_uid", err))
+		default:
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update order",
+			})
+		}
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(order.Version))
 	writeJSON(w, http.StatusOK, order)
 }
 
+// Delete обрабатывает DELETE /api/v1/admin/orders/:uid.
+// Как и Update, требует If-Match: заказ, изменённый с чтения, не удаляется
+func (h *OrderHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "uid")
+
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.orderUseCase.Delete(r.Context(), orderUID, expectedVersion); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrEmptyOrderUID):
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "Order UID is required",
+			})
+		case errors.Is(err, domain.ErrOrderNotFound):
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "Order not found",
+			})
+		case errors.Is(err, domain.ErrVersionConflict):
+			writeJSON(w, http.StatusPreconditionFailed, ErrorResponse{
+				Error: "Order was modified, fetch it again and retry",
+			})
+		default:
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to delete order",
+			})
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangeStatus обрабатывает POST /api/v1/admin/orders/:uid/status.
+// If-Match необязателен: с ним статус меняется, только если заказ не изменился с чтения
+func (h *OrderHandler) ChangeStatus(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "uid")
+
+	var expectedVersion int64
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		version, err := parseETag(ifMatch)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		expectedVersion = version
+	}
+
+	var input dto.ChangeStatusInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+	input.OrderUID = orderUID
+
+	order, err := h.orderUseCase.ChangeStatus(r.Context(), &input, expectedVersion, domain.ChangeOrigin{Source: domain.ChangeSourceAdmin})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrEmptyOrderUID):
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "Order UID is required",
+			})
+		case errors.Is(err, domain.ErrInvalidOrderStatus):
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+		case errors.Is(err, domain.ErrInvalidStatusTransition):
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: err.Error(),
+			})
+		case errors.Is(err, domain.ErrOrderNotFound):
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "Order not found",
+			})
+		case errors.Is(err, domain.ErrVersionConflict):
+			writeJSON(w, http.StatusPreconditionFailed, ErrorResponse{
+				Error: "Order was modified, fetch it again and retry",
+			})
+		default:
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to change order status",
+			})
+		}
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(order.Version))
+	writeJSON(w, http.StatusOK, order)
+}
+
+// StatusHistory обрабатывает GET /api/v1/orders/:uid/status
+func (h *OrderHandler) StatusHistory(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "uid")
+
+	status, err := h.orderUseCase.StatusHistory(r.Context(), orderUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrEmptyOrderUID):
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "Order UID is required",
+			})
+		case errors.Is(err, domain.ErrOrderNotFound):
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "Order not found",
+			})
+		default:
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to get order status",
+			})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// History обрабатывает GET /api/v1/orders/:uid/history
+func (h *OrderHandler) History(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "uid")
+
+	history, err := h.orderUseCase.History(r.Context(), orderUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrEmptyOrderUID):
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "Order UID is required",
+			})
+		case errors.Is(err, domain.ErrOrderNotFound):
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: "Order history not found",
+			})
+		default:
+			writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to get order history",
+			})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+// List обрабатывает GET /api/v1/orders
+func (h *OrderHandler) List(w http.ResponseWriter, r *http.Request) {
+	input, err := parseListOrdersInput(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	page, err := h.orderUseCase.List(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) ||
+			errors.Is(err, domain.ErrInvalidListLimit) ||
+			errors.Is(err, domain.ErrInvalidDateRange) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list orders",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
 // HealthCheck обрабатывает GET /api/v1/health
 func (h *OrderHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	stats := h.orderUseCase.GetCacheStats()
@@ -69,6 +334,46 @@ type HealthResponse struct {
 	Cache     map[string]interface{} `json:"cache"`
 }
 
+// parseListOrdersInput разбирает query-параметры GET /api/v1/orders
+func parseListOrdersInput(r *http.Request) (*dto.ListOrdersInput, error) {
+	q := r.URL.Query()
+
+	input := &dto.ListOrdersInput{
+		Cursor:          q.Get("cursor"),
+		CustomerID:      q.Get("customer_id"),
+		TrackNumber:     q.Get("track_number"),
+		DeliveryService: q.Get("delivery_service"),
+		Entry:           q.Get("entry"),
+		Locale:          q.Get("locale"),
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, errors.New("limit must be a positive integer")
+		}
+		input.Limit = limit
+	}
+
+	if v := q.Get("date_from"); v != "" {
+		dateFrom, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("date_from must be in RFC 3339 format")
+		}
+		input.DateFrom = &dateFrom
+	}
+
+	if v := q.Get("date_to"); v != "" {
+		dateTo, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("date_to must be in RFC 3339 format")
+		}
+		input.DateTo = &dateTo
+	}
+
+	return input, nil
+}
+
 // writeJSON отправляет JSON ответ
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"RWB_L0/internal/domain"
 	"RWB_L0/internal/dto"
+	"RWB_L0/internal/schema"
+	"RWB_L0/internal/usecase"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -21,11 +25,53 @@ type MockOrderUseCase struct {
 	mock.Mock
 }
 
-func (m *MockOrderUseCase) Create(ctx context.Context, input *dto.CreateOrderInput) error {
-	args := m.Called(ctx, input)
+func (m *MockOrderUseCase) Create(ctx context.Context, input *dto.CreateOrderInput, origin domain.ChangeOrigin) (domain.SaveOutcome, error) {
+	args := m.Called(ctx, input, origin)
+	return args.Get(0).(domain.SaveOutcome), args.Error(1)
+}
+
+func (m *MockOrderUseCase) CreateBatch(ctx context.Context, requests []usecase.CreateRequest) []domain.SaveResult {
+	args := m.Called(ctx, requests)
+	return args.Get(0).([]domain.SaveResult)
+}
+
+func (m *MockOrderUseCase) Update(ctx context.Context, orderUID string, input *dto.CreateOrderInput, expectedVersion int64) (*dto.OrderOutput, error) {
+	args := m.Called(ctx, orderUID, input, expectedVersion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.OrderOutput), args.Error(1)
+}
+
+func (m *MockOrderUseCase) Delete(ctx context.Context, orderUID string, expectedVersion int64) error {
+	args := m.Called(ctx, orderUID, expectedVersion)
 	return args.Error(0)
 }
 
+func (m *MockOrderUseCase) History(ctx context.Context, orderUID string) (*dto.OrderHistoryOutput, error) {
+	args := m.Called(ctx, orderUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.OrderHistoryOutput), args.Error(1)
+}
+
+func (m *MockOrderUseCase) ChangeStatus(ctx context.Context, input *dto.ChangeStatusInput, expectedVersion int64, origin domain.ChangeOrigin) (*dto.OrderOutput, error) {
+	args := m.Called(ctx, input, expectedVersion, origin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.OrderOutput), args.Error(1)
+}
+
+func (m *MockOrderUseCase) StatusHistory(ctx context.Context, orderUID string) (*dto.OrderStatusOutput, error) {
+	args := m.Called(ctx, orderUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.OrderStatusOutput), args.Error(1)
+}
+
 func (m *MockOrderUseCase) GetByUID(ctx context.Context, orderUID string) (*dto.OrderOutput, error) {
 	args := m.Called(ctx, orderUID)
 	if args.Get(0) == nil {
@@ -42,6 +88,14 @@ func (m *MockOrderUseCase) GetAll(ctx context.Context) ([]*dto.OrderOutput, erro
 	return args.Get(0).([]*dto.OrderOutput), args.Error(1)
 }
 
+func (m *MockOrderUseCase) List(ctx context.Context, input *dto.ListOrdersInput) (*dto.ListOrdersOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.ListOrdersOutput), args.Error(1)
+}
+
 func (m *MockOrderUseCase) RestoreCache(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -56,7 +110,7 @@ func (m *MockOrderUseCase) GetCacheStats() map[string]interface{} {
 func TestOrderHandler_GetByUID_Success(t *testing.T) {
 	// Arrange
 	mockUseCase := new(MockOrderUseCase)
-	handler := NewOrderHandler(mockUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
 
 	expectedOrder := &dto.OrderOutput{
 		OrderUID:    "test-uid-123",
@@ -90,11 +144,397 @@ func TestOrderHandler_GetByUID_Success(t *testing.T) {
 	mockUseCase.AssertExpectations(t)
 }
 
+// withUID добавляет URL параметр uid через chi context
+func withUID(req *http.Request, uid string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("uid", uid)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestOrderHandler_GetByUID_ETag тестирует ETag и If-None-Match
+func TestOrderHandler_GetByUID_ETag(t *testing.T) {
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
+
+	mockUseCase.On("GetByUID", mock.Anything, "test-uid-123").
+		Return(&dto.OrderOutput{OrderUID: "test-uid-123", Version: 3}, nil)
+
+	w := httptest.NewRecorder()
+	handler.GetByUID(w, withUID(httptest.NewRequest(http.MethodGet, "/api/v1/orders/test-uid-123", nil), "test-uid-123"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	// Клиент с актуальной версией получает 304 без тела
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/test-uid-123", nil)
+	req.Header.Set("If-None-Match", `"3"`)
+	w = httptest.NewRecorder()
+	handler.GetByUID(w, withUID(req, "test-uid-123"))
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+// TestOrderHandler_Update тестирует контракт If-Match
+func TestOrderHandler_Update(t *testing.T) {
+	body := `{"order_uid":"test-uid-123","track_number":"TRACK123","entry":"ADMIN"}`
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		err     error
+		status  int
+	}{
+		{"success", `"2"`, nil, http.StatusOK},
+		{"missing If-Match", "", nil, http.StatusPreconditionRequired},
+		{"weak ETag", `W/"2"`, nil, http.StatusBadRequest},
+		{"stale version", `"2"`, fmt.Errorf("failed to update order: %w", domain.ErrVersionConflict), http.StatusPreconditionFailed},
+		{"not found", `"2"`, domain.ErrOrderNotFound, http.StatusNotFound},
+		{"invalid order", `"2"`, domain.ErrInvalidOrder, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockOrderUseCase)
+			handler := NewOrderHandler(mockUseCase, nil)
+
+			if tt.status != http.StatusPreconditionRequired && tt.status != http.StatusBadRequest {
+				var output *dto.OrderOutput
+				if tt.err == nil {
+					output = &dto.OrderOutput{OrderUID: "test-uid-123", Entry: "ADMIN", Version: 3}
+				}
+				mockUseCase.On("Update", mock.Anything, "test-uid-123", mock.Anything, int64(2)).
+					Return(output, tt.err)
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/orders/test-uid-123", strings.NewReader(body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.Update(w, withUID(req, "test-uid-123"))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+			}
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+// Нарушения правил заказа возвращаются все сразу в формате RFC 7807
+func TestOrderHandler_Update_ValidationProblem(t *testing.T) {
+	invalid := &domain.Order{
+		OrderUID:    "test-uid-123",
+		TrackNumber: "TRACK123",
+		Delivery:    domain.Delivery{Phone: "+79001234567"},
+		Payment:     domain.Payment{Transaction: "test-uid-123", Currency: "USD", Amount: 100, GoodsTotal: 100},
+		Items:       []domain.Item{{TrackNumber: "TRACK123", Name: "Item", Price: -1, TotalPrice: 100}},
+	}
+	validationErr := fmt.Errorf("validation failed: %w: %w", domain.ErrInvalidOrder, invalid.ValidateWith(domain.ValidationStrict))
+
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
+	mockUseCase.On("Update", mock.Anything, "test-uid-123", mock.Anything, int64(2)).
+		Return((*dto.OrderOutput)(nil), validationErr)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/orders/test-uid-123", strings.NewReader(`{}`))
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+	handler.Update(w, withUID(req, "test-uid-123"))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem ProblemDetails
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+	assert.Equal(t, "/api/v1/admin/orders/test-uid-123", problem.Instance)
+
+	fields := make(map[string]string)
+	for _, v := range problem.Violations {
+		fields[v.Field] = v.Code
+	}
+	assert.Equal(t, map[string]string{
+		"delivery.name":        domain.ViolationRequired,
+		"items[0].price":       domain.ViolationOutOfRange,
+		"items[0].total_price": domain.ViolationMismatch,
+	}, fields)
+}
+
+// Пустой order_uid - тоже нарушение с путём поля, а не problem без violations
+func TestOrderHandler_Update_EmptyUID(t *testing.T) {
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
+	mockUseCase.On("Update", mock.Anything, "", mock.Anything, int64(2)).
+		Return((*dto.OrderOutput)(nil), domain.ErrEmptyOrderUID)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/orders/", strings.NewReader(`{}`))
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+	handler.Update(w, withUID(req, ""))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem ProblemDetails
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	if assert.Len(t, problem.Violations, 1) {
+		assert.Equal(t, "order_uid", problem.Violations[0].Field)
+		assert.Equal(t, domain.ViolationRequired, problem.Violations[0].Code)
+	}
+}
+
+// Тело не по схеме отклоняется до use case, с нарушениями схемы
+func TestOrderHandler_Update_SchemaViolation(t *testing.T) {
+	validator, err := schema.NewOrderValidator(schema.ModeStrict)
+	assert.NoError(t, err)
+
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, validator)
+
+	body := `{"order_uid":"test-uid-123","track_number":"TRACK123",` +
+		`"delivery":{"name":"Test","phone":"89001234567"},` +
+		`"payment":{"transaction":"test-uid-123","currency":"usd","amount":100},"items":[]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/orders/test-uid-123", strings.NewReader(body))
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+	handler.Update(w, withUID(req, "test-uid-123"))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem ProblemDetails
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	fields := make(map[string]string)
+	for _, v := range problem.Violations {
+		fields[v.Field] = v.Code
+	}
+	assert.Equal(t, map[string]string{
+		"delivery.phone":   domain.ViolationInvalidFormat,
+		"payment.currency": domain.ViolationInvalidFormat,
+	}, fields)
+	mockUseCase.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Тип поля не по схеме - нарушение с путём поля (RFC 7807), а не ошибка разбора JSON
+func TestOrderHandler_Update_TypeMismatch(t *testing.T) {
+	validator, err := schema.NewOrderValidator(schema.ModeStrict)
+	assert.NoError(t, err)
+
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, validator)
+
+	body := `{"order_uid":"test-uid-123","track_number":"TRACK123",` +
+		`"delivery":{"name":"Test","phone":"+79001234567"},` +
+		`"payment":{"transaction":"test-uid-123","currency":"USD","amount":"10"},"items":[]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/orders/test-uid-123", strings.NewReader(body))
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+	handler.Update(w, withUID(req, "test-uid-123"))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem ProblemDetails
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	fields := make(map[string]string)
+	for _, v := range problem.Violations {
+		fields[v.Field] = v.Code
+	}
+	assert.Equal(t, domain.ViolationInvalidType, fields["payment.amount"])
+	mockUseCase.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Битый JSON - по-прежнему 400
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/admin/orders/test-uid-123", strings.NewReader(`{"order_uid":`))
+	req.Header.Set("If-Match", `"2"`)
+	w = httptest.NewRecorder()
+	handler.Update(w, withUID(req, "test-uid-123"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestOrderHandler_History тестирует GET /api/v1/orders/:uid/history
+func TestOrderHandler_History(t *testing.T) {
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
+
+	history := &dto.OrderHistoryOutput{
+		OrderUID: "test-uid-123",
+		Changes: []*dto.OrderChangeOutput{
+			{ID: 1, Action: "created", Source: "nats", Sequence: 5, Version: 1, Snapshot: json.RawMessage(`{}`)},
+			{ID: 2, Action: "deleted", Source: "admin", Version: 1, Snapshot: json.RawMessage(`{}`)},
+		},
+	}
+	mockUseCase.On("History", mock.Anything, "test-uid-123").Return(history, nil)
+	mockUseCase.On("History", mock.Anything, "missing").Return(nil, domain.ErrOrderNotFound)
+
+	w := httptest.NewRecorder()
+	handler.History(w, withUID(httptest.NewRequest(http.MethodGet, "/api/v1/orders/test-uid-123/history", nil), "test-uid-123"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response dto.OrderHistoryOutput
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Len(t, response.Changes, 2)
+	assert.Equal(t, uint64(5), response.Changes[0].Sequence)
+	assert.Equal(t, "deleted", response.Changes[1].Action)
+
+	w = httptest.NewRecorder()
+	handler.History(w, withUID(httptest.NewRequest(http.MethodGet, "/api/v1/orders/missing/history", nil), "missing"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockUseCase.AssertExpectations(t)
+}
+
+// TestOrderHandler_ChangeStatus тестирует POST /api/v1/admin/orders/:uid/status
+func TestOrderHandler_ChangeStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifMatch         string
+		expectedVersion int64
+		err             error
+		status          int
+	}{
+		{"success", "", 0, nil, http.StatusOK},
+		{"success with If-Match", `"2"`, 2, nil, http.StatusOK},
+		{"weak ETag", `W/"2"`, 0, nil, http.StatusBadRequest},
+		{"unknown status", "", 0, fmt.Errorf("%w: %q", domain.ErrInvalidOrderStatus, "lost"), http.StatusBadRequest},
+		{"invalid transition", "", 0, fmt.Errorf("failed to change order status: %w", domain.ErrInvalidStatusTransition), http.StatusConflict},
+		{"not found", "", 0, domain.ErrOrderNotFound, http.StatusNotFound},
+		{"stale version", `"2"`, 2, domain.ErrVersionConflict, http.StatusPreconditionFailed},
+		{"database error", "", 0, errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockOrderUseCase)
+			handler := NewOrderHandler(mockUseCase, nil)
+
+			if tt.name != "weak ETag" {
+				var output *dto.OrderOutput
+				if tt.err == nil {
+					output = &dto.OrderOutput{OrderUID: "test-uid-123", Status: "paid", Version: 3}
+				}
+				input := &dto.ChangeStatusInput{OrderUID: "test-uid-123", Status: "paid", Reason: "payment confirmed"}
+				mockUseCase.On("ChangeStatus", mock.Anything, input, tt.expectedVersion, domain.ChangeOrigin{Source: domain.ChangeSourceAdmin}).
+					Return(output, tt.err)
+			}
+
+			body := `{"status":"paid","reason":"payment confirmed"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/orders/test-uid-123/status", strings.NewReader(body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.ChangeStatus(w, withUID(req, "test-uid-123"))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+			}
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+// TestOrderHandler_StatusHistory тестирует GET /api/v1/orders/:uid/status
+func TestOrderHandler_StatusHistory(t *testing.T) {
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
+
+	status := &dto.OrderStatusOutput{
+		OrderUID: "test-uid-123",
+		Status:   "paid",
+		Next:     []string{"assembling", "cancelled"},
+		Transitions: []*dto.StatusTransitionOutput{
+			{From: "created", To: "paid", Source: "nats", Sequence: 5, Version: 2},
+		},
+	}
+	mockUseCase.On("StatusHistory", mock.Anything, "test-uid-123").Return(status, nil)
+	mockUseCase.On("StatusHistory", mock.Anything, "missing").Return(nil, domain.ErrOrderNotFound)
+
+	w := httptest.NewRecorder()
+	handler.StatusHistory(w, withUID(httptest.NewRequest(http.MethodGet, "/api/v1/orders/test-uid-123/status", nil), "test-uid-123"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response dto.OrderStatusOutput
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "paid", response.Status)
+	assert.Equal(t, []string{"assembling", "cancelled"}, response.Next)
+	assert.Len(t, response.Transitions, 1)
+
+	w = httptest.NewRecorder()
+	handler.StatusHistory(w, withUID(httptest.NewRequest(http.MethodGet, "/api/v1/orders/missing/status", nil), "missing"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockUseCase.AssertExpectations(t)
+}
+
+// TestOrderHandler_Delete тестирует DELETE /api/v1/admin/orders/:uid
+func TestOrderHandler_Delete(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"success", nil, http.StatusNoContent},
+		{"not found", fmt.Errorf("failed to delete order: %w", domain.ErrOrderNotFound), http.StatusNotFound},
+		{"version mismatch", fmt.Errorf("failed to delete order: %w", domain.ErrVersionConflict), http.StatusPreconditionFailed},
+		{"database error", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockOrderUseCase)
+			handler := NewOrderHandler(mockUseCase, nil)
+			mockUseCase.On("Delete", mock.Anything, "test-uid-123", int64(3)).Return(tt.err)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/orders/test-uid-123", nil)
+			req.Header.Set("If-Match", `"3"`)
+			w := httptest.NewRecorder()
+			handler.Delete(w, withUID(req, "test-uid-123"))
+
+			assert.Equal(t, tt.status, w.Code)
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+// TestOrderHandler_Delete_Precondition - без If-Match или с неверным ETag заказ не удаляется
+func TestOrderHandler_Delete_Precondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		status  int
+	}{
+		{"missing", "", http.StatusPreconditionRequired},
+		{"weak", `W/"3"`, http.StatusBadRequest},
+		{"not a version", `"abc"`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockOrderUseCase)
+			handler := NewOrderHandler(mockUseCase, nil)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/orders/test-uid-123", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			handler.Delete(w, withUID(req, "test-uid-123"))
+
+			assert.Equal(t, tt.status, w.Code)
+			mockUseCase.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
 // TestOrderHandler_GetByUID_EmptyUID тестирует запрос с пустым UID
 func TestOrderHandler_GetByUID_EmptyUID(t *testing.T) {
 	// Arrange
 	mockUseCase := new(MockOrderUseCase)
-	handler := NewOrderHandler(mockUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
 
 	mockUseCase.On("GetByUID", mock.Anything, "").Return(nil, domain.ErrEmptyOrderUID)
 
@@ -123,7 +563,7 @@ func TestOrderHandler_GetByUID_EmptyUID(t *testing.T) {
 func TestOrderHandler_GetByUID_NotFound(t *testing.T) {
 	// Arrange
 	mockUseCase := new(MockOrderUseCase)
-	handler := NewOrderHandler(mockUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
 
 	mockUseCase.On("GetByUID", mock.Anything, "nonexistent").Return(nil, errors.New("order not found"))
 
@@ -148,11 +588,76 @@ func TestOrderHandler_GetByUID_NotFound(t *testing.T) {
 	mockUseCase.AssertExpectations(t)
 }
 
+// TestOrderHandler_List_Success тестирует выборку страницы заказов с фильтрами
+func TestOrderHandler_List_Success(t *testing.T) {
+	// Arrange
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
+
+	expectedPage := &dto.ListOrdersOutput{
+		Orders:     []*dto.OrderOutput{{OrderUID: "uid-1"}, {OrderUID: "uid-2"}},
+		NextCursor: "next",
+		HasMore:    true,
+	}
+
+	mockUseCase.On("List", mock.Anything, mock.MatchedBy(func(input *dto.ListOrdersInput) bool {
+		return input.Limit == 2 &&
+			input.CustomerID == "test" &&
+			input.Cursor == "abc" &&
+			input.DateFrom != nil && input.DateFrom.Year() == 2024
+	})).Return(expectedPage, nil)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/v1/orders?limit=2&customer_id=test&cursor=abc&date_from=2024-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.List(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response dto.ListOrdersOutput
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Orders, 2)
+	assert.Equal(t, "next", response.NextCursor)
+	assert.True(t, response.HasMore)
+
+	mockUseCase.AssertExpectations(t)
+}
+
+// TestOrderHandler_List_BadRequest тестирует невалидные параметры выборки
+func TestOrderHandler_List_BadRequest(t *testing.T) {
+	mockUseCase := new(MockOrderUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
+
+	mockUseCase.On("List", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidCursor)
+
+	tests := []string{
+		"/api/v1/orders?limit=abc",
+		"/api/v1/orders?limit=-1",
+		"/api/v1/orders?date_to=yesterday",
+		"/api/v1/orders?cursor=broken",
+	}
+
+	for _, target := range tests {
+		t.Run(target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			w := httptest.NewRecorder()
+
+			handler.List(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
 // TestOrderHandler_HealthCheck тестирует health check endpoint
 func TestOrderHandler_HealthCheck(t *testing.T) {
 	// Arrange
 	mockUseCase := new(MockOrderUseCase)
-	handler := NewOrderHandler(mockUseCase)
+	handler := NewOrderHandler(mockUseCase, nil)
 
 	stats := map[string]interface{}{
 		"cached_orders": 42,
@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"RWB_L0/internal/domain"
+)
+
+// ProblemDetails - ответ об ошибке в формате RFC 7807 (application/problem+json).
+// Violations - нарушения правил заказа, все сразу. Так отвечают только ошибки проверки
+// заказа (422); остальные ошибки API - ErrorResponse
+type ProblemDetails struct {
+	Type       string             `json:"type"`
+	Title      string             `json:"title"`
+	Status     int                `json:"status"`
+	Detail     string             `json:"detail,omitempty"`
+	Instance   string             `json:"instance,omitempty"`
+	Violations []domain.Violation `json:"violations,omitempty"`
+}
+
+// writeValidationProblem отвечает 422 с нарушениями правил заказа из err
+// (err должен содержать domain.ValidationError, иначе violations пуст)
+func writeValidationProblem(w http.ResponseWriter, r *http.Request, err error) {
+	problem := ProblemDetails{
+		Type:       "about:blank",
+		Title:      http.StatusText(http.StatusUnprocessableEntity),
+		Status:     http.StatusUnprocessableEntity,
+		Detail:     err.Error(),
+		Instance:   r.URL.Path,
+		Violations: domain.Violations(err),
+	}
+	if n := len(problem.Violations); n > 0 {
+		problem.Detail = fmt.Sprintf("Order has %d validation error(s)", n)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,15 @@
+package v1
+
+import (
+	"net/http"
+
+	"RWB_L0/internal/schema"
+)
+
+// OrderSchema обрабатывает GET /api/v1/schema/order - JSON Schema сообщения о заказе.
+// Версия контракта - в $id схемы
+func OrderSchema(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(schema.Order())
+}
@@ -0,0 +1,26 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"RWB_L0/internal/schema"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderSchema(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/schema/order", nil)
+	w := httptest.NewRecorder()
+
+	OrderSchema(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/schema+json", w.Header().Get("Content-Type"))
+
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, schema.OrderID, doc["$id"])
+}
@@ -130,3 +130,33 @@ func TestWebHandler_OrderPage_NotFound(t *testing.T) {
 
 	mockUseCase.AssertExpectations(t)
 }
+
+// Суммы на странице заказа - в валюте платежа, а не голые минимальные единицы,
+// и в одном формате "18.17 USD" при любой локали заказа
+func TestWebHandler_OrderPage_Money(t *testing.T) {
+	mockUseCase := new(MockOrderUseCase)
+	handler := &WebHandler{
+		orderUseCase: mockUseCase,
+		templates:    template.Must(template.ParseFiles("../../../../web/templates/order.html")),
+	}
+
+	order := &dto.OrderOutput{
+		OrderUID: "test-uid",
+		Locale:   "ru",
+		Payment:  dto.PaymentOutput{Currency: "USD", Amount: 1817, DeliveryCost: 1500, GoodsTotal: 317},
+		Items:    []dto.ItemOutput{{Name: "Mascaras", Price: 453, Sale: 30, TotalPrice: 317}},
+	}
+	mockUseCase.On("GetByUID", mock.Anything, "test-uid").Return(order, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/test-uid", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("order_uid", "test-uid")
+	w := httptest.NewRecorder()
+	handler.OrderPage(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	for _, want := range []string{"18.17 USD", "15.00 USD", "3.17 USD", "4.53 USD"} {
+		assert.Contains(t, w.Body.String(), want)
+	}
+	assert.NotContains(t, w.Body.String(), "1817")
+}
@@ -2,37 +2,101 @@ package nats
 
 import (
 	"context"
+	"errors"
 	"fmt"
-
-	"github.com/nats-io/stan.go"
+	"sync"
+	"time"
 
 	"RWB_L0/pkg/logger"
 	pkgnats "RWB_L0/pkg/nats"
 )
 
+// DefaultDrainTimeout - сколько ждать обработки принятых сообщений при остановке
+const DefaultDrainTimeout = 10 * time.Second
+
+// DefaultRetryDelay - пауза перед повтором временной ошибки, если RetryBackoff не задан
+const DefaultRetryDelay = time.Second
+
+// MessageObserver - получатель результатов обработки сообщений (например, метрики)
+type MessageObserver interface {
+	ObserveNATSMessage(duration time.Duration, err error)
+}
+
+// ConsumerConfig - настройки параллельной обработки
+type ConsumerConfig struct {
+	Workers      int           // Сколько сообщений обрабатывать параллельно (разных заказов)
+	QueueSize    int           // Размер очереди каждого обработчика
+	DrainTimeout time.Duration // Сколько ждать обработки принятых сообщений при остановке
+	BatchSize    int           // Сколько сообщений сохранять одним пакетом (1 - по одному)
+	BatchWait    time.Duration // Сколько ждать заполнения пакета после первого сообщения
+
+	// Временная ошибка повторяется внутри раздела, не отдавая сообщение брокеру:
+	// иначе следующие сообщения того же заказа обогнали бы его
+	MaxRetries   int             // Повторы до dead letters (0 - без лимита); как SubscriberConfig.MaxRedeliveries
+	RetryBackoff []time.Duration // Паузы перед повторами (последняя - для всех следующих)
+	Heartbeat    time.Duration   // Как часто продлевать AckWait сообщений во время паузы (0 - только перед ней)
+}
+
+// Subscriber - подписка на брокер (pkgnats.JetStreamSubscriber или pkgnats.Subscriber)
+type Subscriber interface {
+	SubscribeAsync(subject string, durableName string, dispatch func(msg pkgnats.Message)) (pkgnats.Subscription, error)
+	Settle(msg pkgnats.Message, err error)
+}
+
 // Consumer - NATS потребитель
 type Consumer struct {
-	subscriber *pkgnats.Subscriber
-	handler    *Handler
+	subscriber Subscriber
+	handle     batchHandler
 	log        logger.Logger
-	sub        stan.Subscription
+	observer   MessageObserver
+	cfg        ConsumerConfig
+
+	sub      pkgnats.Subscription
+	pool     *workerPool
+	stopOnce sync.Once
+	stopErr  error
+}
+
+// NewConsumer - создание consumer сообщений о заказах
+func NewConsumer(subscriber Subscriber, handler *Handler, log logger.Logger, observer MessageObserver, cfg ConsumerConfig) *Consumer {
+	return newConsumer(subscriber, handler.HandleOrderBatch, log, observer, cfg)
+}
+
+// NewStatusConsumer - создание consumer сообщений о смене статуса заказа.
+// Сообщения одного заказа обрабатываются по порядку: пока статус ждёт ещё не сохранённый
+// заказ (ErrOrderNotFound), следующие статусы того же заказа не применяются
+func NewStatusConsumer(subscriber Subscriber, handler *StatusHandler, log logger.Logger, observer MessageObserver, cfg ConsumerConfig) *Consumer {
+	return newConsumer(subscriber, handler.HandleStatusBatch, log, observer, cfg)
 }
 
-// NewConsumer - создание consumer
-func NewConsumer(subscriber *pkgnats.Subscriber, handler *Handler, log logger.Logger) *Consumer {
+func newConsumer(subscriber Subscriber, handle batchHandler, log logger.Logger, observer MessageObserver, cfg ConsumerConfig) *Consumer {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = DefaultDrainTimeout
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+
 	return &Consumer{
 		subscriber: subscriber,
-		handler:    handler,
+		handle:     handle,
 		log:        log,
+		observer:   observer,
+		cfg:        cfg,
 	}
 }
 
 // Start - запуск подписки
 func (c *Consumer) Start(ctx context.Context, subject string, durableName string) error {
-	c.log.Info("Starting NATS consumer for subject: %s", subject)
+	c.log.Info("Starting NATS consumer for subject: %s (%d workers, batches of up to %d)", subject, c.cfg.Workers, c.cfg.BatchSize)
+
+	// Сообщение подтверждается обработчиком пула после сохранения заказа
+	c.pool = newWorkerPool(c.cfg, c.observe(c.handle), c.subscriber.Settle)
 
-	// Подписываемся с handler
-	sub, err := c.subscriber.Subscribe(subject, durableName, c.handler.HandleOrderCreate)
+	sub, err := c.subscriber.SubscribeAsync(subject, durableName, c.pool.dispatch)
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to NATS: %w", err)
 	}
@@ -46,17 +110,57 @@ func (c *Consumer) Start(ctx context.Context, subject string, durableName string
 	return c.Stop()
 }
 
-// Stop - остановка consumer
+// observe - оборачивает обработчик замером длительности и результата.
+// Длительность сообщения - время обработки всего его пакета
+func (c *Consumer) observe(handler batchHandler) batchHandler {
+	return func(msgs []pkgnats.Message) []error {
+		started := time.Now()
+		errs := handler(msgs)
+		duration := time.Since(started)
+		for _, err := range errs {
+			c.observer.ObserveNATSMessage(duration, err)
+		}
+		return errs
+	}
+}
+
+// Stop - остановка consumer: новые сообщения больше не принимаются, уже принятые
+// дообрабатываются (не дольше DrainTimeout). Повторный вызов ничего не делает
 func (c *Consumer) Stop() error {
+	c.stopOnce.Do(func() {
+		c.stopErr = c.stop()
+	})
+	return c.stopErr
+}
+
+// stop - пул перестаёт принимать сообщения и дообрабатывает принятые, и только потом
+// закрывается подписка: через закрытую подписку подтверждения уже не доходят
+func (c *Consumer) stop() error {
 	c.log.Info("Stopping NATS consumer...")
 
+	var errs []error
+	if c.pool != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.DrainTimeout)
+		defer cancel()
+
+		if err := c.pool.drain(ctx); err != nil {
+			// Неподтверждённые сообщения NATS доставит снова после перезапуска
+			c.log.Error("Failed to drain NATS workers: %v", err)
+			errs = append(errs, err)
+		}
+	}
+
 	if c.sub != nil {
-		if err := c.sub.Unsubscribe(); err != nil {
-			c.log.Error("Failed to unsubscribe: %v", err)
-			return err
+		// Close, а не Unsubscribe: durable подписка сохраняет позицию до перезапуска
+		if err := c.sub.Close(); err != nil {
+			c.log.Error("Failed to close subscription: %v", err)
+			errs = append(errs, err)
 		}
 	}
 
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
 	c.log.Info("NATS consumer stopped successfully")
 	return nil
 }
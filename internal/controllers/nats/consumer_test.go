@@ -0,0 +1,244 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/usecase"
+	"RWB_L0/pkg/logger"
+	pkgnats "RWB_L0/pkg/nats"
+)
+
+// fakeCreator - OrderCreator, возвращающий для заказа результаты по очереди вызовов
+type fakeCreator struct {
+	mu       sync.Mutex
+	results  map[string][]domain.SaveResult
+	requests []usecase.CreateRequest
+}
+
+func (c *fakeCreator) CreateBatch(_ context.Context, requests []usecase.CreateRequest) []domain.SaveResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	results := make([]domain.SaveResult, len(requests))
+	for i, req := range requests {
+		c.requests = append(c.requests, req)
+		results[i] = domain.SaveResult{Outcome: domain.SaveCreated}
+		if queued := c.results[req.Input.OrderUID]; len(queued) > 0 {
+			results[i] = queued[0]
+			if len(queued) > 1 {
+				c.results[req.Input.OrderUID] = queued[1:]
+			}
+		}
+	}
+	return results
+}
+
+type fakeObserver struct {
+	mu         sync.Mutex
+	messages   int
+	duplicates int
+}
+
+func (o *fakeObserver) ObserveNATSMessage(time.Duration, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages++
+}
+
+func (o *fakeObserver) ObserveNATSDuplicate() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.duplicates++
+}
+
+// waitSettled - дождаться, пока все сообщения subject будут подтверждены
+func waitSettled(t *testing.T, broker *pkgnats.MemoryBroker, subject string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		settled := true
+		for _, msg := range broker.Messages(subject) {
+			settled = settled && msg.Acked()
+		}
+		if settled {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Messages were not acked in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Путь сообщения от брокера до подтверждения: успех - ack, временная ошибка - повтор
+// внутри раздела, невалидный заказ и исчерпанные повторы - dead letter и ack
+func TestConsumer_AckPaths(t *testing.T) {
+	transient := errors.New("db is down")
+	creator := &fakeCreator{results: map[string][]domain.SaveResult{
+		"flaky":     {{Err: transient}, {Outcome: domain.SaveCreated}},
+		"down":      {{Err: transient}},
+		"invalid":   {{Err: fmt.Errorf("%w: empty items", domain.ErrInvalidOrder)}},
+		"duplicate": {{Outcome: domain.SaveDuplicate}},
+	}}
+	observer := &fakeObserver{}
+	recorder := &fakeRecorder{}
+
+	var deadLetters *DeadLetterHandler
+	broker := pkgnats.NewMemoryBroker(pkgnats.SubscriberConfig{
+		MaxRedeliveries: 2,
+		Backoff:         []time.Duration{10 * time.Millisecond},
+		DeadLetter: func(msg pkgnats.Message, err error) error {
+			return deadLetters.Handle(msg, err)
+		},
+	})
+	deadLetters = NewDeadLetterHandler(recorder, broker, "orders.dead-letter", logger.New("error"))
+
+	published := make(map[string]*pkgnats.MemoryMessage)
+	for _, uid := range []string{"ok", "flaky", "down", "invalid", "duplicate"} {
+		data, _ := json.Marshal(map[string]string{"order_uid": uid})
+		published[uid] = broker.PublishMsg("orders", data, nil)
+	}
+	published["broken"] = broker.PublishMsg("orders", []byte(`{"order_uid":`), nil)
+
+	handler := NewHandler(creator, logger.New("error"), observer, nil)
+	consumer := NewConsumer(broker, handler, logger.New("error"), observer, ConsumerConfig{
+		Workers:      2,
+		MaxRetries:   2,
+		RetryBackoff: []time.Duration{10 * time.Millisecond},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Start(ctx, "orders", "service") }()
+
+	waitSettled(t, broker, "orders")
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	for uid, want := range map[string]struct{ deliveries, naks int }{
+		"ok":        {1, 0},
+		"flaky":     {1, 0},
+		"down":      {1, 0},
+		"invalid":   {1, 0},
+		"duplicate": {1, 0},
+		"broken":    {1, 0},
+	} {
+		msg := published[uid]
+		if msg.Deliveries() != want.deliveries || msg.Naks() != want.naks {
+			t.Errorf("%s: expected %d deliveries and %d naks, got %d and %d",
+				uid, want.deliveries, want.naks, msg.Deliveries(), msg.Naks())
+		}
+	}
+
+	// Невалидные сообщения - сразу, временная ошибка - после всех повторов
+	letters := make(map[string]string)
+	for _, letter := range recorder.letters {
+		letters[string(letter.Payload)] = fmt.Sprintf("permanent=%t attempts=%d", letter.Permanent, letter.Attempts)
+	}
+	for payload, want := range map[string]string{
+		`{"order_uid":"down"}`:    "permanent=false attempts=3",
+		`{"order_uid":"invalid"}`: "permanent=true attempts=1",
+		`{"order_uid":`:           "permanent=true attempts=1",
+	} {
+		if letters[payload] != want {
+			t.Errorf("Dead letter %s: expected %s, got %q", payload, want, letters[payload])
+		}
+	}
+	if len(recorder.letters) != 3 {
+		t.Errorf("Expected 3 dead letters, got %d", len(recorder.letters))
+	}
+	if n := len(broker.Messages("orders.dead-letter")); n != 3 {
+		t.Errorf("Expected 3 published dead letters, got %d", n)
+	}
+	if observer.duplicates != 1 {
+		t.Errorf("Expected 1 observed duplicate, got %d", observer.duplicates)
+	}
+
+	// Источник изменения - subject и номер сообщения
+	for _, req := range creator.requests {
+		if req.Origin.Source != domain.ChangeSourceNATS || req.Origin.Subject != "orders" || req.Origin.Sequence == 0 {
+			t.Errorf("Unexpected change origin: %+v", req.Origin)
+		}
+	}
+}
+
+// fakeSubscriber - подписка, запоминающая, было ли сообщение подтверждено до закрытия
+type fakeSubscriber struct {
+	mu       sync.Mutex
+	dispatch func(msg pkgnats.Message)
+	closed   bool
+	closeErr error
+	settled  map[uint64]bool // Номер сообщения -> подписка ещё открыта
+}
+
+func (s *fakeSubscriber) SubscribeAsync(_ string, _ string, dispatch func(msg pkgnats.Message)) (pkgnats.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dispatch = dispatch
+	return s, nil
+}
+
+func (s *fakeSubscriber) Settle(msg pkgnats.Message, _ error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settled[msg.Sequence()] = !s.closed
+}
+
+func (s *fakeSubscriber) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.closeErr
+}
+
+// Остановка дообрабатывает принятые сообщения и только потом закрывает подписку;
+// ошибка закрытия не отменяет дообработку
+func TestConsumer_StopDrainsBeforeClose(t *testing.T) {
+	closeErr := errors.New("connection closed")
+	subscriber := &fakeSubscriber{closeErr: closeErr, settled: make(map[uint64]bool)}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handle := func(msgs []pkgnats.Message) []error {
+		close(started)
+		<-release
+		return make([]error, len(msgs))
+	}
+	consumer := newConsumer(subscriber, handle, logger.New("error"), &fakeObserver{}, ConsumerConfig{Workers: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Start(ctx, "orders", "service") }()
+
+	for {
+		subscriber.mu.Lock()
+		dispatch := subscriber.dispatch
+		subscriber.mu.Unlock()
+		if dispatch != nil {
+			dispatch(orderMsg("a", 1))
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	<-started
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if err := <-done; !errors.Is(err, closeErr) {
+		t.Errorf("Expected the close error to be returned, got %v", err)
+	}
+	if open, ok := subscriber.settled[1]; !ok || !open {
+		t.Errorf("Expected the message to be settled before the subscription is closed (settled %t, open %t)", ok, open)
+	}
+}
@@ -0,0 +1,85 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/pkg/logger"
+	pkgnats "RWB_L0/pkg/nats"
+)
+
+// DeadLetterRecorder - сохранение необработанных сообщений (usecase.DeadLetterUseCase)
+type DeadLetterRecorder interface {
+	Record(ctx context.Context, letter *domain.DeadLetter) error
+}
+
+// Publisher - публикация сообщений в NATS (pkgnats.Publisher)
+type Publisher interface {
+	PublishBytes(subject string, data []byte) error
+}
+
+// deadLetterTimeout - сколько ждать сохранения dead letter в БД
+const deadLetterTimeout = 5 * time.Second
+
+// DeadLetterHandler - переносит необработанные сообщения в БД и в dead-letter subject
+type DeadLetterHandler struct {
+	recorder  DeadLetterRecorder
+	publisher Publisher
+	subject   string
+	log       logger.Logger
+}
+
+// NewDeadLetterHandler - создание обработчика dead letters
+func NewDeadLetterHandler(recorder DeadLetterRecorder, publisher Publisher, subject string, log logger.Logger) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		recorder:  recorder,
+		publisher: publisher,
+		subject:   subject,
+		log:       log,
+	}
+}
+
+// Handle - реализация pkgnats.DeadLetterHandler.
+// Ошибка означает, что сообщение не сохранено и его нельзя подтверждать
+func (h *DeadLetterHandler) Handle(msg pkgnats.Message, cause error) error {
+	letter := &domain.DeadLetter{
+		Subject:    msg.Subject(),
+		Sequence:   msg.Sequence(),
+		Payload:    msg.Data(),
+		Error:      cause.Error(),
+		Permanent:  pkgnats.IsPermanent(cause),
+		Attempts:   msg.DeliveryCount(),
+		Violations: domain.Violations(cause),
+	}
+
+	log := h.log.With(
+		logger.F("nats_subject", msg.Subject()),
+		logger.F("nats_sequence", msg.Sequence()),
+		logger.F("permanent", letter.Permanent),
+		logger.F("attempts", letter.Attempts),
+		logger.F("violations", len(letter.Violations)),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+	defer cancel()
+
+	if err := h.recorder.Record(ctx, letter); err != nil {
+		log.Error("Failed to store dead letter: %v", err)
+		return err
+	}
+
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+	if err := h.publisher.PublishBytes(h.subject, data); err != nil {
+		log.Error("Failed to publish dead letter to %s: %v", h.subject, err)
+		return err
+	}
+
+	log.Warn("Message moved to dead letters: %v", cause)
+	return nil
+}
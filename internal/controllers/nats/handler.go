@@ -3,54 +3,158 @@ package nats
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
-	"github.com/nats-io/stan.go"
-
+	"RWB_L0/internal/domain"
 	"RWB_L0/internal/dto"
 	"RWB_L0/internal/usecase"
 	"RWB_L0/pkg/logger"
+	pkgnats "RWB_L0/pkg/nats"
 )
 
+// DuplicateObserver - получатель повторных доставок уже обработанных сообщений (например, метрики)
+type DuplicateObserver interface {
+	ObserveNATSDuplicate()
+}
+
+// OrderCreator - пакетное создание заказов (usecase.OrderUseCase)
+type OrderCreator interface {
+	CreateBatch(ctx context.Context, requests []usecase.CreateRequest) []domain.SaveResult
+}
+
+// PayloadValidator - проверка сообщения до разбора JSON (schema.Validator).
+// Нарушения - domain.ValidationError
+type PayloadValidator interface {
+	Validate(data []byte) error
+}
+
 // Handler - обработчик NATS сообщений
 type Handler struct {
-	orderUseCase *usecase.OrderUseCase
+	orderUseCase OrderCreator
 	log          logger.Logger
+	observer     DuplicateObserver
+	schema       PayloadValidator
 }
 
-// NewHandler - создание handler
-func NewHandler(orderUseCase *usecase.OrderUseCase, log logger.Logger) *Handler {
+// NewHandler - создание handler. schema == nil - сообщения по схеме не проверяются
+func NewHandler(orderUseCase OrderCreator, log logger.Logger, observer DuplicateObserver, schema PayloadValidator) *Handler {
 	return &Handler{
 		orderUseCase: orderUseCase,
 		log:          log,
+		observer:     observer,
+		schema:       schema,
 	}
 }
 
-// HandleOrderCreate - обработка создания заказа
-func (h *Handler) HandleOrderCreate(msg *stan.Msg) error {
-	h.log.Debug("Received order creation message: sequence=%d", msg.Sequence)
+// HandleOrderCreate - обработка создания заказа.
+// Битый JSON, сообщение не по схеме и невалидный заказ возвращаются как постоянные ошибки (pkgnats.Permanent),
+// остальные ошибки (например, БД недоступна) - как временные, для повторной доставки
+func (h *Handler) HandleOrderCreate(msg pkgnats.Message) error {
+	return h.HandleOrderBatch([]pkgnats.Message{msg})[0]
+}
+
+// HandleOrderBatch - обработка пакета сообщений о создании заказов одним сохранением в БД.
+// Возвращает ошибку для каждого сообщения в порядке msgs (правила - как у HandleOrderCreate):
+// сообщения подтверждаются по отдельности, даже если часть заказов пакета не сохранилась
+func (h *Handler) HandleOrderBatch(msgs []pkgnats.Message) []error {
+	errs := make([]error, len(msgs))
+	logs := make([]logger.Logger, len(msgs))
 
-	// Десериализуем JSON
-	var input dto.CreateOrderInput
-	if err := json.Unmarshal(msg.Data, &input); err != nil {
-		h.log.Error("Failed to unmarshal order: %v", err)
-		return fmt.Errorf("invalid JSON: %w", err)
+	// Разбираем сообщения; requests[j] - сообщение msgs[positions[j]]
+	requests := make([]usecase.CreateRequest, 0, len(msgs))
+	positions := make([]int, 0, len(msgs))
+	for i, msg := range msgs {
+		log := h.log.With(
+			logger.F("nats_message_id", msg.ID()),
+			logger.F("nats_subject", msg.Subject()),
+			logger.F("nats_sequence", msg.Sequence()),
+		)
+		if msg.Redelivered() {
+			log.Info("Received redelivered order creation message (delivery %d)", msg.DeliveryCount())
+		} else {
+			log.Debug("Received order creation message")
+		}
+
+		// Проверяем контракт сообщения до разбора
+		if h.schema != nil {
+			if err := h.schema.Validate(msg.Data()); err != nil {
+				log.Error("Order message does not match the schema: %v", err)
+				errs[i] = pkgnats.Permanent(fmt.Errorf("schema validation failed: %w: %w", domain.ErrInvalidOrder, err))
+				continue
+			}
+		}
+
+		// Десериализуем JSON
+		var input dto.CreateOrderInput
+		if err := json.Unmarshal(msg.Data(), &input); err != nil {
+			log.Error("Failed to unmarshal order: %v", err)
+			errs[i] = pkgnats.Permanent(fmt.Errorf("invalid JSON: %w", err))
+			continue
+		}
+
+		// Валидация order_uid
+		if input.OrderUID == "" {
+			log.Error("Received order with empty order_uid")
+			errs[i] = pkgnats.Permanent(domain.ErrEmptyOrderUID)
+			continue
+		}
+
+		logs[i] = log.With(logger.F("order_uid", input.OrderUID))
+		logs[i].Info("Processing order")
+
+		requests = append(requests, usecase.CreateRequest{
+			Input: &input,
+			Origin: domain.ChangeOrigin{
+				Source:   domain.ChangeSourceNATS,
+				Subject:  msg.Subject(),
+				Sequence: msg.Sequence(),
+			},
+		})
+		positions = append(positions, i)
+	}
+	if len(requests) == 0 {
+		return errs
 	}
 
-	// Валидация order_uid
-	if input.OrderUID == "" {
-		h.log.Error("Received order with empty order_uid")
-		return fmt.Errorf("order_uid is required")
+	// Создаём заказы через Use Case
+	log := h.log
+	if len(requests) == 1 {
+		log = logs[positions[0]]
 	}
+	ctx := logger.WithContext(context.Background(), log.With(logger.F("batch_size", len(requests))))
+	results := h.orderUseCase.CreateBatch(ctx, requests)
 
-	h.log.Info("Processing order: %s", input.OrderUID)
+	for j, res := range results {
+		i := positions[j]
+		errs[i] = h.settleResult(logs[i], res)
+	}
+	return errs
+}
 
-	// Создаём заказ через Use Case
-	if err := h.orderUseCase.Create(context.Background(), &input); err != nil {
-		h.log.Error("Failed to create order %s: %v", input.OrderUID, err)
-		return fmt.Errorf("failed to create order: %w", err)
+// settleResult - логирование результата сохранения заказа и ошибка для подтверждения сообщения
+func (h *Handler) settleResult(log logger.Logger, res domain.SaveResult) error {
+	if err := res.Err; err != nil {
+		log.Error("Failed to create order: %v", err)
+		err = fmt.Errorf("failed to create order: %w", err)
+		if errors.Is(err, domain.ErrInvalidOrder) {
+			return pkgnats.Permanent(err)
+		}
+		return err
 	}
 
-	h.log.Info("Order %s successfully created and cached", input.OrderUID)
+	log = log.With(logger.F("outcome", res.Outcome))
+	switch res.Outcome {
+	case domain.SaveDuplicate:
+		// Сохранено раньше, но ack не дошёл до NATS - просто подтверждаем снова
+		h.observer.ObserveNATSDuplicate()
+		log.Info("Redelivered message already processed")
+	case domain.SaveIgnored:
+		log.Info("Duplicate order ignored")
+	case domain.SaveUpdated:
+		log.Info("Order updated")
+	default:
+		log.Info("Order created")
+	}
 	return nil
 }
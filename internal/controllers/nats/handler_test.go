@@ -1,9 +1,18 @@
 package nats
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"RWB_L0/internal/domain"
+	"RWB_L0/internal/schema"
+	"RWB_L0/pkg/logger"
+	pkgnats "RWB_L0/pkg/nats"
 )
 
 // Простой тест: проверяем, что handler создаётся
@@ -33,3 +42,238 @@ func TestOrderValidation(t *testing.T) {
 		t.Error("Expected error for empty TrackNumber, got nil")
 	}
 }
+
+// testMessage - pkgnats.Message для тестов без брокера
+type testMessage struct {
+	subject    string
+	sequence   uint64
+	data       []byte
+	deliveries int
+	inProgress int // Сколько раз продлевался AckWait
+}
+
+func (m *testMessage) ID() string                   { return fmt.Sprintf("%s:%d", m.subject, m.sequence) }
+func (m *testMessage) Subject() string              { return m.subject }
+func (m *testMessage) Sequence() uint64             { return m.sequence }
+func (m *testMessage) Data() []byte                 { return m.data }
+func (m *testMessage) Headers() map[string][]string { return nil }
+func (m *testMessage) DeliveryCount() int           { return m.deliveries }
+func (m *testMessage) Redelivered() bool            { return m.deliveries > 1 }
+func (m *testMessage) Ack() error                   { return nil }
+func (m *testMessage) Nak(time.Duration) error      { return nil }
+func (m *testMessage) InProgress() error            { m.inProgress++; return nil }
+
+func newMsg(data string) *testMessage {
+	return &testMessage{subject: "orders", sequence: 42, data: []byte(data), deliveries: 1}
+}
+
+// Битые сообщения не повторяются - сразу уходят в dead letters
+func TestHandleOrderCreate_PermanentErrors(t *testing.T) {
+	handler := NewHandler(nil, logger.New("error"), nil, nil)
+
+	for name, data := range map[string]string{
+		"invalid json":  `{"order_uid":`,
+		"empty uid":     `{"track_number":"TRACK"}`,
+		"not an object": `[]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := handler.HandleOrderCreate(newMsg(data))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !pkgnats.IsPermanent(err) {
+				t.Errorf("Expected permanent error, got %v", err)
+			}
+		})
+	}
+}
+
+// Сообщение не по схеме отклоняется до разбора и не доходит до use case
+func TestHandleOrderBatch_Schema(t *testing.T) {
+	validator, err := schema.NewOrderValidator(schema.ModeStrict)
+	if err != nil {
+		t.Fatalf("NewOrderValidator() error = %v", err)
+	}
+	creator := &fakeCreator{}
+	handler := NewHandler(creator, logger.New("error"), nil, validator)
+
+	errs := handler.HandleOrderBatch([]pkgnats.Message{
+		newMsg(`{"order_uid":"test-123","discount":5}`),
+		newMsg(`{"order_uid":`),
+	})
+
+	if !pkgnats.IsPermanent(errs[0]) || !errors.Is(errs[0], domain.ErrInvalidOrder) {
+		t.Errorf("Expected permanent ErrInvalidOrder, got %v", errs[0])
+	}
+	fields := make(map[string]string)
+	for _, v := range domain.Violations(errs[0]) {
+		fields[v.Field] = v.Code
+	}
+	if fields["discount"] != domain.ViolationUnknownField || fields["delivery"] != domain.ViolationRequired {
+		t.Errorf("Unexpected violations: %+v", domain.Violations(errs[0]))
+	}
+	if !pkgnats.IsPermanent(errs[1]) {
+		t.Errorf("Expected permanent error for invalid JSON, got %v", errs[1])
+	}
+	if len(creator.requests) != 0 {
+		t.Errorf("Rejected messages must not reach the use case, got %d requests", len(creator.requests))
+	}
+}
+
+// Результаты пакета сопоставляются с сообщениями по позиции: битое сообщение
+// не сдвигает результаты остальных, у каждого - своя ошибка или подтверждение
+func TestHandleOrderBatch_MixedResults(t *testing.T) {
+	creator := &fakeCreator{results: map[string][]domain.SaveResult{
+		"invalid":   {{Err: fmt.Errorf("validation failed: %w", domain.ErrInvalidOrder)}},
+		"db-down":   {{Err: errors.New("connection refused")}},
+		"duplicate": {{Outcome: domain.SaveDuplicate}},
+	}}
+	observer := &fakeObserver{}
+	handler := NewHandler(creator, logger.New("error"), observer, nil)
+
+	msgs := []pkgnats.Message{
+		newMsg(`{"order_uid":"created"}`),
+		newMsg(`{"order_uid":`),
+		newMsg(`{"order_uid":"invalid"}`),
+		newMsg(`{"order_uid":"db-down"}`),
+		newMsg(`{"order_uid":"duplicate"}`),
+	}
+	for i, msg := range msgs {
+		msg.(*testMessage).sequence = uint64(i + 1)
+	}
+
+	errs := handler.HandleOrderBatch(msgs)
+
+	if len(errs) != len(msgs) {
+		t.Fatalf("Expected %d results, got %d", len(msgs), len(errs))
+	}
+	if errs[0] != nil || errs[4] != nil {
+		t.Errorf("Expected created and duplicate messages to be acked, got %v, %v", errs[0], errs[4])
+	}
+	if !pkgnats.IsPermanent(errs[1]) {
+		t.Errorf("Expected permanent error for invalid JSON, got %v", errs[1])
+	}
+	if !pkgnats.IsPermanent(errs[2]) || !errors.Is(errs[2], domain.ErrInvalidOrder) {
+		t.Errorf("Expected permanent ErrInvalidOrder, got %v", errs[2])
+	}
+	if errs[3] == nil || pkgnats.IsPermanent(errs[3]) {
+		t.Errorf("Expected transient error, got %v", errs[3])
+	}
+	if observer.duplicates != 1 {
+		t.Errorf("Expected 1 duplicate, got %d", observer.duplicates)
+	}
+
+	// До use case доходят только разобранные сообщения, каждое со своим номером
+	var got []string
+	for _, req := range creator.requests {
+		got = append(got, fmt.Sprintf("%s@%d", req.Input.OrderUID, req.Origin.Sequence))
+	}
+	want := []string{"created@1", "invalid@3", "db-down@4", "duplicate@5"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected requests %v, got %v", want, got)
+	}
+}
+
+type fakeRecorder struct {
+	mu      sync.Mutex
+	letters []*domain.DeadLetter
+	err     error
+}
+
+func (r *fakeRecorder) Record(_ context.Context, letter *domain.DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.letters = append(r.letters, letter)
+	return nil
+}
+
+type fakePublisher struct {
+	subject string
+	data    []byte
+}
+
+func (p *fakePublisher) PublishBytes(subject string, data []byte) error {
+	p.subject = subject
+	p.data = data
+	return nil
+}
+
+func TestDeadLetterHandler_Handle(t *testing.T) {
+	recorder := &fakeRecorder{}
+	publisher := &fakePublisher{}
+	handler := NewDeadLetterHandler(recorder, publisher, "orders.dead-letter", logger.New("error"))
+
+	msg := newMsg(`{"order_uid":`)
+	msg.deliveries = 3
+	if err := handler.Handle(msg, pkgnats.Permanent(errors.New("invalid JSON"))); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(recorder.letters) != 1 {
+		t.Fatalf("Expected 1 stored dead letter, got %d", len(recorder.letters))
+	}
+	letter := recorder.letters[0]
+	if letter.Sequence != 42 || letter.Attempts != 3 || !letter.Permanent || string(letter.Payload) != `{"order_uid":` {
+		t.Errorf("Unexpected dead letter: %+v", letter)
+	}
+
+	if publisher.subject != "orders.dead-letter" {
+		t.Errorf("Expected publish to orders.dead-letter, got %q", publisher.subject)
+	}
+	var published domain.DeadLetter
+	if err := json.Unmarshal(publisher.data, &published); err != nil {
+		t.Fatalf("Published dead letter is not JSON: %v", err)
+	}
+	if string(published.Payload) != `{"order_uid":` {
+		t.Errorf("Expected raw payload to be preserved, got %q", published.Payload)
+	}
+}
+
+// Если dead letter не сохранён, сообщение нельзя подтверждать
+func TestDeadLetterHandler_StoreFailure(t *testing.T) {
+	recorder := &fakeRecorder{err: errors.New("db is down")}
+	publisher := &fakePublisher{}
+	handler := NewDeadLetterHandler(recorder, publisher, "orders.dead-letter", logger.New("error"))
+
+	if err := handler.Handle(newMsg(`{}`), errors.New("db is down")); err == nil {
+		t.Fatal("Expected error when dead letter cannot be stored")
+	}
+	if publisher.data != nil {
+		t.Error("Nothing should be published when storing fails")
+	}
+}
+
+// Нарушения правил заказа сохраняются и публикуются вместе с dead letter
+func TestDeadLetterHandler_Violations(t *testing.T) {
+	recorder := &fakeRecorder{}
+	publisher := &fakePublisher{}
+	handler := NewDeadLetterHandler(recorder, publisher, "orders.dead-letter", logger.New("error"))
+
+	invalid := &domain.Order{OrderUID: "test-123", TrackNumber: "TRACK"}
+	cause := pkgnats.Permanent(fmt.Errorf("failed to create order: %w: %w", domain.ErrInvalidOrder, invalid.Validate()))
+	if err := handler.Handle(newMsg(`{"order_uid":"test-123"}`), cause); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	stored := recorder.letters[0].Violations
+	if len(stored) == 0 || len(stored) != len(domain.Violations(cause)) {
+		t.Fatalf("Expected all violations to be stored, got %+v", stored)
+	}
+
+	var published domain.DeadLetter
+	if err := json.Unmarshal(publisher.data, &published); err != nil {
+		t.Fatalf("Published dead letter is not JSON: %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, v := range published.Violations {
+		fields[v.Field] = true
+	}
+	for _, field := range []string{"delivery.name", "delivery.phone", "payment.transaction", "payment.currency"} {
+		if !fields[field] {
+			t.Errorf("Expected violation for %s in the published dead letter, got %+v", field, published.Violations)
+		}
+	}
+}
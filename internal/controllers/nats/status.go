@@ -0,0 +1,81 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/dto"
+	"RWB_L0/pkg/logger"
+	pkgnats "RWB_L0/pkg/nats"
+)
+
+// StatusChanger - смена статуса заказа (usecase.OrderUseCase)
+type StatusChanger interface {
+	ChangeStatus(ctx context.Context, input *dto.ChangeStatusInput, expectedVersion int64, origin domain.ChangeOrigin) (*dto.OrderOutput, error)
+}
+
+// StatusHandler - обработчик NATS сообщений о смене статуса заказа
+type StatusHandler struct {
+	orderUseCase StatusChanger
+	log          logger.Logger
+}
+
+// NewStatusHandler - создание обработчика сообщений о статусе
+func NewStatusHandler(orderUseCase StatusChanger, log logger.Logger) *StatusHandler {
+	return &StatusHandler{
+		orderUseCase: orderUseCase,
+		log:          log,
+	}
+}
+
+// HandleStatusBatch - обработка пакета сообщений по одному, по порядку.
+// Возвращает ошибку для каждого сообщения в порядке msgs
+func (h *StatusHandler) HandleStatusBatch(msgs []pkgnats.Message) []error {
+	errs := make([]error, len(msgs))
+	for i, msg := range msgs {
+		errs[i] = h.HandleStatusChange(msg)
+	}
+	return errs
+}
+
+// HandleStatusChange - обработка смены статуса заказа.
+// Битый JSON, неизвестный статус и недопустимый переход - постоянные ошибки (pkgnats.Permanent).
+// Ненайденный заказ - временная ошибка: сообщение о статусе могло обогнать сам заказ
+func (h *StatusHandler) HandleStatusChange(msg pkgnats.Message) error {
+	log := h.log.With(
+		logger.F("nats_message_id", msg.ID()),
+		logger.F("nats_subject", msg.Subject()),
+		logger.F("nats_sequence", msg.Sequence()),
+	)
+
+	var input dto.ChangeStatusInput
+	if err := json.Unmarshal(msg.Data(), &input); err != nil {
+		log.Error("Failed to unmarshal status change: %v", err)
+		return pkgnats.Permanent(fmt.Errorf("invalid JSON: %w", err))
+	}
+	if input.OrderUID == "" {
+		log.Error("Received status change with empty order_uid")
+		return pkgnats.Permanent(domain.ErrEmptyOrderUID)
+	}
+
+	log = log.With(logger.F("order_uid", input.OrderUID), logger.F("status", input.Status))
+	origin := domain.ChangeOrigin{
+		Source:   domain.ChangeSourceNATS,
+		Subject:  msg.Subject(),
+		Sequence: msg.Sequence(),
+	}
+	order, err := h.orderUseCase.ChangeStatus(logger.WithContext(context.Background(), log), &input, 0, origin)
+	if err != nil {
+		log.Error("Failed to change order status: %v", err)
+		if errors.Is(err, domain.ErrInvalidOrderStatus) || errors.Is(err, domain.ErrInvalidStatusTransition) {
+			return pkgnats.Permanent(err)
+		}
+		return err
+	}
+
+	log.Info("Order status is %s (version %d)", order.Status, order.Version)
+	return nil
+}
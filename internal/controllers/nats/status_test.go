@@ -0,0 +1,156 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/dto"
+	"RWB_L0/pkg/logger"
+	pkgnats "RWB_L0/pkg/nats"
+)
+
+// fakeStatusChanger - StatusChanger с заданной ошибкой
+type fakeStatusChanger struct {
+	err     error
+	inputs  []*dto.ChangeStatusInput
+	origins []domain.ChangeOrigin
+}
+
+func (c *fakeStatusChanger) ChangeStatus(_ context.Context, input *dto.ChangeStatusInput, _ int64, origin domain.ChangeOrigin) (*dto.OrderOutput, error) {
+	c.inputs = append(c.inputs, input)
+	c.origins = append(c.origins, origin)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &dto.OrderOutput{OrderUID: input.OrderUID, Status: input.Status, Version: 2}, nil
+}
+
+func statusMsg(data string) *testMessage {
+	return &testMessage{subject: "orders.status", sequence: 11, data: []byte(data), deliveries: 1}
+}
+
+func TestStatusHandler_HandleStatusChange(t *testing.T) {
+	changer := &fakeStatusChanger{}
+	handler := NewStatusHandler(changer, logger.New("error"))
+
+	if err := handler.HandleStatusChange(statusMsg(`{"order_uid":"test-123","status":"paid"}`)); err != nil {
+		t.Fatalf("HandleStatusChange() error = %v", err)
+	}
+	want := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders.status", Sequence: 11}
+	if len(changer.origins) != 1 || changer.origins[0] != want {
+		t.Errorf("Expected origin %+v, got %+v", want, changer.origins)
+	}
+}
+
+// Сообщения, которые не станут корректными при повторе, сразу уходят в dead letters;
+// ненайденный заказ повторяется - сообщение о статусе могло обогнать заказ
+func TestStatusHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		err       error
+		permanent bool
+	}{
+		{"invalid json", `{"order_uid":`, nil, true},
+		{"empty uid", `{"status":"paid"}`, nil, true},
+		{"unknown status", `{"order_uid":"test-123","status":"lost"}`, domain.ErrInvalidOrderStatus, true},
+		{"invalid transition", `{"order_uid":"test-123","status":"delivered"}`, domain.ErrInvalidStatusTransition, true},
+		{"order not found", `{"order_uid":"test-123","status":"paid"}`, domain.ErrOrderNotFound, false},
+		{"database error", `{"order_uid":"test-123","status":"paid"}`, errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewStatusHandler(&fakeStatusChanger{err: tt.err}, logger.New("error"))
+
+			errs := handler.HandleStatusBatch([]pkgnats.Message{statusMsg(tt.data)})
+			if errs[0] == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if pkgnats.IsPermanent(errs[0]) != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", errs[0], !tt.permanent, tt.permanent)
+			}
+		})
+	}
+}
+
+// orderStatuses - StatusChanger с настоящими переходами: заказ появляется
+// только после missing вызовов (сообщение о статусе обогнало сам заказ)
+type orderStatuses struct {
+	mu      sync.Mutex
+	missing int
+	status  domain.OrderStatus
+	applied []string
+}
+
+func (s *orderStatuses) ChangeStatus(_ context.Context, input *dto.ChangeStatusInput, _ int64, _ domain.ChangeOrigin) (*dto.OrderOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.missing > 0 {
+		s.missing--
+		return nil, domain.ErrOrderNotFound
+	}
+	to, err := domain.ParseOrderStatus(input.Status)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.status.CheckTransition(to); err != nil {
+		return nil, err
+	}
+	s.status = to
+	s.applied = append(s.applied, input.Status)
+	return &dto.OrderOutput{OrderUID: input.OrderUID, Status: input.Status, Version: int64(len(s.applied) + 1)}, nil
+}
+
+// paid пришёл раньше заказа: раздел ждёт, пока заказ появится, и assembling
+// применяется после paid, а не уходит в dead letters как недопустимый переход
+func TestStatusConsumer_StatusBeforeOrder(t *testing.T) {
+	changer := &orderStatuses{missing: 2, status: domain.StatusCreated}
+	recorder := &fakeRecorder{}
+
+	var deadLetters *DeadLetterHandler
+	broker := pkgnats.NewMemoryBroker(pkgnats.SubscriberConfig{
+		MaxRedeliveries: 5,
+		Backoff:         []time.Duration{10 * time.Millisecond},
+		DeadLetter: func(msg pkgnats.Message, err error) error {
+			return deadLetters.Handle(msg, err)
+		},
+	})
+	deadLetters = NewDeadLetterHandler(recorder, broker, "orders.dead-letter", logger.New("error"))
+
+	paid := broker.PublishMsg("orders.status", []byte(`{"order_uid":"test-123","status":"paid"}`), nil)
+	broker.PublishMsg("orders.status", []byte(`{"order_uid":"test-123","status":"assembling"}`), nil)
+
+	consumer := NewStatusConsumer(broker, NewStatusHandler(changer, logger.New("error")), logger.New("error"), &fakeObserver{}, ConsumerConfig{
+		Workers:      2,
+		QueueSize:    4,
+		MaxRetries:   5,
+		RetryBackoff: []time.Duration{5 * time.Millisecond},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Start(ctx, "orders.status", "service") }()
+
+	waitSettled(t, broker, "orders.status")
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if got := strings.Join(changer.applied, ","); got != "paid,assembling" {
+		t.Errorf("Expected paid then assembling to be applied, got %q", got)
+	}
+	if len(recorder.letters) != 0 {
+		t.Errorf("Expected no dead letters, got %d", len(recorder.letters))
+	}
+	if paid.Naks() != 0 {
+		t.Errorf("Expected paid to be retried inside the partition, got %d naks", paid.Naks())
+	}
+}
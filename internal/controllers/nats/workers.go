@@ -0,0 +1,329 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	pkgnats "RWB_L0/pkg/nats"
+)
+
+// batchHandler - обработчик пакета сообщений; ошибки - по одной на сообщение, в порядке msgs
+type batchHandler func(msgs []pkgnats.Message) []error
+
+// workerPool - параллельная обработка сообщений с сохранением порядка внутри заказа.
+// Сообщения разбиваются по хэшу order_uid: сообщения одного заказа попадают
+// в одну очередь и обрабатываются по порядку, разных заказов - параллельно.
+// Каждый обработчик собирает сообщения своей очереди в пакеты (micro-batching):
+// до batchSize сообщений или сколько успело прийти за batchWait после первого.
+//
+// Временная ошибка повторяется внутри раздела (паузы retryBackoff), и раздел
+// стоит, пока сообщение не обработается или не уйдёт в dead letters: вернуть его
+// брокеру нельзя - следующие сообщения того же заказа обогнали бы его.
+// Чтобы брокер не доставил снова сообщения, которые ещё собираются в пакет или ждут
+// повтора, их AckWait продлевается (InProgress): после сбора пакета, перед паузой
+// и каждые heartbeat во время неё
+type workerPool struct {
+	queues       []chan pkgnats.Message
+	handler      batchHandler
+	settle       func(msg pkgnats.Message, err error)
+	batchSize    int
+	batchWait    time.Duration
+	maxRetries   int
+	retryBackoff []time.Duration
+	heartbeat    time.Duration
+
+	mu       sync.RWMutex
+	closed   bool
+	stopped  chan struct{} // Закрывается в drain: приём и повторы прекращаются
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// newWorkerPool - запуск cfg.Workers обработчиков; settle вызывается для каждого
+// сообщения пакета после handler (подтверждение - только когда заказ уже сохранён)
+func newWorkerPool(cfg ConsumerConfig, handler batchHandler, settle func(pkgnats.Message, error)) *workerPool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+	if len(cfg.RetryBackoff) == 0 {
+		cfg.RetryBackoff = []time.Duration{DefaultRetryDelay}
+	}
+
+	p := &workerPool{
+		queues:       make([]chan pkgnats.Message, cfg.Workers),
+		handler:      handler,
+		settle:       settle,
+		batchSize:    cfg.BatchSize,
+		batchWait:    cfg.BatchWait,
+		maxRetries:   cfg.MaxRetries,
+		retryBackoff: cfg.RetryBackoff,
+		heartbeat:    cfg.Heartbeat,
+		stopped:      make(chan struct{}),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan pkgnats.Message, cfg.QueueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+
+	return p
+}
+
+// dispatch - передать сообщение обработчику его заказа.
+// Блокируется, если очередь заполнена; после drain сообщения не принимаются
+// и остаются неподтверждёнными - NATS доставит их снова
+func (p *workerPool) dispatch(msg pkgnats.Message) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return
+	}
+	select {
+	case p.queues[p.partition(msg)] <- msg:
+	case <-p.stopped:
+	}
+}
+
+// drain - прекратить приём и дождаться обработки уже принятых сообщений
+func (p *workerPool) drain(ctx context.Context) error {
+	// Сначала отпускаем dispatch, ждущий места в очереди, иначе очереди не закрыть
+	p.stopOnce.Do(func() { close(p.stopped) })
+
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, queue := range p.queues {
+			close(queue)
+		}
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work - обработка очереди одного раздела по порядку, пакетами
+func (p *workerPool) work(queue <-chan pkgnats.Message) {
+	defer p.wg.Done()
+
+	// next - сообщение, не попавшее в предыдущий пакет: его заказ там уже был
+	var next pkgnats.Message
+	for {
+		msg := next
+		if msg == nil {
+			var ok bool
+			if msg, ok = <-queue; !ok {
+				return
+			}
+		}
+
+		var batch []pkgnats.Message
+		batch, next = p.collect(queue, msg)
+		inProgress(batch)
+		if !p.process(batch) {
+			// Остановка во время повторов: остальные сообщения раздела не подтверждаются,
+			// брокер доставит их снова вслед за отложенным
+			for range queue {
+			}
+			return
+		}
+	}
+}
+
+// process - обработка пакета; временные ошибки повторяются, пока сообщение не обработается
+// или не исчерпает повторы. false - пул остановлен, а сообщение так и не обработано
+func (p *workerPool) process(batch []pkgnats.Message) bool {
+	msgs := make([]*retriedMessage, len(batch))
+	for i, msg := range batch {
+		msgs[i] = &retriedMessage{Message: msg}
+	}
+
+	for attempt := 0; ; attempt++ {
+		errs := p.handler(messages(msgs))
+
+		// Каждое сообщение подтверждается со своим результатом; временные ошибки - на повтор
+		pending := msgs[:0]
+		for i, msg := range msgs {
+			if errs[i] == nil || pkgnats.IsPermanent(errs[i]) || p.exhausted(msg) {
+				p.settle(msg, errs[i])
+				continue
+			}
+			msg.err = errs[i]
+			pending = append(pending, msg)
+		}
+		if len(pending) == 0 {
+			return true
+		}
+		msgs = pending
+
+		if !p.wait(messages(msgs), p.retryDelay(attempt)) {
+			for _, msg := range msgs {
+				p.settle(msg, msg.err)
+			}
+			return false
+		}
+		for _, msg := range msgs {
+			msg.retries++
+		}
+	}
+}
+
+// wait - пауза перед повтором msgs. AckWait сообщений продлевается перед паузой
+// и каждые heartbeat во время неё. false - пул остановлен во время паузы
+func (p *workerPool) wait(msgs []pkgnats.Message, delay time.Duration) bool {
+	inProgress(msgs)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var tick <-chan time.Time
+	if p.heartbeat > 0 {
+		ticker := time.NewTicker(p.heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-timer.C:
+			return true
+		case <-tick:
+			inProgress(msgs)
+		case <-p.stopped:
+			return false
+		}
+	}
+}
+
+// inProgress - отсрочить повторную доставку сообщений, которые ещё обрабатываются.
+// Ошибка не мешает обработке: в худшем случае брокер доставит дубль,
+// а его отбросит отметка об обработке
+func inProgress(msgs []pkgnats.Message) {
+	for _, msg := range msgs {
+		_ = msg.InProgress()
+	}
+}
+
+// exhausted - повторы сообщения исчерпаны (maxRetries 0 - без лимита)
+func (p *workerPool) exhausted(msg *retriedMessage) bool {
+	return p.maxRetries > 0 && msg.DeliveryCount()-1 >= p.maxRetries
+}
+
+// retryDelay - пауза перед повтором attempt (с нуля); последняя пауза - для всех следующих
+func (p *workerPool) retryDelay(attempt int) time.Duration {
+	return p.retryBackoff[min(attempt, len(p.retryBackoff)-1)]
+}
+
+// retriedMessage - сообщение, повторяемое внутри раздела. Повторы считаются доставками,
+// чтобы лимит повторов и число попыток в dead letter учитывали и их
+type retriedMessage struct {
+	pkgnats.Message
+	retries int
+	err     error // Последняя временная ошибка
+}
+
+func (m *retriedMessage) DeliveryCount() int {
+	return m.Message.DeliveryCount() + m.retries
+}
+
+func (m *retriedMessage) Redelivered() bool {
+	return m.DeliveryCount() > 1
+}
+
+func messages(msgs []*retriedMessage) []pkgnats.Message {
+	out := make([]pkgnats.Message, len(msgs))
+	for i, msg := range msgs {
+		out[i] = msg
+	}
+	return out
+}
+
+// collect - добирает в пакет сообщения из очереди, пока он не заполнится или не пройдёт
+// batchWait. Без batchWait берутся только уже ожидающие в очереди сообщения.
+// Заказ попадает в пакет не больше одного раза: следующее его сообщение возвращается
+// как next и начинает новый пакет, иначе оно обработалось бы раньше повтора предыдущего
+func (p *workerPool) collect(queue <-chan pkgnats.Message, first pkgnats.Message) (batch []pkgnats.Message, next pkgnats.Message) {
+	batch = append(make([]pkgnats.Message, 0, p.batchSize), first)
+	orders := map[string]bool{orderKey(first): true}
+
+	var timeout <-chan time.Time
+	if p.batchWait > 0 {
+		timer := time.NewTimer(p.batchWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for len(batch) < p.batchSize {
+		var msg pkgnats.Message
+		var ok bool
+		if timeout == nil {
+			select {
+			case msg, ok = <-queue:
+			default:
+				return batch, nil
+			}
+		} else {
+			select {
+			case msg, ok = <-queue:
+			case <-timeout:
+				return batch, nil
+			}
+		}
+		if !ok {
+			return batch, nil
+		}
+
+		key := orderKey(msg)
+		if key != "" && orders[key] {
+			return batch, msg
+		}
+		orders[key] = true
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
+// partition - номер очереди по order_uid. Сообщения без order_uid (битые)
+// попадают в первую очередь - обработчик всё равно отправит их в dead letters
+func (p *workerPool) partition(msg pkgnats.Message) int {
+	if len(p.queues) == 1 {
+		return 0
+	}
+
+	key := orderKey(msg)
+	if key == "" {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+// orderKey - order_uid сообщения ("" - нет или JSON битый)
+func orderKey(msg pkgnats.Message) string {
+	var key struct {
+		OrderUID string `json:"order_uid"`
+	}
+	if err := json.Unmarshal(msg.Data(), &key); err != nil {
+		return ""
+	}
+	return key.OrderUID
+}
@@ -0,0 +1,440 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pkgnats "RWB_L0/pkg/nats"
+)
+
+func orderMsg(orderUID string, sequence uint64) *testMessage {
+	return &testMessage{
+		subject:    "orders",
+		sequence:   sequence,
+		data:       []byte(fmt.Sprintf(`{"order_uid":%q}`, orderUID)),
+		deliveries: 1,
+	}
+}
+
+// perMessage - пакетный обработчик, вызывающий handler для каждого сообщения
+func perMessage(handler func(pkgnats.Message) error) batchHandler {
+	return func(msgs []pkgnats.Message) []error {
+		errs := make([]error, len(msgs))
+		for i, msg := range msgs {
+			errs[i] = handler(msg)
+		}
+		return errs
+	}
+}
+
+// settled - сообщения, переданные в settle, с результатом обработки
+type settled struct {
+	mu   sync.Mutex
+	errs map[uint64]error
+}
+
+func (s *settled) settle(msg pkgnats.Message, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs[msg.Sequence()] = err
+}
+
+// wait - дождаться, пока в settle попадут n сообщений
+func (s *settled) wait(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		s.mu.Lock()
+		got := len(s.errs)
+		s.mu.Unlock()
+		if got >= n {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("Expected %d settled messages, got %d", n, got)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// Сообщения одного заказа обрабатываются по порядку, каждое подтверждается после обработки
+func TestWorkerPool_PerOrderOrdering(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string][]uint64)
+	failed := errors.New("db is down")
+
+	handler := func(msg pkgnats.Message) error {
+		var input struct {
+			OrderUID string `json:"order_uid"`
+		}
+		_ = json.Unmarshal(msg.Data(), &input)
+		orderUID := input.OrderUID
+		time.Sleep(time.Duration(msg.Sequence()%3) * time.Millisecond)
+
+		mu.Lock()
+		seen[orderUID] = append(seen[orderUID], msg.Sequence())
+		mu.Unlock()
+
+		if msg.Sequence() == 7 {
+			return failed
+		}
+		return nil
+	}
+
+	results := &settled{errs: make(map[uint64]error)}
+	cfg := ConsumerConfig{Workers: 4, QueueSize: 2, BatchSize: 3, MaxRetries: 1, RetryBackoff: []time.Duration{time.Millisecond}}
+	pool := newWorkerPool(cfg, perMessage(handler), results.settle)
+
+	orders := []string{"a", "b", "c", "d", "e"}
+	for seq := uint64(1); seq <= 50; seq++ {
+		pool.dispatch(orderMsg(orders[seq%uint64(len(orders))], seq))
+	}
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	for orderUID, sequences := range seen {
+		for i := 1; i < len(sequences); i++ {
+			if sequences[i] < sequences[i-1] {
+				t.Errorf("Order %s processed out of order: %v", orderUID, sequences)
+				break
+			}
+		}
+	}
+
+	if len(results.errs) != 50 {
+		t.Fatalf("Expected 50 settled messages, got %d", len(results.errs))
+	}
+	if !errors.Is(results.errs[7], failed) || results.errs[8] != nil {
+		t.Errorf("Expected handler results to be passed to settle, got %v and %v", results.errs[7], results.errs[8])
+	}
+}
+
+// Медленный заказ не задерживает заказы из других разделов
+func TestWorkerPool_Parallel(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan uint64, 2)
+
+	handler := func(msg pkgnats.Message) error {
+		if msg.Sequence() == 1 {
+			<-release
+		}
+		done <- msg.Sequence()
+		return nil
+	}
+
+	pool := newWorkerPool(ConsumerConfig{Workers: 2, QueueSize: 1}, perMessage(handler), func(pkgnats.Message, error) {})
+
+	// Подбираем второй заказ из другого раздела
+	slow := orderMsg("slow", 1)
+	var fast *testMessage
+	for i := 0; ; i++ {
+		fast = orderMsg(fmt.Sprintf("fast-%d", i), 2)
+		if pool.partition(fast) != pool.partition(slow) {
+			break
+		}
+	}
+
+	pool.dispatch(slow)
+	pool.dispatch(fast)
+
+	select {
+	case seq := <-done:
+		if seq != 2 {
+			t.Errorf("Expected the fast order first, got %d", seq)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Fast order was blocked by the slow one")
+	}
+
+	close(release)
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+}
+
+// drain дожидается принятых сообщений, новые после него не принимаются
+func TestWorkerPool_Drain(t *testing.T) {
+	release := make(chan struct{})
+	results := &settled{errs: make(map[uint64]error)}
+
+	handler := func(msg pkgnats.Message) error {
+		<-release
+		return nil
+	}
+	pool := newWorkerPool(ConsumerConfig{Workers: 1, QueueSize: 4}, perMessage(handler), results.settle)
+
+	pool.dispatch(orderMsg("a", 1))
+	pool.dispatch(orderMsg("a", 2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected drain to time out while messages are in flight, got %v", err)
+	}
+
+	pool.dispatch(orderMsg("a", 3))
+	close(release)
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	if len(results.errs) != 2 {
+		t.Errorf("Expected the 2 accepted messages to be settled, got %d", len(results.errs))
+	}
+	if _, ok := results.errs[3]; ok {
+		t.Error("Messages dispatched after drain must not be processed")
+	}
+}
+
+// Сообщения собираются в пакеты не больше BatchSize; неполный пакет уходит через BatchWait
+func TestWorkerPool_Batching(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	handler := func(msgs []pkgnats.Message) []error {
+		mu.Lock()
+		sizes = append(sizes, len(msgs))
+		mu.Unlock()
+		return make([]error, len(msgs))
+	}
+
+	results := &settled{errs: make(map[uint64]error)}
+	cfg := ConsumerConfig{Workers: 1, QueueSize: 10, BatchSize: 4, BatchWait: time.Hour}
+	pool := newWorkerPool(cfg, handler, results.settle)
+
+	for seq := uint64(1); seq <= 8; seq++ {
+		pool.dispatch(orderMsg(fmt.Sprintf("o%d", seq), seq))
+	}
+
+	// Два полных пакета не ждут BatchWait
+	deadline := time.After(time.Second)
+	for {
+		mu.Lock()
+		n := len(sizes)
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("Full batches were not flushed, got %v", sizes)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// Остаток обрабатывается при остановке, не дожидаясь BatchWait
+	pool.dispatch(orderMsg("o9", 9))
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	if fmt.Sprint(sizes) != "[4 4 1]" {
+		t.Errorf("Expected batches [4 4 1], got %v", sizes)
+	}
+	if len(results.errs) != 9 {
+		t.Errorf("Expected 9 settled messages, got %d", len(results.errs))
+	}
+}
+
+// Каждое сообщение пакета подтверждается со своим результатом
+func TestWorkerPool_BatchPartialFailure(t *testing.T) {
+	failed := errors.New("invalid order")
+	release := make(chan struct{})
+	handler := func(msgs []pkgnats.Message) []error {
+		<-release
+		errs := make([]error, len(msgs))
+		for i, msg := range msgs {
+			if msg.Sequence()%2 == 0 {
+				errs[i] = pkgnats.Permanent(failed)
+			}
+		}
+		return errs
+	}
+
+	results := &settled{errs: make(map[uint64]error)}
+	cfg := ConsumerConfig{Workers: 1, QueueSize: 10, BatchSize: 10, BatchWait: 50 * time.Millisecond}
+	pool := newWorkerPool(cfg, handler, results.settle)
+
+	for seq := uint64(1); seq <= 5; seq++ {
+		pool.dispatch(orderMsg(fmt.Sprintf("o%d", seq), seq))
+	}
+	close(release)
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	for seq := uint64(1); seq <= 5; seq++ {
+		err, ok := results.errs[seq]
+		if !ok {
+			t.Errorf("Message %d was not settled", seq)
+			continue
+		}
+		if wantFailed := seq%2 == 0; errors.Is(err, failed) != wantFailed {
+			t.Errorf("Message %d settled with %v", seq, err)
+		}
+	}
+}
+
+// Временная ошибка держит раздел: следующее сообщение того же заказа не обрабатывается,
+// пока предыдущее не сохранится, даже если оба пришли в одном пакете
+func TestWorkerPool_TransientErrorHoldsPartition(t *testing.T) {
+	var mu sync.Mutex
+	var applied []uint64
+	failures := 2
+	handler := func(msg pkgnats.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if msg.Sequence() == 1 && failures > 0 {
+			failures--
+			return errors.New("db is down")
+		}
+		applied = append(applied, msg.Sequence())
+		return nil
+	}
+
+	results := &settled{errs: make(map[uint64]error)}
+	cfg := ConsumerConfig{Workers: 1, QueueSize: 4, BatchSize: 4, MaxRetries: 5, RetryBackoff: []time.Duration{time.Millisecond}}
+	pool := newWorkerPool(cfg, perMessage(handler), results.settle)
+
+	pool.dispatch(orderMsg("a", 1))
+	pool.dispatch(orderMsg("a", 2))
+	pool.dispatch(orderMsg("b", 3))
+	results.wait(t, 3)
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	position := make(map[uint64]int)
+	for i, seq := range applied {
+		position[seq] = i
+	}
+	if len(applied) != 3 || position[1] > position[2] {
+		t.Errorf("Message 2 must not be applied before message 1, got %v", applied)
+	}
+	for seq := uint64(1); seq <= 3; seq++ {
+		if err, ok := results.errs[seq]; !ok || err != nil {
+			t.Errorf("Expected message %d to be settled successfully, got %v (settled %t)", seq, err, ok)
+		}
+	}
+}
+
+// Исчерпанные повторы уходят в settle с числом попыток, раздел идёт дальше
+func TestWorkerPool_RetriesExhausted(t *testing.T) {
+	failed := errors.New("db is down")
+	var mu sync.Mutex
+	attempts := 0
+	handler := func(msg pkgnats.Message) error {
+		if msg.Sequence() == 1 {
+			mu.Lock()
+			attempts++
+			mu.Unlock()
+			return failed
+		}
+		return nil
+	}
+
+	var deliveries int
+	results := &settled{errs: make(map[uint64]error)}
+	settle := func(msg pkgnats.Message, err error) {
+		if msg.Sequence() == 1 {
+			deliveries = msg.DeliveryCount()
+		}
+		results.settle(msg, err)
+	}
+	cfg := ConsumerConfig{Workers: 1, QueueSize: 4, MaxRetries: 2, RetryBackoff: []time.Duration{time.Millisecond}}
+	pool := newWorkerPool(cfg, perMessage(handler), settle)
+
+	pool.dispatch(orderMsg("a", 1))
+	pool.dispatch(orderMsg("a", 2))
+	results.wait(t, 2)
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	if attempts != 3 || deliveries != 3 {
+		t.Errorf("Expected 3 attempts counted as deliveries, got %d attempts and %d deliveries", attempts, deliveries)
+	}
+	if !errors.Is(results.errs[1], failed) || results.errs[2] != nil {
+		t.Errorf("Unexpected results: %v", results.errs)
+	}
+}
+
+// Остановка прерывает повторы: отложенное сообщение возвращается брокеру,
+// следующие сообщения раздела не обрабатываются
+func TestWorkerPool_StopWhileRetrying(t *testing.T) {
+	failed := errors.New("db is down")
+	started := make(chan struct{}, 1)
+	handler := func(msg pkgnats.Message) error {
+		if msg.Sequence() == 1 {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			return failed
+		}
+		return nil
+	}
+
+	results := &settled{errs: make(map[uint64]error)}
+	cfg := ConsumerConfig{Workers: 1, QueueSize: 4, RetryBackoff: []time.Duration{time.Hour}}
+	pool := newWorkerPool(cfg, perMessage(handler), results.settle)
+
+	pool.dispatch(orderMsg("a", 1))
+	pool.dispatch(orderMsg("a", 2))
+	<-started
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	if !errors.Is(results.errs[1], failed) {
+		t.Errorf("Expected the held message to be settled with its error, got %v", results.errs[1])
+	}
+	if _, ok := results.errs[2]; ok {
+		t.Error("Messages behind the held one must not be processed")
+	}
+}
+
+// Собранный пакет и сообщение, ждущее повтора, продлевают AckWait: иначе брокер
+// доставил бы их снова, пока они ещё в разделе
+func TestWorkerPool_InProgressWhileRetrying(t *testing.T) {
+	failures := 2
+	handler := func(msg pkgnats.Message) error {
+		if msg.Sequence() == 1 && failures > 0 {
+			failures--
+			return errors.New("db is down")
+		}
+		return nil
+	}
+
+	results := &settled{errs: make(map[uint64]error)}
+	cfg := ConsumerConfig{
+		Workers:      1,
+		QueueSize:    4,
+		BatchSize:    4,
+		RetryBackoff: []time.Duration{50 * time.Millisecond},
+		Heartbeat:    10 * time.Millisecond,
+	}
+	pool := newWorkerPool(cfg, perMessage(handler), results.settle)
+
+	retried, other := orderMsg("a", 1), orderMsg("b", 2)
+	pool.dispatch(retried)
+	pool.dispatch(other)
+	results.wait(t, 2)
+	if err := pool.drain(context.Background()); err != nil {
+		t.Fatalf("drain() error = %v", err)
+	}
+
+	if other.inProgress < 1 {
+		t.Error("Expected the collected batch to extend its AckWait")
+	}
+	// Пакет, две паузы перед повторами и продления во время пауз
+	if retried.inProgress < 4 {
+		t.Errorf("Expected the retried message to extend its AckWait during pauses, got %d", retried.inProgress)
+	}
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// DeadLetter - сообщение, которое не удалось обработать.
+// Хранит исходные байты, чтобы сообщение можно было разобрать вручную или переотправить
+type DeadLetter struct {
+	ID        int64     `json:"id"`
+	Subject   string    `json:"subject"`
+	Sequence  uint64    `json:"sequence"`
+	Payload   []byte    `json:"payload"`
+	Error     string    `json:"error"`
+	Permanent bool      `json:"permanent"` // false - исчерпан лимит повторов временной ошибки
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	// Violations - нарушения правил заказа, если сообщение не прошло проверку
+	Violations []Violation `json:"violations,omitempty"`
+}
@@ -20,4 +20,54 @@ var (
 	ErrEmptyTrackNumber = errors.New("track number cannot be empty")
 
 	ErrOrderNotFound = errors.New("order not found")
+
+	// Бизнес-правила заказа (Order.ValidateWith)
+
+	ErrItemTrackNumberMismatch = errors.New("item track number does not match the order")
+
+	ErrTransactionMismatch = errors.New("payment transaction does not match order UID")
+
+	ErrInvalidItemSale = errors.New("item sale must be between 0 and 100")
+
+	ErrItemTotalMismatch = errors.New("item total price does not match price and sale")
+
+	ErrGoodsTotalMismatch = errors.New("payment goods total does not match items total")
+
+	ErrPaymentAmountMismatch = errors.New("payment amount does not match goods total, delivery cost and custom fee")
+
+	ErrInvalidCurrency = errors.New("payment currency must be an ISO 4217 code")
+
+	// Суммы (Money)
+
+	// ErrMoneyOverflow - результат не помещается в int64 минимальных единиц
+	ErrMoneyOverflow = errors.New("money amount overflow")
+
+	ErrCurrencyMismatch = errors.New("money currencies do not match")
+
+	ErrInvalidLocale = errors.New("invalid locale")
+
+	ErrInvalidEmail = errors.New("invalid delivery email")
+
+	ErrInvalidPhone = errors.New("delivery phone must be in E.164 format")
+
+	ErrInvalidListLimit = errors.New("list limit must be greater than 0")
+
+	ErrInvalidDateRange = errors.New("date range start must be before its end")
+
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+	// ErrInvalidOrder - заказ не прошёл разбор или валидацию; повторная обработка не поможет
+	ErrInvalidOrder = errors.New("invalid order")
+
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	// ErrVersionConflict - заказ изменён после того, как его прочитал клиент
+	ErrVersionConflict = errors.New("order version conflict")
+
+	// Статус заказа (OrderStatus)
+
+	ErrInvalidOrderStatus = errors.New("unknown order status")
+
+	// ErrInvalidStatusTransition - переход не разрешён таблицей переходов; повтор не поможет
+	ErrInvalidStatusTransition = errors.New("order status transition is not allowed")
 )
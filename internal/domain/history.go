@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ========================================
+// ChangeSource - откуда пришло изменение заказа
+// ========================================
+
+type ChangeSource string
+
+const (
+	// ChangeSourceNATS - сообщение из канала NATS
+	ChangeSourceNATS ChangeSource = "nats"
+	// ChangeSourceAdmin - admin API (правка, удаление, повтор dead letter)
+	ChangeSourceAdmin ChangeSource = "admin"
+)
+
+// ChangeOrigin - источник изменения, который записывается в историю заказа.
+// Для сообщений NATS пара (Subject, Sequence) - ещё и ключ идемпотентности:
+// повторно доставленное сообщение не сохраняется второй раз
+type ChangeOrigin struct {
+	Source   ChangeSource
+	Subject  string // Subject сообщения NATS; пусто - без проверки повторной доставки
+	Sequence uint64 // Номер сообщения NATS; 0 - изменение пришло не из NATS
+}
+
+// ========================================
+// ChangeAction - что произошло с заказом
+// ========================================
+
+type ChangeAction string
+
+const (
+	ChangeCreated ChangeAction = "created"
+	ChangeUpdated ChangeAction = "updated"
+	ChangeDeleted ChangeAction = "deleted"
+	// ChangeStatusChanged - изменился только статус заказа
+	ChangeStatusChanged ChangeAction = "status_changed"
+)
+
+// OrderChange - неизменяемая запись истории заказа.
+// Snapshot - заказ целиком (JSON) после изменения, для удаления - до него
+type OrderChange struct {
+	ID        int64           `json:"id"`
+	OrderUID  string          `json:"order_uid"`
+	Action    ChangeAction    `json:"action"`
+	Source    ChangeSource    `json:"source"`
+	Sequence  uint64          `json:"sequence"`
+	Version   int64           `json:"version"`
+	Snapshot  json.RawMessage `json:"snapshot"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -35,35 +35,42 @@ func NewDelivery(name, phone, zip, city, address, region, email string) (*Delive
 	}, nil
 }
 
+// Validate - проверка обязательных полей; все нарушения - одним ValidationError
 func (d *Delivery) Validate() error {
+	var v validator
+	d.validate(&v, "")
+	return v.err()
+}
+
+func (d *Delivery) validate(v *validator, path string) {
 	if d.Name == "" {
-		return ErrEmptyDeliveryName
+		v.add(fieldPath(path, "name"), ErrEmptyDeliveryName)
 	}
 	if d.Phone == "" {
-		return ErrEmptyDeliveryPhone
+		v.add(fieldPath(path, "phone"), ErrEmptyDeliveryPhone)
 	}
-	return nil
 }
 
 // ========================================
 // Payment - информация о платеже
 // ========================================
 
+// Payment - суммы в минимальных единицах валюты Currency (1817 USD - это 18.17 USD)
 type Payment struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDt    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	Transaction  string     `json:"transaction"`
+	RequestID    string     `json:"request_id"`
+	Currency     Currency   `json:"currency"`
+	Provider     string     `json:"provider"`
+	Amount       MinorUnits `json:"amount"`
+	PaymentDt    int64      `json:"payment_dt"`
+	Bank         string     `json:"bank"`
+	DeliveryCost MinorUnits `json:"delivery_cost"`
+	GoodsTotal   MinorUnits `json:"goods_total"`
+	CustomFee    MinorUnits `json:"custom_fee"`
 }
 
-func NewPayment(transaction, requestID, currency, provider, bank string,
-	amount int, paymentDt int64, deliveryCost, goodsTotal, customFee int) (*Payment, error) {
+func NewPayment(transaction, requestID string, currency Currency, provider, bank string,
+	amount MinorUnits, paymentDt int64, deliveryCost, goodsTotal, customFee MinorUnits) (*Payment, error) {
 
 	if transaction == "" {
 		return nil, ErrEmptyPaymentTransaction
@@ -86,36 +93,48 @@ func NewPayment(transaction, requestID, currency, provider, bank string,
 	}, nil
 }
 
+// Validate - проверка обязательных полей; все нарушения - одним ValidationError
 func (p *Payment) Validate() error {
+	var v validator
+	p.validate(&v, "")
+	return v.err()
+}
+
+// Money - сумма amount в валюте платежа (например, p.Money(p.Amount) или цена товара)
+func (p *Payment) Money(amount MinorUnits) Money {
+	return NewMoney(amount, p.Currency)
+}
+
+func (p *Payment) validate(v *validator, path string) {
 	if p.Transaction == "" {
-		return ErrEmptyPaymentTransaction
+		v.add(fieldPath(path, "transaction"), ErrEmptyPaymentTransaction)
 	}
 	if p.Amount <= 0 {
-		return ErrInvalidPaymentAmount
+		v.add(fieldPath(path, "amount"), ErrInvalidPaymentAmount)
 	}
-	return nil
 }
 
 // ========================================
 // Item - товар в заказе
 // ========================================
 
+// Item - цены в минимальных единицах валюты платежа заказа
 type Item struct {
-	ChrtID      int    `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	Rid         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NmID        int    `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
+	ChrtID      int        `json:"chrt_id"`
+	TrackNumber string     `json:"track_number"`
+	Price       MinorUnits `json:"price"`
+	Rid         string     `json:"rid"`
+	Name        string     `json:"name"`
+	Sale        int        `json:"sale"`
+	Size        string     `json:"size"`
+	TotalPrice  MinorUnits `json:"total_price"`
+	NmID        int        `json:"nm_id"`
+	Brand       string     `json:"brand"`
+	Status      int        `json:"status"`
 }
 
 func NewItem(chrtID int, trackNumber, name, rid, size, brand string,
-	price, sale, totalPrice, nmID, status int) (*Item, error) {
+	price MinorUnits, sale int, totalPrice MinorUnits, nmID, status int) (*Item, error) {
 
 	if name == "" {
 		return nil, ErrEmptyItemName
@@ -139,14 +158,20 @@ func NewItem(chrtID int, trackNumber, name, rid, size, brand string,
 	}, nil
 }
 
+// Validate - проверка обязательных полей; все нарушения - одним ValidationError
 func (i *Item) Validate() error {
+	var v validator
+	i.validate(&v, "")
+	return v.err()
+}
+
+func (i *Item) validate(v *validator, path string) {
 	if i.Name == "" {
-		return ErrEmptyItemName
+		v.add(fieldPath(path, "name"), ErrEmptyItemName)
 	}
 	if i.Price < 0 {
-		return ErrInvalidItemPrice
+		v.add(fieldPath(path, "price"), ErrInvalidItemPrice)
 	}
-	return nil
 }
 
 // ========================================
@@ -154,20 +179,22 @@ func (i *Item) Validate() error {
 // ========================================
 
 type Order struct {
-	OrderUID          string    `json:"order_uid"`
-	TrackNumber       string    `json:"track_number"`
-	Entry             string    `json:"entry"`
-	Delivery          Delivery  `json:"delivery"`
-	Payment           Payment   `json:"payment"`
-	Items             []Item    `json:"items"`
-	Locale            string    `json:"locale"`
-	InternalSignature string    `json:"internal_signature"`
-	CustomerID        string    `json:"customer_id"`
-	DeliveryService   string    `json:"delivery_service"`
-	Shardkey          string    `json:"shardkey"`
-	SmID              int       `json:"sm_id"`
-	DateCreated       time.Time `json:"date_created"`
-	OofShard          string    `json:"oof_shard"`
+	OrderUID          string      `json:"order_uid"`
+	TrackNumber       string      `json:"track_number"`
+	Entry             string      `json:"entry"`
+	Delivery          Delivery    `json:"delivery"`
+	Payment           Payment     `json:"payment"`
+	Items             []Item      `json:"items"`
+	Locale            string      `json:"locale"`
+	InternalSignature string      `json:"internal_signature"`
+	CustomerID        string      `json:"customer_id"`
+	DeliveryService   string      `json:"delivery_service"`
+	Shardkey          string      `json:"shardkey"`
+	SmID              int         `json:"sm_id"`
+	DateCreated       time.Time   `json:"date_created"`
+	OofShard          string      `json:"oof_shard"`
+	Status            OrderStatus `json:"status"`  // Этап жизненного цикла; меняется только переходами
+	Version           int64       `json:"version"` // Номер версии в БД, растёт при каждом изменении
 }
 
 func NewOrder(orderUID, trackNumber, entry string) (*Order, error) {
@@ -183,32 +210,45 @@ func NewOrder(orderUID, trackNumber, entry string) (*Order, error) {
 		TrackNumber: trackNumber,
 		Entry:       entry,
 		DateCreated: time.Now(),
+		Status:      StatusCreated,
 		Items:       make([]Item, 0),
 	}, nil
 }
 
+// Validate - проверка заказа в режиме по умолчанию (DefaultValidationMode)
 func (o *Order) Validate() error {
+	return o.ValidateWith(DefaultValidationMode)
+}
+
+// ValidateWith - проверка обязательных полей и бизнес-правил заказа в режиме mode.
+// Все нарушения возвращаются одним ValidationError
+func (o *Order) ValidateWith(mode ValidationMode) error {
+	var v validator
+	o.validate(&v)
+	o.checkRules(&v, mode)
+	return v.err()
+}
+
+// ValidateFields - проверка только обязательных полей заказа, без бизнес-правил
+func (o *Order) ValidateFields() error {
+	var v validator
+	o.validate(&v)
+	return v.err()
+}
+
+func (o *Order) validate(v *validator) {
 	if o.OrderUID == "" {
-		return ErrEmptyOrderUID
+		v.add("order_uid", ErrEmptyOrderUID)
 	}
 	if o.TrackNumber == "" {
-		return ErrEmptyTrackNumber
-	}
-
-	if err := o.Delivery.Validate(); err != nil {
-		return err
-	}
-	if err := o.Payment.Validate(); err != nil {
-		return err
+		v.add("track_number", ErrEmptyTrackNumber)
 	}
 
-	for _, item := range o.Items {
-		if err := item.Validate(); err != nil {
-			return err
-		}
+	o.Delivery.validate(v, "delivery")
+	o.Payment.validate(v, "payment")
+	for i := range o.Items {
+		o.Items[i].validate(v, itemPath(i))
 	}
-
-	return nil
 }
 
 func (o *Order) AddItem(item Item) error {
@@ -219,8 +259,9 @@ func (o *Order) AddItem(item Item) error {
 	return nil
 }
 
-func (o *Order) GetTotal() int {
-	return o.Payment.Amount
+// GetTotal - сумма заказа в валюте платежа
+func (o *Order) GetTotal() Money {
+	return o.Payment.Money(o.Payment.Amount)
 }
 
 func (o *Order) GetItemsCount() int {
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ========================================
+// Currency - код валюты ISO 4217
+// ========================================
+
+type Currency string
+
+// currencies - действующие коды валют ISO 4217 и число знаков после запятой
+// у минимальной единицы (у большинства валют 2: центы, копейки)
+var currencies = func() map[Currency]int {
+	codes := strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV
+		BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUP CVE CZK
+		DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL
+		HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT
+		LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR
+		MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF
+		SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP
+		TRY TTD TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XAG XAU
+		XBA XBB XBC XBD XCD XCG XDR XOF XPD XPF XPT XSU XTS XUA XXX YER ZAR ZMW ZWG
+	`)
+	set := make(map[Currency]int, len(codes))
+	for _, code := range codes {
+		set[Currency(code)] = 2
+	}
+
+	// Валюты без дробной части, а также расчётные единицы и драгоценные металлы (X..),
+	// у которых минимальной единицы нет
+	for _, code := range strings.Fields(`
+		BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF
+		XAG XAU XBA XBB XBC XBD XDR XPD XPT XSU XTS XUA XXX
+	`) {
+		set[Currency(code)] = 0
+	}
+	for _, code := range strings.Fields(`BHD IQD JOD KWD LYD OMR TND`) {
+		set[Currency(code)] = 3
+	}
+	for _, code := range strings.Fields(`CLF UYW`) {
+		set[Currency(code)] = 4
+	}
+	return set
+}()
+
+// Currencies - все действующие коды по алфавиту (перечень payment.currency в схеме заказа)
+func Currencies() []Currency {
+	codes := make([]Currency, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Valid - действующий код ISO 4217 (регистр важен: "usd" - не код)
+func (c Currency) Valid() bool {
+	_, ok := currencies[c]
+	return ok
+}
+
+// Exponent - знаков после запятой у минимальной единицы: 2 для USD, 0 для JPY, 3 для KWD.
+// Для неизвестного кода - 2
+func (c Currency) Exponent() int {
+	if exp, ok := currencies[c]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ========================================
+// MinorUnits - сумма в минимальных единицах валюты (центы, копейки)
+// ========================================
+
+// MinorUnits - целое число минимальных единиц: 1817 в USD - это 18.17 USD.
+// Суммы хранятся и передаются только так, без дробей
+type MinorUnits int64
+
+// addMinor - сумма с проверкой переполнения int64
+func addMinor(a, b MinorUnits) (MinorUnits, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, fmt.Errorf("%w: %d + %d", ErrMoneyOverflow, a, b)
+	}
+	return sum, nil
+}
+
+// mulMinor - произведение с проверкой переполнения int64
+func mulMinor(a MinorUnits, n int64) (MinorUnits, error) {
+	if a == 0 || n == 0 {
+		return 0, nil
+	}
+	product := a * MinorUnits(n)
+	// MinInt64 * -1 == MinInt64: деление переполнение не замечает
+	if product/MinorUnits(n) != a || (n == -1 && a == math.MinInt64) {
+		return 0, fmt.Errorf("%w: %d * %d", ErrMoneyOverflow, a, n)
+	}
+	return product, nil
+}
+
+// ========================================
+// Money - сумма с валютой
+// ========================================
+
+// Money - сумма в минимальных единицах вместе с валютой. Арифметика проверяет
+// совпадение валют (ErrCurrencyMismatch) и переполнение (ErrMoneyOverflow)
+type Money struct {
+	Amount   MinorUnits `json:"amount"`
+	Currency Currency   `json:"currency"`
+}
+
+// NewMoney - сумма amount минимальных единиц валюты currency
+func NewMoney(amount MinorUnits, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Add - m + other; валюты должны совпадать
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	amount, err := addMinor(m.Amount, other.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(amount, m.Currency), nil
+}
+
+// Sum - сумма amounts в валюте currency (пустой список - ноль)
+func Sum(currency Currency, amounts ...Money) (Money, error) {
+	total := NewMoney(0, currency)
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// String - сумма с точкой и кодом валюты, без разделителей разрядов: "18.17 USD"
+func (m Money) String() string {
+	integer, fraction, negative := m.split()
+
+	var sb strings.Builder
+	if negative {
+		sb.WriteByte('-')
+	}
+	sb.WriteString(strconv.FormatUint(integer, 10))
+	if fraction != "" {
+		sb.WriteByte('.')
+		sb.WriteString(fraction)
+	}
+	sb.WriteString(" " + string(m.Currency))
+	return sb.String()
+}
+
+// split - целая часть, дробная часть (ровно Exponent цифр) и знак суммы
+func (m Money) split() (integer uint64, fraction string, negative bool) {
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		negative = true
+		abs = -abs // Верно и для math.MinInt64
+	}
+
+	exp := m.Currency.Exponent()
+	if exp == 0 {
+		return abs, "", negative
+	}
+	scale := uint64(math.Pow10(exp))
+	fraction = strconv.FormatUint(abs%scale, 10)
+	fraction = strings.Repeat("0", exp-len(fraction)) + fraction
+	return abs / scale, fraction, negative
+}
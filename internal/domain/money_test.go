@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMoney_Arithmetic(t *testing.T) {
+	usd := func(amount MinorUnits) Money { return NewMoney(amount, "USD") }
+
+	tests := []struct {
+		name    string
+		op      func() (Money, error)
+		want    Money
+		wantErr error
+	}{
+		{"add", func() (Money, error) { return usd(317).Add(usd(1500)) }, usd(1817), nil},
+		{"sum", func() (Money, error) { return Sum("USD", usd(317), usd(1500), usd(0)) }, usd(1817), nil},
+		{"empty sum", func() (Money, error) { return Sum("EUR") }, NewMoney(0, "EUR"), nil},
+
+		{"currency mismatch", func() (Money, error) { return usd(1).Add(NewMoney(1, "EUR")) }, Money{}, ErrCurrencyMismatch},
+		{"sum currency mismatch", func() (Money, error) { return Sum("USD", usd(1), NewMoney(1, "RUB")) }, Money{}, ErrCurrencyMismatch},
+		{"add overflow", func() (Money, error) { return usd(math.MaxInt64).Add(usd(1)) }, Money{}, ErrMoneyOverflow},
+		{"add negative overflow", func() (Money, error) { return usd(math.MinInt64).Add(usd(-1)) }, Money{}, ErrMoneyOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(1817, "USD"), "18.17 USD"},
+		{NewMoney(5, "USD"), "0.05 USD"},
+		{NewMoney(-1183, "USD"), "-11.83 USD"},
+		{NewMoney(123456789, "USD"), "1234567.89 USD"},
+		{NewMoney(1500, "JPY"), "1500 JPY"},
+		{NewMoney(1500, "KWD"), "1.500 KWD"},
+		{NewMoney(math.MinInt64, "USD"), "-92233720368547758.08 USD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	for code, exp := range map[Currency]int{"USD": 2, "RUB": 2, "JPY": 0, "KWD": 3, "CLF": 4} {
+		if !code.Valid() {
+			t.Errorf("%s.Valid() = false, want true", code)
+		}
+		if code.Exponent() != exp {
+			t.Errorf("%s.Exponent() = %d, want %d", code, code.Exponent(), exp)
+		}
+	}
+
+	for _, code := range []Currency{"", "usd", "ABC", "US"} {
+		if code.Valid() {
+			t.Errorf("%q.Valid() = true, want false", code)
+		}
+	}
+}
+
+func TestMulMinor(t *testing.T) {
+	if got, err := mulMinor(453, 3); err != nil || got != 1359 {
+		t.Errorf("mulMinor(453, 3) = %d, %v", got, err)
+	}
+	for _, tt := range []struct {
+		a MinorUnits
+		n int64
+	}{{math.MaxInt64 / 2, 3}, {math.MinInt64, -1}} {
+		if _, err := mulMinor(tt.a, tt.n); !errors.Is(err, ErrMoneyOverflow) {
+			t.Errorf("mulMinor(%d, %d) error = %v, want ErrMoneyOverflow", tt.a, tt.n, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ========================================
+// OrderEventType - тип события о заказе для внешних систем
+// ========================================
+
+type OrderEventType string
+
+const (
+	// OrderEventCreated - заказ сохранён впервые
+	OrderEventCreated OrderEventType = "order.created"
+	// OrderEventUpdated - сохранённый заказ заменён
+	OrderEventUpdated OrderEventType = "order.updated"
+	// OrderEventStatusChanged - изменился статус заказа
+	OrderEventStatusChanged OrderEventType = "order.status_changed"
+)
+
+// OrderEvent - тело события, публикуемого в NATS.
+// Доставка не реже одного раза: получатель отбрасывает повтор по (order_uid, version)
+type OrderEvent struct {
+	Type       OrderEventType `json:"type"`
+	OrderUID   string         `json:"order_uid"`
+	Version    int64          `json:"version"`
+	Order      *Order         `json:"order"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// OutboxEvent - событие, сохранённое вместе с заказом и ожидающее публикации
+type OutboxEvent struct {
+	ID        int64
+	Type      OrderEventType
+	OrderUID  string
+	Payload   json.RawMessage // OrderEvent в JSON
+	Attempts  int             // Неудачные попытки публикации
+	CreatedAt time.Time
+}
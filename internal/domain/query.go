@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// ========================================
+// OrderListQuery - параметры выборки списка заказов
+// ========================================
+
+// OrderFilter - фильтры для выборки заказов (пустые поля не учитываются)
+type OrderFilter struct {
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	Entry           string
+	Locale          string
+	CreatedFrom     time.Time // включительно
+	CreatedTo       time.Time // не включительно
+}
+
+// OrderCursor - позиция keyset-пагинации (date_created DESC, order_uid DESC)
+type OrderCursor struct {
+	DateCreated time.Time
+	OrderUID    string
+}
+
+// OrderListQuery - запрос страницы заказов
+type OrderListQuery struct {
+	Filter OrderFilter
+	After  *OrderCursor // nil - с первой страницы
+	Limit  int
+}
+
+// OrderPage - страница заказов
+type OrderPage struct {
+	Orders     []*Order
+	NextCursor *OrderCursor // nil - страниц больше нет
+}
+
+func (q *OrderListQuery) Validate() error {
+	if q.Limit <= 0 {
+		return ErrInvalidListLimit
+	}
+	if !q.Filter.CreatedFrom.IsZero() && !q.Filter.CreatedTo.IsZero() &&
+		!q.Filter.CreatedFrom.Before(q.Filter.CreatedTo) {
+		return ErrInvalidDateRange
+	}
+	return nil
+}
+
+// CursorOf - курсор, указывающий на заказ
+func CursorOf(order *Order) *OrderCursor {
+	return &OrderCursor{
+		DateCreated: order.DateCreated,
+		OrderUID:    order.OrderUID,
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ========================================
+// OrderStatus - этап жизненного цикла заказа
+// ========================================
+
+type OrderStatus string
+
+const (
+	// StatusCreated - заказ сохранён, оплаты ещё нет (статус нового заказа)
+	StatusCreated OrderStatus = "created"
+	// StatusPaid - заказ оплачен
+	StatusPaid OrderStatus = "paid"
+	// StatusAssembling - заказ собирается на складе
+	StatusAssembling OrderStatus = "assembling"
+	// StatusShipped - заказ передан в доставку
+	StatusShipped OrderStatus = "shipped"
+	// StatusDelivered - заказ получен покупателем
+	StatusDelivered OrderStatus = "delivered"
+	// StatusCancelled - заказ отменён до отправки
+	StatusCancelled OrderStatus = "cancelled"
+	// StatusReturned - заказ возвращён после отправки
+	StatusReturned OrderStatus = "returned"
+)
+
+// statusTransitions - разрешённые переходы между статусами.
+// Статусы без переходов (cancelled, returned) - конечные
+var statusTransitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:    {StatusPaid, StatusCancelled},
+	StatusPaid:       {StatusAssembling, StatusCancelled},
+	StatusAssembling: {StatusShipped, StatusCancelled},
+	StatusShipped:    {StatusDelivered, StatusReturned},
+	StatusDelivered:  {StatusReturned},
+}
+
+// ParseOrderStatus - статус из строки (сообщение NATS, admin API)
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	switch status := OrderStatus(s); status {
+	case StatusCreated, StatusPaid, StatusAssembling, StatusShipped,
+		StatusDelivered, StatusCancelled, StatusReturned:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidOrderStatus, s)
+	}
+}
+
+// Next - статусы, в которые можно перейти из s
+func (s OrderStatus) Next() []OrderStatus {
+	return statusTransitions[s]
+}
+
+// IsFinal - из статуса переходов нет
+func (s OrderStatus) IsFinal() bool {
+	return len(statusTransitions[s]) == 0
+}
+
+// CheckTransition - разрешён ли переход из s в to; иначе ErrInvalidStatusTransition
+func (s OrderStatus) CheckTransition(to OrderStatus) error {
+	for _, next := range statusTransitions[s] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, s, to)
+}
+
+// StatusChange - смена статуса заказа
+type StatusChange struct {
+	To     OrderStatus
+	Reason string // Причина для истории (например, "payment confirmed"); может быть пустой
+	// ExpectedVersion - если не 0, статус меняется, только если версия заказа равна ей
+	// (иначе ErrVersionConflict)
+	ExpectedVersion int64
+	// Origin - источник изменения; для NATS (Subject, Sequence) - ключ идемпотентности
+	Origin ChangeOrigin
+}
+
+// StatusTransition - неизменяемая запись истории статусов заказа
+type StatusTransition struct {
+	ID        int64        `json:"id"`
+	OrderUID  string       `json:"order_uid"`
+	From      OrderStatus  `json:"from"`
+	To        OrderStatus  `json:"to"`
+	Reason    string       `json:"reason"`
+	Source    ChangeSource `json:"source"`
+	Sequence  uint64       `json:"sequence"`
+	Version   int64        `json:"version"` // Версия заказа после перехода
+	CreatedAt time.Time    `json:"created_at"`
+}
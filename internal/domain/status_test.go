@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// Полная таблица переходов: всё, что не разрешено явно, запрещено
+func TestOrderStatus_CheckTransition(t *testing.T) {
+	allowed := map[[2]OrderStatus]bool{
+		{StatusCreated, StatusPaid}:         true,
+		{StatusCreated, StatusCancelled}:    true,
+		{StatusPaid, StatusAssembling}:      true,
+		{StatusPaid, StatusCancelled}:       true,
+		{StatusAssembling, StatusShipped}:   true,
+		{StatusAssembling, StatusCancelled}: true,
+		{StatusShipped, StatusDelivered}:    true,
+		{StatusShipped, StatusReturned}:     true,
+		{StatusDelivered, StatusReturned}:   true,
+	}
+	statuses := []OrderStatus{StatusCreated, StatusPaid, StatusAssembling, StatusShipped,
+		StatusDelivered, StatusCancelled, StatusReturned}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			err := from.CheckTransition(to)
+			if allowed[[2]OrderStatus{from, to}] {
+				if err != nil {
+					t.Errorf("%s -> %s: unexpected error %v", from, to, err)
+				}
+				continue
+			}
+			if !errors.Is(err, ErrInvalidStatusTransition) {
+				t.Errorf("%s -> %s: expected ErrInvalidStatusTransition, got %v", from, to, err)
+			}
+		}
+	}
+
+	for _, status := range []OrderStatus{StatusCancelled, StatusReturned} {
+		if !status.IsFinal() || len(status.Next()) != 0 {
+			t.Errorf("Expected %s to be final", status)
+		}
+	}
+	if StatusShipped.IsFinal() {
+		t.Error("Shipped order must not be final")
+	}
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	for _, s := range []string{"created", "paid", "assembling", "shipped", "delivered", "cancelled", "returned"} {
+		got, err := ParseOrderStatus(s)
+		if err != nil || string(got) != s {
+			t.Errorf("ParseOrderStatus(%q) = %q, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{"", "Paid", "canceled"} {
+		if _, err := ParseOrderStatus(s); !errors.Is(err, ErrInvalidOrderStatus) {
+			t.Errorf("ParseOrderStatus(%q) error = %v, want ErrInvalidOrderStatus", s, err)
+		}
+	}
+}
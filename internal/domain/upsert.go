@@ -0,0 +1,87 @@
+package domain
+
+import "fmt"
+
+// ========================================
+// UpsertPolicy - что делать с заказом, order_uid которого уже сохранён
+// ========================================
+
+type UpsertPolicy string
+
+const (
+	// UpsertReplace - полностью заменить сохранённый заказ присланным
+	UpsertReplace UpsertPolicy = "replace"
+	// UpsertReject - оставить сохранённый заказ, присланный считать дубликатом
+	UpsertReject UpsertPolicy = "reject"
+	// UpsertNewer - заменить, только если сообщение NATS с присланным заказом новее того,
+	// что записало сохранённый (по номеру сообщения в stream, а не по date_created)
+	UpsertNewer UpsertPolicy = "newer"
+)
+
+// ParseUpsertPolicy - политика из строки конфигурации (пустая строка - UpsertReplace)
+func ParseUpsertPolicy(s string) (UpsertPolicy, error) {
+	switch policy := UpsertPolicy(s); policy {
+	case "":
+		return UpsertReplace, nil
+	case UpsertReplace, UpsertReject, UpsertNewer:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown upsert policy %q (want replace, reject or newer)", s)
+	}
+}
+
+// SaveOptions - параметры сохранения заказа
+type SaveOptions struct {
+	Policy UpsertPolicy
+	// ExpectedVersion - если не 0, заказ должен существовать и иметь эту версию,
+	// иначе ErrOrderNotFound или ErrVersionConflict (compare-and-swap)
+	ExpectedVersion int64
+	// Origin - источник изменения для истории заказа
+	Origin ChangeOrigin
+}
+
+// ========================================
+// SaveOutcome - результат сохранения заказа
+// ========================================
+
+type SaveOutcome string
+
+const (
+	// SaveCreated - заказ сохранён впервые
+	SaveCreated SaveOutcome = "created"
+	// SaveUpdated - сохранённый заказ заменён присланным
+	SaveUpdated SaveOutcome = "updated"
+	// SaveIgnored - заказ уже сохранён, присланный отброшен политикой
+	SaveIgnored SaveOutcome = "ignored"
+	// SaveDuplicate - сообщение NATS уже обработано, это повторная доставка
+	SaveDuplicate SaveOutcome = "duplicate"
+)
+
+// Resolve - результат сохранения присланного заказа поверх уже сохранённого.
+// storedSequence - номер сообщения NATS, последним записавшего сохранённый заказ
+// (0 - не из NATS), incoming - источник присланного. Для UpsertNewer заказ не из NATS
+// (Sequence 0) заменяет сохранённый всегда, как и любое сообщение - заказ, записанный не из NATS
+func (p UpsertPolicy) Resolve(storedSequence uint64, incoming ChangeOrigin) SaveOutcome {
+	switch p {
+	case UpsertReject:
+		return SaveIgnored
+	case UpsertNewer:
+		if incoming.Sequence != 0 && incoming.Sequence <= storedSequence {
+			return SaveIgnored
+		}
+	}
+	return SaveUpdated
+}
+
+// SaveRequest - заказ и параметры его сохранения в пакетном сохранении
+type SaveRequest struct {
+	Order   *Order
+	Options SaveOptions
+}
+
+// SaveResult - результат сохранения одного заказа пакета.
+// Err != nil - заказ не сохранён, остальные заказы пакета это не затрагивает
+type SaveResult struct {
+	Outcome SaveOutcome
+	Err     error
+}
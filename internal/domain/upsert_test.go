@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestParseUpsertPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    UpsertPolicy
+		wantErr bool
+	}{
+		{"", UpsertReplace, false},
+		{"replace", UpsertReplace, false},
+		{"reject", UpsertReject, false},
+		{"newer", UpsertNewer, false},
+		{"merge", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseUpsertPolicy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUpsertPolicy(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseUpsertPolicy(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertPolicy_Resolve(t *testing.T) {
+	const stored = 10
+	nats := func(seq uint64) ChangeOrigin {
+		return ChangeOrigin{Source: ChangeSourceNATS, Subject: "orders", Sequence: seq}
+	}
+
+	tests := []struct {
+		name           string
+		policy         UpsertPolicy
+		storedSequence uint64
+		incoming       ChangeOrigin
+		want           SaveOutcome
+	}{
+		{"replace older", UpsertReplace, stored, nats(5), SaveUpdated},
+		{"reject newer", UpsertReject, stored, nats(20), SaveIgnored},
+		{"newer: older message is ignored", UpsertNewer, stored, nats(5), SaveIgnored},
+		{"newer: same message is ignored", UpsertNewer, stored, nats(stored), SaveIgnored},
+		{"newer: newer message replaces", UpsertNewer, stored, nats(20), SaveUpdated},
+		{"newer: order not from NATS replaces", UpsertNewer, stored, ChangeOrigin{Source: ChangeSourceAdmin}, SaveUpdated},
+		{"newer: stored not from NATS is replaced", UpsertNewer, 0, nats(5), SaveUpdated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Resolve(tt.storedSequence, tt.incoming); got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ========================================
+// ValidationMode - насколько строго проверять согласованность заказа
+// ========================================
+
+type ValidationMode string
+
+const (
+	// ValidationStrict - все бизнес-правила, включая согласованность сумм
+	ValidationStrict ValidationMode = "strict"
+	// ValidationLenient - суммы платежа и товаров не сверяются друг с другом
+	// (источник иногда присылает несогласованные суммы); остальные правила действуют
+	ValidationLenient ValidationMode = "lenient"
+
+	// DefaultValidationMode - режим, если он не задан (ORDER_VALIDATION_MODE, Config, Validate):
+	// источник иногда присылает несогласованные суммы
+	DefaultValidationMode = ValidationLenient
+)
+
+// ParseValidationMode - режим из строки конфигурации (пустая строка - DefaultValidationMode)
+func ParseValidationMode(s string) (ValidationMode, error) {
+	switch mode := ValidationMode(s); mode {
+	case "":
+		return DefaultValidationMode, nil
+	case ValidationStrict, ValidationLenient:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown validation mode %q (want strict or lenient)", s)
+	}
+}
+
+var (
+	// Язык ISO 639 и необязательный регион: en, ru, en-US, en_US, es-419
+	localePattern = regexp.MustCompile(`^[a-z]{2,3}([-_]([A-Z]{2}|[0-9]{3}))?$`)
+
+	// Правдоподобный адрес: локальная часть, @ и домен с точкой, без пробелов
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s.]+$`)
+
+	phonePattern = regexp.MustCompile(PhonePattern)
+)
+
+// PhonePattern - телефон в E.164: +, код страны без ведущего нуля, всего не больше 15 цифр.
+// Тот же шаблон - у delivery.phone в схеме заказа (schema/order.v1.json)
+const PhonePattern = `^\+[1-9][0-9]{1,14}$`
+
+// checkRules - бизнес-правила заказа поверх проверок отдельных полей.
+// Нарушения - ошибки домена с подробностями; в ValidationLenient суммы не сверяются
+func (o *Order) checkRules(v *validator, mode ValidationMode) {
+	if o.Payment.Transaction != "" && o.Payment.Transaction != o.OrderUID {
+		v.add("payment.transaction", fmt.Errorf("%w: transaction %q, order_uid %q", ErrTransactionMismatch, o.Payment.Transaction, o.OrderUID))
+	}
+	if !o.Payment.Currency.Valid() {
+		v.add("payment.currency", fmt.Errorf("%w: %q", ErrInvalidCurrency, o.Payment.Currency))
+	}
+	if o.Locale != "" && !localePattern.MatchString(o.Locale) {
+		v.add("locale", fmt.Errorf("%w: %q", ErrInvalidLocale, o.Locale))
+	}
+	if o.Delivery.Email != "" && !emailPattern.MatchString(o.Delivery.Email) {
+		v.add("delivery.email", fmt.Errorf("%w: %q", ErrInvalidEmail, o.Delivery.Email))
+	}
+	if o.Delivery.Phone != "" && !phonePattern.MatchString(o.Delivery.Phone) {
+		v.add("delivery.phone", fmt.Errorf("%w: %q", ErrInvalidPhone, o.Delivery.Phone))
+	}
+
+	// Суммы складываются как Money: переполнение - нарушение, а не неверная сверка
+	goodsTotal, overflow := o.Payment.Money(0), false
+	for i, item := range o.Items {
+		path := itemPath(i)
+		if item.TrackNumber != o.TrackNumber {
+			v.add(path+".track_number", fmt.Errorf("%w: %q, order has %q", ErrItemTrackNumberMismatch, item.TrackNumber, o.TrackNumber))
+		}
+		switch {
+		case item.Sale < 0 || item.Sale > 100:
+			v.add(path+".sale", fmt.Errorf("%w: %d", ErrInvalidItemSale, item.Sale))
+		case mode != ValidationLenient:
+			if err := item.checkTotal(); err != nil {
+				v.add(path+".total_price", err)
+			}
+		}
+
+		if !overflow {
+			total, err := goodsTotal.Add(o.Payment.Money(item.TotalPrice))
+			if err != nil {
+				v.add(path+".total_price", fmt.Errorf("items total: %w", err))
+			}
+			goodsTotal, overflow = total, err != nil
+		}
+	}
+
+	if mode == ValidationLenient {
+		return
+	}
+	if !overflow && o.Payment.GoodsTotal != goodsTotal.Amount {
+		v.add("payment.goods_total", fmt.Errorf("%w: goods_total %d, items total %d", ErrGoodsTotalMismatch, o.Payment.GoodsTotal, goodsTotal.Amount))
+	}
+	want, err := Sum(o.Payment.Currency,
+		o.Payment.Money(o.Payment.GoodsTotal), o.Payment.Money(o.Payment.DeliveryCost), o.Payment.Money(o.Payment.CustomFee))
+	switch {
+	case err != nil:
+		v.add("payment.amount", fmt.Errorf("expected amount: %w", err))
+	case o.Payment.Amount != want.Amount:
+		v.add("payment.amount", fmt.Errorf("%w: amount %d, expected %d", ErrPaymentAmountMismatch, o.Payment.Amount, want.Amount))
+	}
+}
+
+// itemPath - путь товара с индексом i
+func itemPath(i int) string {
+	return "items[" + strconv.Itoa(i) + "]"
+}
+
+// checkTotal - total_price равна цене со скидкой sale процентов
+// с точностью до округления (источник округляет и вниз, и до ближайшего)
+func (i *Item) checkTotal() error {
+	exact, err := mulMinor(i.Price, int64(100-i.Sale)) // Цена со скидкой, умноженная на 100
+	if err != nil {
+		return err
+	}
+	total, err := mulMinor(i.TotalPrice, 100)
+	if err != nil {
+		return err
+	}
+
+	// Модуль разности без знака: total - exact может не поместиться в int64
+	diff := uint64(total - exact)
+	if total < exact {
+		diff = uint64(exact - total)
+	}
+	if diff >= 100 {
+		return fmt.Errorf("%w: price %d, sale %d%%, total_price %d", ErrItemTotalMismatch, i.Price, i.Sale, i.TotalPrice)
+	}
+	return nil
+}
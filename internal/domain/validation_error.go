@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Коды нарушений - стабильные значения для клиентов (сообщения могут меняться)
+const (
+	ViolationRequired      = "required"
+	ViolationOutOfRange    = "out_of_range"
+	ViolationInvalidFormat = "invalid_format"
+	ViolationMismatch      = "mismatch"
+	ViolationInvalidType   = "invalid_type"  // Проверка по JSON Schema: значение другого типа
+	ViolationUnknownField  = "unknown_field" // Проверка по JSON Schema: поле не описано в контракте
+)
+
+// violationCodes - код нарушения по ошибке домена
+var violationCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrEmptyOrderUID, ViolationRequired},
+	{ErrEmptyTrackNumber, ViolationRequired},
+	{ErrEmptyDeliveryName, ViolationRequired},
+	{ErrEmptyDeliveryPhone, ViolationRequired},
+	{ErrEmptyPaymentTransaction, ViolationRequired},
+	{ErrEmptyItemName, ViolationRequired},
+	{ErrInvalidPaymentAmount, ViolationOutOfRange},
+	{ErrInvalidItemPrice, ViolationOutOfRange},
+	{ErrInvalidItemSale, ViolationOutOfRange},
+	{ErrMoneyOverflow, ViolationOutOfRange},
+	{ErrInvalidCurrency, ViolationInvalidFormat},
+	{ErrInvalidLocale, ViolationInvalidFormat},
+	{ErrInvalidEmail, ViolationInvalidFormat},
+	{ErrInvalidPhone, ViolationInvalidFormat},
+	{ErrTransactionMismatch, ViolationMismatch},
+	{ErrItemTrackNumberMismatch, ViolationMismatch},
+	{ErrItemTotalMismatch, ViolationMismatch},
+	{ErrGoodsTotalMismatch, ViolationMismatch},
+	{ErrPaymentAmountMismatch, ViolationMismatch},
+}
+
+// Violation - одно нарушение правил заказа
+type Violation struct {
+	Field   string `json:"field"`   // JSON путь поля: "delivery.phone", "items[2].price"
+	Code    string `json:"code"`    // Вид нарушения: required, out_of_range, invalid_format, mismatch
+	Message string `json:"message"` // Описание для человека
+
+	err error
+}
+
+// ValidationError - все нарушения правил заказа сразу, чтобы источник исправил их за один раз.
+// errors.Is находит ошибки домена отдельных нарушений (например, ErrEmptyDeliveryName)
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Field + ": " + v.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		if v.err != nil {
+			errs = append(errs, v.err)
+		}
+	}
+	return errs
+}
+
+// NewValidationError - ValidationError с одним нарушением поля field;
+// код нарушения - по ошибке домена err, как при проверке заказа
+func NewValidationError(field string, err error) *ValidationError {
+	var v validator
+	v.add(field, err)
+	return &ValidationError{Violations: v.violations}
+}
+
+// Violations - нарушения из цепочки err (nil, если в ней нет ValidationError)
+func Violations(err error) []Violation {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	return verr.Violations
+}
+
+// validator - собирает нарушения с путями полей
+type validator struct {
+	violations []Violation
+}
+
+// add - нарушение поля; err - ошибка домена, возможно с подробностями (fmt.Errorf("%w: ..."))
+func (v *validator) add(field string, err error) {
+	code := ViolationInvalidFormat
+	for _, c := range violationCodes {
+		if errors.Is(err, c.err) {
+			code = c.code
+			break
+		}
+	}
+	v.violations = append(v.violations, Violation{Field: field, Code: code, Message: err.Error(), err: err})
+}
+
+// err - ValidationError со всеми нарушениями или nil
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+// fieldPath - путь поля name внутри path ("" - корень заказа)
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Все нарушения собираются сразу, с путями полей и кодами; errors.Is находит ошибки домена
+func TestValidationError_Aggregates(t *testing.T) {
+	order := sampleOrder()
+	order.Delivery.Name = ""
+	order.Delivery.Phone = "8900"
+	order.Payment.Currency = "usd"
+	order.Items = append(order.Items,
+		Item{TrackNumber: order.TrackNumber, Name: "Free", Price: 0, TotalPrice: 0},
+		Item{TrackNumber: "OTHER", Price: -5, Sale: 120},
+	)
+
+	err := fmt.Errorf("validation failed: %w: %w", ErrInvalidOrder, order.Validate())
+
+	want := map[string]string{
+		"delivery.name":         ViolationRequired,
+		"delivery.phone":        ViolationInvalidFormat,
+		"payment.currency":      ViolationInvalidFormat,
+		"items[2].name":         ViolationRequired,
+		"items[2].price":        ViolationOutOfRange,
+		"items[2].track_number": ViolationMismatch,
+		"items[2].sale":         ViolationOutOfRange,
+	}
+	got := make(map[string]string)
+	for _, v := range Violations(err) {
+		got[v.Field] = v.Code
+		if v.Message == "" {
+			t.Errorf("Violation %s has no message", v.Field)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Expected %d violations, got %d: %v", len(want), len(got), got)
+	}
+	for field, code := range want {
+		if got[field] != code {
+			t.Errorf("Violation %s: expected code %q, got %q", field, code, got[field])
+		}
+	}
+
+	for _, sentinel := range []error{ErrInvalidOrder, ErrEmptyDeliveryName, ErrInvalidPhone, ErrInvalidCurrency, ErrInvalidItemPrice} {
+		if !errors.Is(err, sentinel) {
+			t.Errorf("errors.Is(err, %v) = false", sentinel)
+		}
+	}
+	if errors.Is(err, ErrEmptyOrderUID) {
+		t.Error("errors.Is must not match a rule that was not violated")
+	}
+}
+
+func TestViolations_NoValidationError(t *testing.T) {
+	if v := Violations(errors.New("db is down")); v != nil {
+		t.Errorf("Expected no violations, got %+v", v)
+	}
+	if err := sampleOrder().Validate(); err != nil {
+		t.Errorf("Expected valid order, got %v", err)
+	}
+}
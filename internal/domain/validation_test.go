@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// sampleOrder - согласованный заказ из примера модели данных
+func sampleOrder() *Order {
+	return &Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Locale:      "en",
+		Delivery: Delivery{
+			Name:  "Test Testov",
+			Phone: "+9720000000",
+			Email: "test@gmail.com",
+		},
+		Payment: Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     "USD",
+			Amount:       1817,
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []Item{
+			{TrackNumber: "WBILMTESTTRACK", Name: "Mascaras", Price: 453, Sale: 30, TotalPrice: 317},
+		},
+	}
+}
+
+func TestOrder_ValidateWith(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(o *Order)
+		wantErr error
+		lenient bool // Ошибка остаётся и в ValidationLenient
+	}{
+		{"valid order", func(o *Order) {}, nil, true},
+		{"locale with region", func(o *Order) { o.Locale = "en-US" }, nil, true},
+		{"no locale and email", func(o *Order) { o.Locale, o.Delivery.Email = "", "" }, nil, true},
+		{"total rounded up", func(o *Order) {
+			o.Items[0].TotalPrice, o.Payment.GoodsTotal, o.Payment.Amount = 318, 318, 1818
+		}, nil, true},
+		{"two items", func(o *Order) {
+			o.Items = append(o.Items, Item{TrackNumber: o.TrackNumber, Name: "Lipstick", Price: 200, TotalPrice: 200})
+			o.Payment.GoodsTotal, o.Payment.Amount = 517, 2017
+		}, nil, true},
+
+		{"transaction mismatch", func(o *Order) { o.Payment.Transaction = "other" }, ErrTransactionMismatch, true},
+		{"item track number mismatch", func(o *Order) { o.Items[0].TrackNumber = "OTHER" }, ErrItemTrackNumberMismatch, true},
+		{"unknown currency", func(o *Order) { o.Payment.Currency = "ABC" }, ErrInvalidCurrency, true},
+		{"lowercase currency", func(o *Order) { o.Payment.Currency = "usd" }, ErrInvalidCurrency, true},
+		{"empty currency", func(o *Order) { o.Payment.Currency = "" }, ErrInvalidCurrency, true},
+		{"invalid locale", func(o *Order) { o.Locale = "english" }, ErrInvalidLocale, true},
+		{"invalid email", func(o *Order) { o.Delivery.Email = "test@" }, ErrInvalidEmail, true},
+		{"phone without plus", func(o *Order) { o.Delivery.Phone = "89001234567" }, ErrInvalidPhone, true},
+		{"phone too long", func(o *Order) { o.Delivery.Phone = "+7900123456789012" }, ErrInvalidPhone, true},
+		{"sale over 100", func(o *Order) { o.Items[0].Sale = 101 }, ErrInvalidItemSale, true},
+
+		{"item total mismatch", func(o *Order) { o.Items[0].TotalPrice = 300 }, ErrItemTotalMismatch, false},
+		{"goods total mismatch", func(o *Order) { o.Payment.GoodsTotal, o.Payment.Amount = 300, 1800 }, ErrGoodsTotalMismatch, false},
+		{"amount mismatch", func(o *Order) { o.Payment.Amount = 1000 }, ErrPaymentAmountMismatch, false},
+		{"custom fee not in amount", func(o *Order) { o.Payment.CustomFee = 10 }, ErrPaymentAmountMismatch, false},
+
+		{"items total overflow", func(o *Order) {
+			o.Items = append(o.Items, Item{TrackNumber: o.TrackNumber, Name: "Gold", Price: math.MaxInt64, TotalPrice: math.MaxInt64})
+		}, ErrMoneyOverflow, true},
+		{"amount overflow", func(o *Order) { o.Payment.DeliveryCost = math.MaxInt64 }, ErrMoneyOverflow, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := sampleOrder()
+			tt.modify(order)
+
+			err := order.ValidateWith(ValidationStrict)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("ValidateWith(strict) error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantLenient := tt.wantErr
+			if !tt.lenient {
+				wantLenient = nil
+			}
+			err = order.ValidateWith(ValidationLenient)
+			if !errors.Is(err, wantLenient) || (wantLenient == nil && err != nil) {
+				t.Errorf("ValidateWith(lenient) error = %v, want %v", err, wantLenient)
+			}
+		})
+	}
+}
+
+// Validate - проверка в режиме по умолчанию (lenient): суммы не сверяются, остальные правила действуют
+func TestOrder_Validate(t *testing.T) {
+	order := sampleOrder()
+	order.Payment.Amount = 1000
+	if err := order.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil in %s mode", err, DefaultValidationMode)
+	}
+	if err := order.ValidateWith(ValidationStrict); !errors.Is(err, ErrPaymentAmountMismatch) {
+		t.Errorf("ValidateWith(strict) error = %v, want %v", err, ErrPaymentAmountMismatch)
+	}
+
+	order.Payment.Transaction = "other"
+	if err := order.Validate(); !errors.Is(err, ErrTransactionMismatch) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrTransactionMismatch)
+	}
+}
+
+// Пустой режим - документированный режим по умолчанию (lenient, как в README и .env.example)
+func TestParseValidationMode(t *testing.T) {
+	if DefaultValidationMode != ValidationLenient {
+		t.Errorf("DefaultValidationMode = %q, want %q", DefaultValidationMode, ValidationLenient)
+	}
+	for s, want := range map[string]ValidationMode{
+		"":        ValidationLenient,
+		"strict":  ValidationStrict,
+		"lenient": ValidationLenient,
+	} {
+		got, err := ParseValidationMode(s)
+		if err != nil || got != want {
+			t.Errorf("ParseValidationMode(%q) = %q, %v; want %q", s, got, err, want)
+		}
+	}
+	if _, err := ParseValidationMode("loose"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
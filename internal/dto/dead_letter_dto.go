@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"RWB_L0/internal/domain"
+)
+
+// DeadLetterOutput - необработанное сообщение для admin API
+type DeadLetterOutput struct {
+	ID       int64  `json:"id"`
+	Subject  string `json:"subject"`
+	Sequence uint64 `json:"sequence"`
+	// Payload - исходное сообщение, если это JSON; иначе (битый JSON, не UTF-8)
+	// его байты без изменений в PayloadBase64
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	PayloadBase64 string          `json:"payload_base64,omitempty"`
+	Error         string          `json:"error"`
+	Permanent     bool            `json:"permanent"`
+	Attempts      int             `json:"attempts"`
+	CreatedAt     time.Time       `json:"created_at"`
+	// Violations - нарушения правил заказа: поле, код и сообщение каждого
+	Violations []domain.Violation `json:"violations,omitempty"`
+}
+
+// ListDeadLettersInput - параметры постраничной выборки dead letters
+type ListDeadLettersInput struct {
+	Limit  int    `json:"limit"`
+	Cursor string `json:"cursor"`
+}
+
+// ListDeadLettersOutput - страница dead letters
+type ListDeadLettersOutput struct {
+	DeadLetters []*DeadLetterOutput `json:"dead_letters"`
+	NextCursor  string              `json:"next_cursor,omitempty"`
+	HasMore     bool                `json:"has_more"`
+}
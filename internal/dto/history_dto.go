@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OrderChangeOutput - запись истории заказа
+type OrderChangeOutput struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"` // created, updated, status_changed или deleted
+	Source    string          `json:"source"` // nats, http или admin
+	Sequence  uint64          `json:"sequence,omitempty"`
+	Version   int64           `json:"version"`
+	Snapshot  json.RawMessage `json:"snapshot"` // Заказ целиком после изменения (для deleted - до него)
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// OrderHistoryOutput - история изменений заказа, от старых к новым
+type OrderHistoryOutput struct {
+	OrderUID string               `json:"order_uid"`
+	Changes  []*OrderChangeOutput `json:"changes"`
+}
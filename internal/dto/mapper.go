@@ -1,82 +1,95 @@
 package dto
 
-import "RWB_L0/internal/domain"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
 
-// ToDomain - конвертирует CreateOrderInput в domain.Order
+	"RWB_L0/internal/domain"
+)
+
+// ToDomain - конвертирует CreateOrderInput в domain.Order.
+// Проверяет только обязательные поля; все нарушения - одним domain.ValidationError
 func (input *CreateOrderInput) ToDomain() (*domain.Order, error) {
-	// Создаём заказ через конструктор Domain
-	order, err := domain.NewOrder(input.OrderUID, input.TrackNumber, input.Entry)
-	if err != nil {
+	order := input.toOrder()
+	if err := order.ValidateFields(); err != nil {
 		return nil, err
 	}
+	return order, nil
+}
 
-	// Delivery
-	delivery, err := domain.NewDelivery(
-		input.Delivery.Name,
-		input.Delivery.Phone,
-		input.Delivery.Zip,
-		input.Delivery.City,
-		input.Delivery.Address,
-		input.Delivery.Region,
-		input.Delivery.Email,
-	)
-	if err != nil {
+// ToDomainWith - ToDomain с проверкой бизнес-правил заказа в режиме mode:
+// нарушения обязательных полей и правил возвращаются вместе
+func (input *CreateOrderInput) ToDomainWith(mode domain.ValidationMode) (*domain.Order, error) {
+	order := input.toOrder()
+	if err := order.ValidateWith(mode); err != nil {
 		return nil, err
 	}
-	order.Delivery = *delivery
+	return order, nil
+}
+
+// toOrder - перенос полей без проверок
+func (input *CreateOrderInput) toOrder() *domain.Order {
+	order := &domain.Order{
+		OrderUID:          input.OrderUID,
+		TrackNumber:       input.TrackNumber,
+		Entry:             input.Entry,
+		Locale:            input.Locale,
+		InternalSignature: input.InternalSignature,
+		CustomerID:        input.CustomerID,
+		DeliveryService:   input.DeliveryService,
+		Shardkey:          input.Shardkey,
+		SmID:              input.SmID,
+		DateCreated:       input.DateCreated,
+		OofShard:          input.OofShard,
+	}
+
+	// Delivery
+	order.Delivery = domain.Delivery{
+		Name:    input.Delivery.Name,
+		Phone:   input.Delivery.Phone,
+		Zip:     input.Delivery.Zip,
+		City:    input.Delivery.City,
+		Address: input.Delivery.Address,
+		Region:  input.Delivery.Region,
+		Email:   input.Delivery.Email,
+	}
 
 	// Payment
-	payment, err := domain.NewPayment(
-		input.Payment.Transaction,
-		input.Payment.RequestID,
-		input.Payment.Currency,
-		input.Payment.Provider,
-		input.Payment.Bank,
-		input.Payment.Amount,
-		input.Payment.PaymentDt,
-		input.Payment.DeliveryCost,
-		input.Payment.GoodsTotal,
-		input.Payment.CustomFee,
-	)
-	if err != nil {
-		return nil, err
+	order.Payment = domain.Payment{
+		Transaction:  input.Payment.Transaction,
+		RequestID:    input.Payment.RequestID,
+		Currency:     domain.Currency(input.Payment.Currency),
+		Provider:     input.Payment.Provider,
+		Amount:       domain.MinorUnits(input.Payment.Amount),
+		PaymentDt:    input.Payment.PaymentDt,
+		Bank:         input.Payment.Bank,
+		DeliveryCost: domain.MinorUnits(input.Payment.DeliveryCost),
+		GoodsTotal:   domain.MinorUnits(input.Payment.GoodsTotal),
+		CustomFee:    domain.MinorUnits(input.Payment.CustomFee),
 	}
-	order.Payment = *payment
 
 	// Items
-	for _, itemInput := range input.Items {
-		item, err := domain.NewItem(
-			itemInput.ChrtID,
-			itemInput.TrackNumber,
-			itemInput.Name,
-			itemInput.Rid,
-			itemInput.Size,
-			itemInput.Brand,
-			itemInput.Price,
-			itemInput.Sale,
-			itemInput.TotalPrice,
-			itemInput.NmID,
-			itemInput.Status,
-		)
-		if err != nil {
-			return nil, err
-		}
-		if err := order.AddItem(*item); err != nil {
-			return nil, err
+	order.Items = make([]domain.Item, len(input.Items))
+	for i, item := range input.Items {
+		order.Items[i] = domain.Item{
+			ChrtID:      item.ChrtID,
+			TrackNumber: item.TrackNumber,
+			Price:       domain.MinorUnits(item.Price),
+			Rid:         item.Rid,
+			Name:        item.Name,
+			Sale:        item.Sale,
+			Size:        item.Size,
+			TotalPrice:  domain.MinorUnits(item.TotalPrice),
+			NmID:        item.NmID,
+			Brand:       item.Brand,
+			Status:      item.Status,
 		}
 	}
 
-	// Остальные поля
-	order.Locale = input.Locale
-	order.InternalSignature = input.InternalSignature
-	order.CustomerID = input.CustomerID
-	order.DeliveryService = input.DeliveryService
-	order.Shardkey = input.Shardkey
-	order.SmID = input.SmID
-	order.DateCreated = input.DateCreated
-	order.OofShard = input.OofShard
-
-	return order, nil
+	return order
 }
 
 // FromDomain - конвертирует domain.Order в OrderOutput
@@ -93,6 +106,8 @@ func FromDomain(order *domain.Order) *OrderOutput {
 		SmID:              order.SmID,
 		DateCreated:       order.DateCreated,
 		OofShard:          order.OofShard,
+		Status:            string(order.Status),
+		Version:           order.Version,
 	}
 
 	// Delivery
@@ -110,14 +125,14 @@ func FromDomain(order *domain.Order) *OrderOutput {
 	output.Payment = PaymentOutput{
 		Transaction:  order.Payment.Transaction,
 		RequestID:    order.Payment.RequestID,
-		Currency:     order.Payment.Currency,
+		Currency:     string(order.Payment.Currency),
 		Provider:     order.Payment.Provider,
-		Amount:       order.Payment.Amount,
+		Amount:       int64(order.Payment.Amount),
 		PaymentDt:    order.Payment.PaymentDt,
 		Bank:         order.Payment.Bank,
-		DeliveryCost: order.Payment.DeliveryCost,
-		GoodsTotal:   order.Payment.GoodsTotal,
-		CustomFee:    order.Payment.CustomFee,
+		DeliveryCost: int64(order.Payment.DeliveryCost),
+		GoodsTotal:   int64(order.Payment.GoodsTotal),
+		CustomFee:    int64(order.Payment.CustomFee),
 	}
 
 	// Items
@@ -126,12 +141,12 @@ func FromDomain(order *domain.Order) *OrderOutput {
 		output.Items[i] = ItemOutput{
 			ChrtID:      item.ChrtID,
 			TrackNumber: item.TrackNumber,
-			Price:       item.Price,
+			Price:       int64(item.Price),
 			Rid:         item.Rid,
 			Name:        item.Name,
 			Sale:        item.Sale,
 			Size:        item.Size,
-			TotalPrice:  item.TotalPrice,
+			TotalPrice:  int64(item.TotalPrice),
 			NmID:        item.NmID,
 			Brand:       item.Brand,
 			Status:      item.Status,
@@ -139,3 +154,160 @@ func FromDomain(order *domain.Order) *OrderOutput {
 	}
 	return output
 }
+
+// ToQuery - конвертирует ListOrdersInput в domain.OrderListQuery
+func (input *ListOrdersInput) ToQuery() (*domain.OrderListQuery, error) {
+	query := &domain.OrderListQuery{
+		Filter: domain.OrderFilter{
+			CustomerID:      input.CustomerID,
+			TrackNumber:     input.TrackNumber,
+			DeliveryService: input.DeliveryService,
+			Entry:           input.Entry,
+			Locale:          input.Locale,
+		},
+		Limit: input.Limit,
+	}
+	if input.DateFrom != nil {
+		query.Filter.CreatedFrom = *input.DateFrom
+	}
+	if input.DateTo != nil {
+		query.Filter.CreatedTo = *input.DateTo
+	}
+
+	if input.Cursor != "" {
+		cursor, err := DecodeCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = cursor
+	}
+
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// FromDomainPage - конвертирует domain.OrderPage в ListOrdersOutput
+func FromDomainPage(page *domain.OrderPage) *ListOrdersOutput {
+	output := &ListOrdersOutput{
+		Orders: make([]*OrderOutput, 0, len(page.Orders)),
+	}
+	for _, order := range page.Orders {
+		output.Orders = append(output.Orders, FromDomain(order))
+	}
+	if page.NextCursor != nil {
+		output.NextCursor = EncodeCursor(page.NextCursor)
+		output.HasMore = true
+	}
+	return output
+}
+
+// EncodeCursor - кодирует курсор в непрозрачную строку для клиента
+func EncodeCursor(cursor *domain.OrderCursor) string {
+	raw := cursor.DateCreated.UTC().Format(time.RFC3339Nano) + "|" + cursor.OrderUID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor - разбирает строку, полученную из EncodeCursor
+func DecodeCursor(s string) (*domain.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	dateCreated, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	return &domain.OrderCursor{
+		DateCreated: dateCreated,
+		OrderUID:    parts[1],
+	}, nil
+}
+
+// FromDomainDeadLetter - конвертирует domain.DeadLetter в DeadLetterOutput.
+// Payload, который не является JSON, передаётся в base64: строка исказила бы байты не UTF-8
+func FromDomainDeadLetter(letter *domain.DeadLetter) *DeadLetterOutput {
+	output := &DeadLetterOutput{
+		ID:         letter.ID,
+		Subject:    letter.Subject,
+		Sequence:   letter.Sequence,
+		Error:      letter.Error,
+		Permanent:  letter.Permanent,
+		Attempts:   letter.Attempts,
+		CreatedAt:  letter.CreatedAt,
+		Violations: letter.Violations,
+	}
+	if json.Valid(letter.Payload) {
+		output.Payload = json.RawMessage(letter.Payload)
+	} else {
+		output.PayloadBase64 = base64.StdEncoding.EncodeToString(letter.Payload)
+	}
+	return output
+}
+
+// EncodeDeadLetterCursor - курсор dead letters (id последней записи страницы)
+func EncodeDeadLetterCursor(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// DecodeDeadLetterCursor - разбирает строку, полученную из EncodeDeadLetterCursor
+func DecodeDeadLetterCursor(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, domain.ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// FromDomainHistory - конвертирует историю заказа в OrderHistoryOutput
+func FromDomainHistory(orderUID string, changes []*domain.OrderChange) *OrderHistoryOutput {
+	output := &OrderHistoryOutput{
+		OrderUID: orderUID,
+		Changes:  make([]*OrderChangeOutput, 0, len(changes)),
+	}
+	for _, change := range changes {
+		output.Changes = append(output.Changes, &OrderChangeOutput{
+			ID:        change.ID,
+			Action:    string(change.Action),
+			Source:    string(change.Source),
+			Sequence:  change.Sequence,
+			Version:   change.Version,
+			Snapshot:  change.Snapshot,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+	return output
+}
+
+// FromDomainStatus - конвертирует статус заказа и его историю в OrderStatusOutput
+func FromDomainStatus(orderUID string, status domain.OrderStatus, transitions []*domain.StatusTransition) *OrderStatusOutput {
+	output := &OrderStatusOutput{
+		OrderUID:    orderUID,
+		Status:      string(status),
+		Next:        make([]string, 0, len(status.Next())),
+		Transitions: make([]*StatusTransitionOutput, 0, len(transitions)),
+	}
+	for _, next := range status.Next() {
+		output.Next = append(output.Next, string(next))
+	}
+	for _, transition := range transitions {
+		output.Transitions = append(output.Transitions, &StatusTransitionOutput{
+			From:      string(transition.From),
+			To:        string(transition.To),
+			Reason:    transition.Reason,
+			Source:    string(transition.Source),
+			Sequence:  transition.Sequence,
+			Version:   transition.Version,
+			CreatedAt: transition.CreatedAt,
+		})
+	}
+	return output
+}
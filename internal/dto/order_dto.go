@@ -2,6 +2,8 @@ package dto
 
 import (
 	"time"
+
+	"RWB_L0/internal/domain"
 )
 
 // CreateOrderInput - входные данные для создания заказа
@@ -33,30 +35,31 @@ type DeliveryInput struct {
 	Email   string `json:"email"`
 }
 
-// PaymentInput - входные данные платежа
+// PaymentInput - входные данные платежа.
+// Суммы - в минимальных единицах валюты Currency: 1817 USD - это 18.17 USD
 type PaymentInput struct {
 	Transaction  string `json:"transaction"`
 	RequestID    string `json:"request_id"`
 	Currency     string `json:"currency"`
 	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
+	Amount       int64  `json:"amount"`
 	PaymentDt    int64  `json:"payment_dt"`
 	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	DeliveryCost int64  `json:"delivery_cost"`
+	GoodsTotal   int64  `json:"goods_total"`
+	CustomFee    int64  `json:"custom_fee"`
 }
 
-// ItemInput - входные данные товара
+// ItemInput - входные данные товара (цены - в минимальных единицах валюты платежа)
 type ItemInput struct {
 	ChrtID      int    `json:"chrt_id"`
 	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
+	Price       int64  `json:"price"`
 	Rid         string `json:"rid"`
 	Name        string `json:"name"`
 	Sale        int    `json:"sale"`
 	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
+	TotalPrice  int64  `json:"total_price"`
 	NmID        int    `json:"nm_id"`
 	Brand       string `json:"brand"`
 	Status      int    `json:"status"`
@@ -83,6 +86,14 @@ type OrderOutput struct {
 	SmID              int            `json:"sm_id"`
 	DateCreated       time.Time      `json:"date_created"`
 	OofShard          string         `json:"oof_shard"`
+	Status            string         `json:"status"`
+	Version           int64          `json:"version"`
+}
+
+// Money - сумма amount в валюте платежа заказа. В шаблоне выводится через String:
+// "18.17 USD" при любой локали заказа
+func (o *OrderOutput) Money(amount int64) domain.Money {
+	return domain.NewMoney(domain.MinorUnits(amount), domain.Currency(o.Payment.Currency))
 }
 
 // DeliveryOutput - выходные данные доставки
@@ -96,31 +107,52 @@ type DeliveryOutput struct {
 	Email   string `json:"email"`
 }
 
-// PaymentOutput - выходные данные платежа
+// PaymentOutput - выходные данные платежа.
+// Суммы - в минимальных единицах валюты Currency: 1817 USD - это 18.17 USD
 type PaymentOutput struct {
 	Transaction  string `json:"transaction"`
 	RequestID    string `json:"request_id"`
 	Currency     string `json:"currency"`
 	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
+	Amount       int64  `json:"amount"`
 	PaymentDt    int64  `json:"payment_dt"`
 	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	DeliveryCost int64  `json:"delivery_cost"`
+	GoodsTotal   int64  `json:"goods_total"`
+	CustomFee    int64  `json:"custom_fee"`
 }
 
-// ItemOutput - выходные данные товара
+// ItemOutput - выходные данные товара (цены - в минимальных единицах валюты платежа)
 type ItemOutput struct {
 	ChrtID      int    `json:"chrt_id"`
 	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
+	Price       int64  `json:"price"`
 	Rid         string `json:"rid"`
 	Name        string `json:"name"`
 	Sale        int    `json:"sale"`
 	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
+	TotalPrice  int64  `json:"total_price"`
 	NmID        int    `json:"nm_id"`
 	Brand       string `json:"brand"`
 	Status      int    `json:"status"`
 }
+
+// ListOrdersInput - входные данные для постраничной выборки заказов
+type ListOrdersInput struct {
+	Limit           int        `json:"limit"`
+	Cursor          string     `json:"cursor"`
+	CustomerID      string     `json:"customer_id"`
+	TrackNumber     string     `json:"track_number"`
+	DeliveryService string     `json:"delivery_service"`
+	Entry           string     `json:"entry"`
+	Locale          string     `json:"locale"`
+	DateFrom        *time.Time `json:"date_from"`
+	DateTo          *time.Time `json:"date_to"`
+}
+
+// ListOrdersOutput - страница заказов
+type ListOrdersOutput struct {
+	Orders     []*OrderOutput `json:"orders"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	HasMore    bool           `json:"has_more"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// ChangeStatusInput - смена статуса заказа (сообщение NATS, admin API)
+type ChangeStatusInput struct {
+	OrderUID string `json:"order_uid"` // В admin API берётся из пути
+	Status   string `json:"status"`    // created, paid, assembling, shipped, delivered, cancelled или returned
+	Reason   string `json:"reason"`    // Причина для истории статусов, необязательна
+}
+
+// StatusTransitionOutput - переход между статусами заказа
+type StatusTransitionOutput struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason,omitempty"`
+	Source    string    `json:"source"` // nats или admin
+	Sequence  uint64    `json:"sequence,omitempty"`
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderStatusOutput - текущий статус заказа и история переходов, от старых к новым
+type OrderStatusOutput struct {
+	OrderUID    string                    `json:"order_uid"`
+	Status      string                    `json:"status"`
+	Next        []string                  `json:"next"` // Разрешённые переходы из текущего статуса
+	Transitions []*StatusTransitionOutput `json:"transitions"`
+}
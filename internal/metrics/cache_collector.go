@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"RWB_L0/internal/usecase"
+)
+
+// Описания метрик кэша
+var (
+	cacheHitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "hits_total"),
+		"Cache lookups that found an order.", nil, nil)
+	cacheMissesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "misses_total"),
+		"Cache lookups that did not find an order.", nil, nil)
+	cacheExpirationsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "expirations_total"),
+		"Cache entries removed because their TTL expired.", nil, nil)
+	cacheEvictionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "evictions_total"),
+		"Cache entries evicted by the LRU policy.", nil, nil)
+	cacheSetsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "sets_total"),
+		"Orders written to the cache.", nil, nil)
+	cacheSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "size"),
+		"Orders currently in the cache.", nil, nil)
+	cacheCapacityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "capacity"),
+		"Maximum number of orders in the cache.", nil, nil)
+	cacheMemoryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "memory_bytes"),
+		"Approximate memory used by cached orders.", nil, nil)
+	cacheHitRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "hit_ratio"),
+		"Share of cache lookups that found an order since start.", nil, nil)
+)
+
+// cacheCollector - читает usecase.CacheStats при каждом сборе метрик,
+// поэтому кэшу не нужно знать о Prometheus
+type cacheCollector struct {
+	stats func() usecase.CacheStats
+}
+
+// Describe - реализация prometheus.Collector
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheExpirationsDesc
+	ch <- cacheEvictionsDesc
+	ch <- cacheSetsDesc
+	ch <- cacheSizeDesc
+	ch <- cacheCapacityDesc
+	ch <- cacheMemoryDesc
+	ch <- cacheHitRatioDesc
+}
+
+// Collect - реализация prometheus.Collector
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(cacheExpirationsDesc, prometheus.CounterValue, float64(stats.Expirations))
+	ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(cacheSetsDesc, prometheus.CounterValue, float64(stats.Sets))
+	ch <- prometheus.MustNewConstMetric(cacheSizeDesc, prometheus.GaugeValue, float64(stats.Size))
+	ch <- prometheus.MustNewConstMetric(cacheCapacityDesc, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(cacheMemoryDesc, prometheus.GaugeValue, float64(stats.MemoryBytes))
+	ch <- prometheus.MustNewConstMetric(cacheHitRatioDesc, prometheus.GaugeValue, stats.HitRatio())
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"RWB_L0/internal/usecase"
+)
+
+const namespace = "order_service"
+
+// Metrics - метрики сервиса в формате Prometheus.
+// Использует собственный реестр, поэтому несколько экземпляров (например, в тестах)
+// не конфликтуют, а /metrics работает без внешнего Prometheus
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestDuration *prometheus.HistogramVec
+	natsMessages        *prometheus.CounterVec
+	natsDuplicates      prometheus.Counter
+	natsHandleDuration  *prometheus.HistogramVec
+	dbQueryDuration     *prometheus.HistogramVec
+}
+
+// New - создание метрик и регистрация коллекторов
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		natsMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "nats",
+			Name:      "messages_total",
+			Help:      "NATS messages handled, by result (ok, failed).",
+		}, []string{"result"}),
+
+		natsDuplicates: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "nats",
+			Name:      "duplicate_messages_total",
+			Help:      "Redelivered NATS messages that were already processed and skipped.",
+		}),
+
+		natsHandleDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "nats",
+			Name:      "handle_duration_seconds",
+			Help:      "NATS message handling latency by result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"result"}),
+
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Order repository operation latency by operation and result.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"operation", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequestDuration,
+		m.natsMessages,
+		m.natsDuplicates,
+		m.natsHandleDuration,
+		m.dbQueryDuration,
+	)
+
+	return m
+}
+
+// Handler - http.Handler для /metrics (text exposition format)
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTPRequest - учёт HTTP запроса
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequestDuration.
+		WithLabelValues(method, route, strconv.Itoa(status)).
+		Observe(duration.Seconds())
+}
+
+// ObserveNATSMessage - учёт обработки NATS сообщения
+func (m *Metrics) ObserveNATSMessage(duration time.Duration, err error) {
+	result := resultLabel(err)
+	m.natsMessages.WithLabelValues(result).Inc()
+	m.natsHandleDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// ObserveNATSDuplicate - учёт повторной доставки уже обработанного сообщения
+func (m *Metrics) ObserveNATSDuplicate() {
+	m.natsDuplicates.Inc()
+}
+
+// ObserveDBQuery - учёт операции репозитория
+func (m *Metrics) ObserveDBQuery(operation string, duration time.Duration, err error) {
+	m.dbQueryDuration.
+		WithLabelValues(operation, resultLabel(err)).
+		Observe(duration.Seconds())
+}
+
+// RegisterCacheStats - экспорт статистики кэша; stats вызывается при каждом сборе метрик
+func (m *Metrics) RegisterCacheStats(stats func() usecase.CacheStats) {
+	m.registry.MustRegister(&cacheCollector{stats: stats})
+}
+
+// resultLabel - значение метки result
+func resultLabel(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return "ok"
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"RWB_L0/internal/usecase"
+)
+
+// scrape - читает /metrics так же, как это делает Prometheus
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET /metrics error = %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text exposition format, got %q", ct)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return string(body)
+}
+
+func TestMetrics_Scrape(t *testing.T) {
+	m := New()
+
+	m.ObserveHTTPRequest(http.MethodGet, "/api/v1/orders/{uid}", http.StatusOK, 15*time.Millisecond)
+	m.ObserveNATSMessage(5*time.Millisecond, nil)
+	m.ObserveNATSMessage(5*time.Millisecond, errors.New("boom"))
+	m.ObserveNATSDuplicate()
+	m.ObserveDBQuery("save", 3*time.Millisecond, nil)
+	m.RegisterCacheStats(func() usecase.CacheStats {
+		return usecase.CacheStats{Hits: 3, Misses: 1, Size: 10, Capacity: 100}
+	})
+
+	body := scrape(t, m)
+
+	expected := []string{
+		`order_service_http_request_duration_seconds_count{method="GET",route="/api/v1/orders/{uid}",status="200"} 1`,
+		`order_service_nats_messages_total{result="ok"} 1`,
+		`order_service_nats_messages_total{result="failed"} 1`,
+		`order_service_nats_duplicate_messages_total 1`,
+		`order_service_nats_handle_duration_seconds_bucket{result="ok",le="0.005"} 1`,
+		`order_service_db_query_duration_seconds_count{operation="save",result="ok"} 1`,
+		`order_service_cache_hits_total 3`,
+		`order_service_cache_misses_total 1`,
+		`order_service_cache_size 10`,
+		`order_service_cache_capacity 100`,
+		`order_service_cache_hit_ratio 0.75`,
+		`go_goroutines`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected /metrics to contain %q", line)
+		}
+	}
+}
+
+func TestMetrics_IndependentRegistries(t *testing.T) {
+	// Два экземпляра не должны конфликтовать при регистрации
+	first := New()
+	second := New()
+
+	first.ObserveDBQuery("list", time.Millisecond, nil)
+
+	if strings.Contains(scrape(t, second), `operation="list"`) {
+		t.Error("Metrics instances must not share series")
+	}
+}
@@ -0,0 +1,200 @@
+// Package schema - JSON Schema контракта сообщения о заказе и проверка сообщений по ней
+package schema
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"RWB_L0/internal/domain"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// OrderVersion - версия контракта сообщения о заказе. Несовместимое изменение
+// (новое обязательное поле, смена типа) - новый файл order.vN.json и новая версия
+const OrderVersion = 1
+
+// OrderID - $id схемы заказа текущей версии
+const OrderID = "urn:rwb-l0:schema:order:v1"
+
+//go:embed order.v1.json
+var orderSchema []byte
+
+// Order - JSON Schema сообщения о заказе (dto.CreateOrderInput)
+func Order() []byte {
+	return orderSchema
+}
+
+// ========================================
+// Mode - проверка входящих сообщений по схеме
+// ========================================
+
+type Mode string
+
+const (
+	// ModeOff - сообщения по схеме не проверяются
+	ModeOff Mode = "off"
+	// ModeLenient - проверка по схеме, неизвестные поля допускаются
+	ModeLenient Mode = "lenient"
+	// ModeStrict - проверка по схеме, неизвестные поля - нарушение
+	ModeStrict Mode = "strict"
+)
+
+// ParseMode - режим из строки конфигурации (пустая строка - ModeOff)
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case "":
+		return ModeOff, nil
+	case ModeOff, ModeLenient, ModeStrict:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown schema validation mode %q (want off, lenient or strict)", s)
+	}
+}
+
+// Validator - проверка сообщений о заказе по схеме
+type Validator struct {
+	schema *jsonschema.Schema
+}
+
+// NewOrderValidator - проверка по схеме заказа. В ModeLenient неизвестные поля допускаются,
+// в остальных режимах - нет (ModeOff решает вызывающий: валидатор просто не создаётся)
+func NewOrderValidator(mode Mode) (*Validator, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(orderSchema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse order schema: %w", err)
+	}
+	if mode == ModeLenient {
+		dropAdditionalProperties(doc)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	if err := compiler.AddResource(OrderID, doc); err != nil {
+		return nil, fmt.Errorf("failed to load order schema: %w", err)
+	}
+	sch, err := compiler.Compile(OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile order schema: %w", err)
+	}
+
+	return &Validator{schema: sch}, nil
+}
+
+// Validate - проверка сообщения. Нарушения схемы возвращаются одним domain.ValidationError
+// с путями полей, как у проверки заказа
+func (v *Validator) Validate(data []byte) error {
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	err = v.schema.Validate(inst)
+	if err == nil {
+		return nil
+	}
+
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+	return &domain.ValidationError{Violations: violations(verr, nil)}
+}
+
+// printer - сообщения библиотеки об ошибках на английском, как ошибки домена
+var printer = message.NewPrinter(language.English)
+
+// violations - нарушения из дерева ошибок схемы (только листья - конкретные ключевые слова)
+func violations(verr *jsonschema.ValidationError, out []domain.Violation) []domain.Violation {
+	if len(verr.Causes) > 0 {
+		for _, cause := range verr.Causes {
+			out = violations(cause, out)
+		}
+		return out
+	}
+
+	field := fieldPath(verr.InstanceLocation)
+	switch k := verr.ErrorKind.(type) {
+	case *kind.Required:
+		for _, name := range k.Missing {
+			out = append(out, domain.Violation{
+				Field:   joinField(field, name),
+				Code:    domain.ViolationRequired,
+				Message: "missing required property",
+			})
+		}
+	case *kind.AdditionalProperties:
+		for _, name := range k.Properties {
+			out = append(out, domain.Violation{
+				Field:   joinField(field, name),
+				Code:    domain.ViolationUnknownField,
+				Message: "unknown property",
+			})
+		}
+	default:
+		out = append(out, domain.Violation{
+			Field:   field,
+			Code:    violationCode(verr.ErrorKind),
+			Message: verr.ErrorKind.LocalizedString(printer),
+		})
+	}
+	return out
+}
+
+// violationCode - код нарушения для ключевого слова схемы
+func violationCode(k jsonschema.ErrorKind) string {
+	switch k.(type) {
+	case *kind.Type:
+		return domain.ViolationInvalidType
+	case *kind.MinLength:
+		return domain.ViolationRequired
+	case *kind.Minimum, *kind.Maximum, *kind.ExclusiveMinimum, *kind.ExclusiveMaximum:
+		return domain.ViolationOutOfRange
+	default:
+		return domain.ViolationInvalidFormat
+	}
+}
+
+// fieldPath - путь поля как в domain.Violation: ["items", "2", "price"] -> items[2].price
+func fieldPath(location []string) string {
+	var sb strings.Builder
+	for _, token := range location {
+		if _, err := strconv.Atoi(token); err == nil {
+			sb.WriteString("[" + token + "]")
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(token)
+	}
+	return sb.String()
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// dropAdditionalProperties - убрать запрет неизвестных полей во всех объектах схемы
+func dropAdditionalProperties(node any) {
+	switch n := node.(type) {
+	case map[string]any:
+		delete(n, "additionalProperties")
+		for _, child := range n {
+			dropAdditionalProperties(child)
+		}
+	case []any:
+		for _, child := range n {
+			dropAdditionalProperties(child)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/dto"
+)
+
+// sampleInput - заказ из примера модели данных
+func sampleInput() dto.CreateOrderInput {
+	return dto.CreateOrderInput{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Locale:      "en",
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Delivery:    dto.DeliveryInput{Name: "Test Testov", Phone: "+9720000000", Email: "test@gmail.com"},
+		Payment: dto.PaymentInput{
+			Transaction: "b563feb7b2b84b6test", Currency: "USD",
+			Amount: 1817, DeliveryCost: 1500, GoodsTotal: 317,
+		},
+		Items: []dto.ItemInput{
+			{TrackNumber: "WBILMTESTTRACK", Name: "Mascaras", Price: 453, Sale: 30, TotalPrice: 317},
+		},
+	}
+}
+
+// Схема описывает ровно поля dto.CreateOrderInput с теми же JSON типами
+func TestOrder_MatchesCreateOrderInput(t *testing.T) {
+	var doc map[string]any
+	if err := json.Unmarshal(Order(), &doc); err != nil {
+		t.Fatalf("Schema is not JSON: %v", err)
+	}
+	if doc["$id"] != OrderID {
+		t.Errorf("Expected $id %q, got %v", OrderID, doc["$id"])
+	}
+	checkObject(t, doc, doc, reflect.TypeOf(dto.CreateOrderInput{}), "")
+}
+
+func checkObject(t *testing.T, root, node map[string]any, typ reflect.Type, path string) {
+	t.Helper()
+	node = resolve(root, node)
+	props, _ := node["properties"].(map[string]any)
+
+	fields := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fields[name] = true
+
+		prop, ok := props[name].(map[string]any)
+		if !ok {
+			t.Errorf("%s%s: field %s is missing from the schema", path, name, field.Name)
+			continue
+		}
+		checkType(t, root, prop, field.Type, path+name)
+	}
+	for name := range props {
+		if !fields[name] {
+			t.Errorf("%s%s: schema property has no field in %s", path, name, typ.Name())
+		}
+	}
+}
+
+func checkType(t *testing.T, root, prop map[string]any, typ reflect.Type, path string) {
+	t.Helper()
+	prop = resolve(root, prop)
+
+	var want string
+	switch {
+	case typ == reflect.TypeOf(time.Time{}):
+		want = "string"
+	case typ.Kind() == reflect.String:
+		want = "string"
+	case typ.Kind() == reflect.Int || typ.Kind() == reflect.Int64:
+		want = "integer"
+	case typ.Kind() == reflect.Slice:
+		want = "array"
+	case typ.Kind() == reflect.Struct:
+		want = "object"
+	default:
+		t.Fatalf("%s: unsupported Go type %s", path, typ)
+	}
+	if prop["type"] != want {
+		t.Errorf("%s: expected schema type %s for %s, got %v", path, want, typ, prop["type"])
+		return
+	}
+
+	switch want {
+	case "array":
+		items, _ := prop["items"].(map[string]any)
+		checkType(t, root, items, typ.Elem(), path+"[]")
+	case "object":
+		if typ != reflect.TypeOf(time.Time{}) {
+			checkObject(t, root, prop, typ, path+".")
+		}
+	}
+}
+
+// resolve - подстановка "$ref": "#/$defs/name"
+func resolve(root, node map[string]any) map[string]any {
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node
+	}
+	defs, _ := root["$defs"].(map[string]any)
+	def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+	return def
+}
+
+// Форматы полей в схеме совпадают с проверками домена: схема не пропускает
+// телефон или валюту, которые отклонит Order.Validate, и наоборот
+func TestOrder_MatchesDomainRules(t *testing.T) {
+	var doc map[string]any
+	if err := json.Unmarshal(Order(), &doc); err != nil {
+		t.Fatalf("Schema is not JSON: %v", err)
+	}
+	defs := doc["$defs"].(map[string]any)
+	property := func(def, name string) map[string]any {
+		props := defs[def].(map[string]any)["properties"].(map[string]any)
+		return props[name].(map[string]any)
+	}
+
+	if got := property("delivery", "phone")["pattern"]; got != domain.PhonePattern {
+		t.Errorf("delivery.phone pattern %v, domain.PhonePattern %q", got, domain.PhonePattern)
+	}
+
+	var enum []domain.Currency
+	for _, code := range property("payment", "currency")["enum"].([]any) {
+		enum = append(enum, domain.Currency(code.(string)))
+	}
+	if want := domain.Currencies(); !reflect.DeepEqual(enum, want) {
+		t.Errorf("payment.currency enum %v, domain.Currencies %v", enum, want)
+	}
+}
+
+func TestValidator_Validate(t *testing.T) {
+	valid, err := json.Marshal(sampleInput())
+	if err != nil {
+		t.Fatalf("Failed to marshal order: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		strict  []domain.Violation // nil - сообщение проходит
+		lenient bool               // Нарушения те же и в ModeLenient
+	}{
+		{name: "valid order", payload: string(valid), lenient: true},
+		{
+			name:    "unknown field",
+			payload: strings.Replace(string(valid), `"entry"`, `"discount":5,"entry"`, 1),
+			strict:  []domain.Violation{{Field: "discount", Code: domain.ViolationUnknownField}},
+		},
+		{
+			name:    "unknown nested field",
+			payload: strings.Replace(string(valid), `"chrt_id"`, `"color":"red","chrt_id"`, 1),
+			strict:  []domain.Violation{{Field: "items[0].color", Code: domain.ViolationUnknownField}},
+		},
+		{
+			name:    "price as string",
+			payload: strings.Replace(string(valid), `"price":453`, `"price":"453"`, 1),
+			strict:  []domain.Violation{{Field: "items[0].price", Code: domain.ViolationInvalidType}},
+			lenient: true,
+		},
+		{
+			name:    "missing delivery name",
+			payload: strings.Replace(string(valid), `"name":"Test Testov",`, ``, 1),
+			strict:  []domain.Violation{{Field: "delivery.name", Code: domain.ViolationRequired}},
+			lenient: true,
+		},
+		{
+			name:    "sale over 100",
+			payload: strings.Replace(string(valid), `"sale":30`, `"sale":130`, 1),
+			strict:  []domain.Violation{{Field: "items[0].sale", Code: domain.ViolationOutOfRange}},
+			lenient: true,
+		},
+		{
+			name:    "lowercase currency",
+			payload: strings.Replace(string(valid), `"USD"`, `"usd"`, 1),
+			strict:  []domain.Violation{{Field: "payment.currency", Code: domain.ViolationInvalidFormat}},
+			lenient: true,
+		},
+	}
+
+	strict, err := NewOrderValidator(ModeStrict)
+	if err != nil {
+		t.Fatalf("NewOrderValidator(strict) error = %v", err)
+	}
+	lenient, err := NewOrderValidator(ModeLenient)
+	if err != nil {
+		t.Fatalf("NewOrderValidator(lenient) error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkViolations(t, "strict", strict.Validate([]byte(tt.payload)), tt.strict)
+
+			var want []domain.Violation
+			if tt.lenient {
+				want = tt.strict
+			}
+			checkViolations(t, "lenient", lenient.Validate([]byte(tt.payload)), want)
+		})
+	}
+}
+
+func checkViolations(t *testing.T, mode string, err error, want []domain.Violation) {
+	t.Helper()
+	if want == nil {
+		if err != nil {
+			t.Errorf("Validate(%s) error = %v, want nil", mode, err)
+		}
+		return
+	}
+
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate(%s) error = %v, want *domain.ValidationError", mode, err)
+	}
+	if len(verr.Violations) != len(want) {
+		t.Fatalf("Validate(%s) violations = %+v, want %+v", mode, verr.Violations, want)
+	}
+	for i, v := range verr.Violations {
+		if v.Field != want[i].Field || v.Code != want[i].Code || v.Message == "" {
+			t.Errorf("Validate(%s) violation %d = %+v, want %s %s", mode, i, v, want[i].Field, want[i].Code)
+		}
+	}
+}
+
+// Битый JSON - не нарушение схемы
+func TestValidator_InvalidJSON(t *testing.T) {
+	v, err := NewOrderValidator(ModeStrict)
+	if err != nil {
+		t.Fatalf("NewOrderValidator() error = %v", err)
+	}
+	err = v.Validate([]byte(`{"order_uid":`))
+	if err == nil || domain.Violations(err) != nil {
+		t.Errorf("Expected plain JSON error, got %v", err)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for s, want := range map[string]Mode{
+		"":        ModeOff,
+		"off":     ModeOff,
+		"lenient": ModeLenient,
+		"strict":  ModeStrict,
+	} {
+		got, err := ParseMode(s)
+		if err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", s, got, err, want)
+		}
+	}
+	if _, err := ParseMode("on"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
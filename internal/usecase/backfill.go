@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"RWB_L0/internal/dto"
+	"RWB_L0/pkg/logger"
+)
+
+// maxPayloadSize - максимальный размер одной строки с заказом при backfill
+const maxPayloadSize = 16 * 1024 * 1024
+
+// BackfillReport - итог дозаполнения rid
+type BackfillReport struct {
+	Orders  int   // Обработано заказов
+	Items   int64 // Обновлено товаров
+	Skipped int   // Строк, которые не удалось разобрать
+}
+
+// RidBackfillUseCase дозаполняет rid у товаров по исходным сообщениям с заказами.
+// Товары, сохранённые до миграции 000004, хранятся с пустым rid; сами значения
+// есть только в исходных сообщениях (например, в выгрузке канала NATS)
+type RidBackfillUseCase struct {
+	repo ItemRidRepository
+	log  logger.Logger
+}
+
+// NewRidBackfillUseCase создаёт новый экземпляр RidBackfillUseCase
+func NewRidBackfillUseCase(repo ItemRidRepository, log logger.Logger) *RidBackfillUseCase {
+	return &RidBackfillUseCase{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Run читает заказы в формате JSON lines (один CreateOrderInput на строку)
+// и дозаполняет rid их товаров. Нечитаемые строки пропускаются, ошибка БД прерывает работу
+func (uc *RidBackfillUseCase) Run(ctx context.Context, r io.Reader) (*BackfillReport, error) {
+	report := &BackfillReport{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxPayloadSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var input dto.CreateOrderInput
+		if err := json.Unmarshal(scanner.Bytes(), &input); err != nil {
+			uc.log.Warn("Skipping line %d: invalid JSON: %v", line, err)
+			report.Skipped++
+			continue
+		}
+
+		order, err := input.ToDomain()
+		if err != nil {
+			uc.log.Warn("Skipping line %d: %v", line, err)
+			report.Skipped++
+			continue
+		}
+
+		updated, err := uc.repo.BackfillItemRids(ctx, order)
+		if err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		report.Orders++
+		report.Items += updated
+	}
+
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("failed to read orders: %w", err)
+	}
+
+	return report, nil
+}
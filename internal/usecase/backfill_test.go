@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/pkg/logger"
+)
+
+// MockItemRidRepository - мок для ItemRidRepository
+type MockItemRidRepository struct {
+	orders []*domain.Order
+	err    error
+}
+
+func (m *MockItemRidRepository) BackfillItemRids(_ context.Context, order *domain.Order) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	m.orders = append(m.orders, order)
+	return int64(len(order.Items)), nil
+}
+
+func TestRidBackfillUseCase_Run(t *testing.T) {
+	repo := &MockItemRidRepository{}
+	uc := NewRidBackfillUseCase(repo, logger.New("error"))
+
+	input := strings.Join([]string{
+		string(validOrderPayload(t, "order-1")),
+		"",
+		`{"order_uid":`,
+		string(validOrderPayload(t, "order-2")),
+	}, "\n")
+
+	report, err := uc.Run(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Orders != 2 || report.Items != 2 || report.Skipped != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(repo.orders) != 2 || repo.orders[1].OrderUID != "order-2" {
+		t.Errorf("Expected orders to be backfilled in input order, got %d", len(repo.orders))
+	}
+}
+
+func TestRidBackfillUseCase_Run_RepositoryError(t *testing.T) {
+	repo := &MockItemRidRepository{err: errors.New("connection refused")}
+	uc := NewRidBackfillUseCase(repo, logger.New("error"))
+
+	_, err := uc.Run(context.Background(), strings.NewReader(string(validOrderPayload(t, "order-1"))))
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected error with line number, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/dto"
+)
+
+// Проверка на этапе компиляции, что DeadLetterUseCase реализует DeadLetterUseCaseInterface
+var _ DeadLetterUseCaseInterface = (*DeadLetterUseCase)(nil)
+
+// DeadLetterConfig - настройки DeadLetterUseCase
+type DeadLetterConfig struct {
+	// StatusSubject - subject сообщений о смене статуса: Replay меняет по ним статус,
+	// payload остальных subject - заказы. Пусто - все сообщения считаются заказами
+	StatusSubject string
+}
+
+// DeadLetterUseCase - работа с сообщениями, которые не удалось обработать
+type DeadLetterUseCase struct {
+	repo   DeadLetterRepository
+	orders OrderUseCaseInterface
+	cfg    DeadLetterConfig
+}
+
+// NewDeadLetterUseCase создаёт новый экземпляр DeadLetterUseCase.
+// orders используется для повторной обработки (Replay)
+func NewDeadLetterUseCase(repo DeadLetterRepository, orders OrderUseCaseInterface, cfg DeadLetterConfig) *DeadLetterUseCase {
+	return &DeadLetterUseCase{
+		repo:   repo,
+		orders: orders,
+		cfg:    cfg,
+	}
+}
+
+// Record сохраняет необработанное сообщение
+func (uc *DeadLetterUseCase) Record(ctx context.Context, letter *domain.DeadLetter) error {
+	if letter.Subject == "" {
+		return fmt.Errorf("dead letter subject is required")
+	}
+	if letter.Attempts <= 0 {
+		letter.Attempts = 1
+	}
+
+	if err := uc.repo.Save(ctx, letter); err != nil {
+		return fmt.Errorf("failed to record dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// List получает страницу сообщений, новые первыми
+func (uc *DeadLetterUseCase) List(ctx context.Context, input *dto.ListDeadLettersInput) (*dto.ListDeadLettersOutput, error) {
+	limit := input.Limit
+	if limit < 0 {
+		return nil, domain.ErrInvalidListLimit
+	}
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+
+	var beforeID int64
+	if input.Cursor != "" {
+		id, err := dto.DecodeDeadLetterCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		beforeID = id
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	letters, err := uc.repo.List(ctx, beforeID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	output := &dto.ListDeadLettersOutput{
+		DeadLetters: make([]*dto.DeadLetterOutput, 0, len(letters)),
+	}
+	if len(letters) > limit {
+		letters = letters[:limit]
+		output.NextCursor = dto.EncodeDeadLetterCursor(letters[limit-1].ID)
+		output.HasMore = true
+	}
+	for _, letter := range letters {
+		output.DeadLetters = append(output.DeadLetters, dto.FromDomainDeadLetter(letter))
+	}
+
+	return output, nil
+}
+
+// Get получает сообщение по id
+func (uc *DeadLetterUseCase) Get(ctx context.Context, id int64) (*dto.DeadLetterOutput, error) {
+	letter, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return dto.FromDomainDeadLetter(letter), nil
+}
+
+// Delete удаляет сообщение
+func (uc *DeadLetterUseCase) Delete(ctx context.Context, id int64) error {
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return nil
+}
+
+// Replay повторно обрабатывает сохранённый payload через OrderUseCase.Create
+// (сообщения о статусе - через OrderUseCase.ChangeStatus).
+// При успехе сообщение удаляется, при ошибке в записи обновляются ошибка и число попыток
+func (uc *DeadLetterUseCase) Replay(ctx context.Context, id int64) error {
+	letter, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	if err := uc.replay(ctx, letter); err != nil {
+		letter.Error = err.Error()
+		letter.Attempts++
+		if saveErr := uc.repo.Save(ctx, letter); saveErr != nil {
+			return fmt.Errorf("replay failed: %w (and failed to update dead letter: %v)", err, saveErr)
+		}
+		return fmt.Errorf("replay failed: %w", err)
+	}
+
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("order replayed, but failed to delete dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// replay - разбор payload и создание заказа или смена его статуса
+func (uc *DeadLetterUseCase) replay(ctx context.Context, letter *domain.DeadLetter) error {
+	origin := domain.ChangeOrigin{Source: domain.ChangeSourceAdmin, Sequence: letter.Sequence}
+
+	if uc.cfg.StatusSubject != "" && letter.Subject == uc.cfg.StatusSubject {
+		var input dto.ChangeStatusInput
+		if err := json.Unmarshal(letter.Payload, &input); err != nil {
+			// Битое сообщение, а не недопустимый статус: повтор исправленного заказа не поможет
+			return fmt.Errorf("invalid JSON: %w: %w", domain.ErrInvalidOrder, err)
+		}
+		_, err := uc.orders.ChangeStatus(ctx, &input, 0, origin)
+		return err
+	}
+
+	var input dto.CreateOrderInput
+	if err := json.Unmarshal(letter.Payload, &input); err != nil {
+		return fmt.Errorf("invalid JSON: %w: %w", domain.ErrInvalidOrder, err)
+	}
+
+	_, err := uc.orders.Create(ctx, &input, origin)
+	return err
+}
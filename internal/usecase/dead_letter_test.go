@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/internal/dto"
+)
+
+// MockDeadLetterRepository - мок для DeadLetterRepository
+type MockDeadLetterRepository struct {
+	letters map[int64]*domain.DeadLetter
+	nextID  int64
+}
+
+func NewMockDeadLetterRepository() *MockDeadLetterRepository {
+	return &MockDeadLetterRepository{letters: make(map[int64]*domain.DeadLetter)}
+}
+
+func (m *MockDeadLetterRepository) Save(_ context.Context, letter *domain.DeadLetter) error {
+	if letter.ID == 0 {
+		m.nextID++
+		letter.ID = m.nextID
+	}
+	m.letters[letter.ID] = letter
+	return nil
+}
+
+func (m *MockDeadLetterRepository) List(_ context.Context, beforeID int64, limit int) ([]*domain.DeadLetter, error) {
+	var letters []*domain.DeadLetter
+	for id, letter := range m.letters {
+		if beforeID == 0 || id < beforeID {
+			letters = append(letters, letter)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i].ID > letters[j].ID })
+	if len(letters) > limit {
+		letters = letters[:limit]
+	}
+	return letters, nil
+}
+
+func (m *MockDeadLetterRepository) GetByID(_ context.Context, id int64) (*domain.DeadLetter, error) {
+	if letter, ok := m.letters[id]; ok {
+		return letter, nil
+	}
+	return nil, domain.ErrDeadLetterNotFound
+}
+
+func (m *MockDeadLetterRepository) Delete(_ context.Context, id int64) error {
+	if _, ok := m.letters[id]; !ok {
+		return domain.ErrDeadLetterNotFound
+	}
+	delete(m.letters, id)
+	return nil
+}
+
+func validOrderPayload(t *testing.T, uid string) []byte {
+	t.Helper()
+	data, err := json.Marshal(dto.CreateOrderInput{
+		OrderUID:    uid,
+		TrackNumber: "TRACK",
+		Entry:       "WBIL",
+		Delivery:    dto.DeliveryInput{Name: "Test User", Phone: "+79001234567"},
+		Payment:     dto.PaymentInput{Transaction: uid, Currency: "USD", Amount: 100, GoodsTotal: 100},
+		Items:       []dto.ItemInput{{ChrtID: 1, TrackNumber: "TRACK", Price: 100, Name: "Item", TotalPrice: 100}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal payload: %v", err)
+	}
+	return data
+}
+
+func TestDeadLetterUseCase_List(t *testing.T) {
+	repo := NewMockDeadLetterRepository()
+	uc := NewDeadLetterUseCase(repo, nil, DeadLetterConfig{})
+
+	for i := 0; i < 3; i++ {
+		_ = uc.Record(context.Background(), &domain.DeadLetter{Subject: "orders", Sequence: uint64(i + 1)})
+	}
+
+	page, err := uc.List(context.Background(), &dto.ListDeadLettersInput{Limit: 2})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(page.DeadLetters) != 2 || !page.HasMore || page.DeadLetters[0].ID != 3 {
+		t.Fatalf("Unexpected first page: %+v", page)
+	}
+
+	page, err = uc.List(context.Background(), &dto.ListDeadLettersInput{Limit: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(page.DeadLetters) != 1 || page.HasMore || page.DeadLetters[0].ID != 1 {
+		t.Errorf("Unexpected second page: %+v", page)
+	}
+
+	_, err = uc.List(context.Background(), &dto.ListDeadLettersInput{Cursor: "abc"})
+	if !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+// JSON отдаётся как есть, остальное - в base64 без потери байтов
+func TestDeadLetterUseCase_List_Payload(t *testing.T) {
+	repo := NewMockDeadLetterRepository()
+	uc := NewDeadLetterUseCase(repo, nil, DeadLetterConfig{})
+
+	binary := []byte{'{', 0xff, 0xfe, '}'}
+	for _, payload := range [][]byte{[]byte(`{"order_uid":"test"}`), []byte(`{"order_uid":`), binary} {
+		_ = uc.Record(context.Background(), &domain.DeadLetter{Subject: "orders", Payload: payload})
+	}
+
+	page, err := uc.List(context.Background(), &dto.ListDeadLettersInput{Limit: 3})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	valid, broken, raw := page.DeadLetters[2], page.DeadLetters[1], page.DeadLetters[0]
+
+	if string(valid.Payload) != `{"order_uid":"test"}` || valid.PayloadBase64 != "" {
+		t.Errorf("Expected JSON payload as is, got %q / %q", valid.Payload, valid.PayloadBase64)
+	}
+	if broken.Payload != nil || broken.PayloadBase64 != base64.StdEncoding.EncodeToString([]byte(`{"order_uid":`)) {
+		t.Errorf("Expected broken JSON in base64, got %q / %q", broken.Payload, broken.PayloadBase64)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(raw.PayloadBase64)
+	if err != nil || !bytes.Equal(decoded, binary) {
+		t.Errorf("Expected non-UTF-8 bytes intact, got %v, %v", decoded, err)
+	}
+
+	// Ответ API - валидный JSON в обоих случаях
+	if _, err := json.Marshal(page); err != nil {
+		t.Errorf("Failed to marshal page: %v", err)
+	}
+}
+
+func TestDeadLetterUseCase_Replay(t *testing.T) {
+	orderRepo := NewMockRepository()
+	orders := newTestOrderUseCase(orderRepo, NewMockCache())
+	repo := NewMockDeadLetterRepository()
+	uc := NewDeadLetterUseCase(repo, orders, DeadLetterConfig{})
+
+	letter := &domain.DeadLetter{Subject: "orders", Sequence: 7, Payload: validOrderPayload(t, "replayed-1")}
+	_ = uc.Record(context.Background(), letter)
+
+	if err := uc.Replay(context.Background(), letter.ID); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if _, ok := orderRepo.orders["replayed-1"]; !ok {
+		t.Error("Expected replayed order to be saved")
+	}
+	if len(repo.letters) != 0 {
+		t.Error("Expected dead letter to be deleted after successful replay")
+	}
+}
+
+func TestDeadLetterUseCase_Replay_StillInvalid(t *testing.T) {
+	orders := newTestOrderUseCase(NewMockRepository(), NewMockCache())
+	repo := NewMockDeadLetterRepository()
+	uc := NewDeadLetterUseCase(repo, orders, DeadLetterConfig{})
+
+	letter := &domain.DeadLetter{Subject: "orders", Sequence: 8, Payload: []byte(`{"order_uid":`)}
+	_ = uc.Record(context.Background(), letter)
+
+	err := uc.Replay(context.Background(), letter.ID)
+	if !errors.Is(err, domain.ErrInvalidOrder) {
+		t.Fatalf("Expected ErrInvalidOrder, got %v", err)
+	}
+
+	stored := repo.letters[letter.ID]
+	if stored == nil {
+		t.Fatal("Dead letter must be kept when replay fails")
+	}
+	if stored.Attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", stored.Attempts)
+	}
+
+	if err := uc.Replay(context.Background(), 999); !errors.Is(err, domain.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound, got %v", err)
+	}
+}
+
+func TestDeadLetterUseCase_Replay_Status(t *testing.T) {
+	orderRepo := NewMockRepository()
+	orders := newTestOrderUseCase(orderRepo, NewMockCache())
+	repo := NewMockDeadLetterRepository()
+	uc := NewDeadLetterUseCase(repo, orders, DeadLetterConfig{StatusSubject: "orders.status"})
+
+	// Сообщение о статусе обогнало заказ: после появления заказа его можно обработать повторно
+	letter := &domain.DeadLetter{Subject: "orders.status", Sequence: 9, Payload: []byte(`{"order_uid":"late","status":"paid"}`)}
+	_ = uc.Record(context.Background(), letter)
+	if err := uc.Replay(context.Background(), letter.ID); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Fatalf("Expected ErrOrderNotFound, got %v", err)
+	}
+
+	var input dto.CreateOrderInput
+	_ = json.Unmarshal(validOrderPayload(t, "late"), &input)
+	if _, err := orders.Create(context.Background(), &input, natsOrigin); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := uc.Replay(context.Background(), letter.ID); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if status := orderRepo.orders["late"].Status; status != domain.StatusPaid {
+		t.Errorf("Expected status paid after replay, got %q", status)
+	}
+	if len(repo.letters) != 0 {
+		t.Error("Expected dead letter to be deleted after successful replay")
+	}
+}
+
+// Битый JSON сообщения о статусе - невалидное сообщение, а не недопустимый статус
+func TestDeadLetterUseCase_Replay_StatusBrokenJSON(t *testing.T) {
+	repo := NewMockDeadLetterRepository()
+	uc := NewDeadLetterUseCase(repo, newTestOrderUseCase(NewMockRepository(), NewMockCache()), DeadLetterConfig{StatusSubject: "orders.status"})
+
+	letter := &domain.DeadLetter{Subject: "orders.status", Sequence: 9, Payload: []byte(`{"order_uid":`)}
+	_ = uc.Record(context.Background(), letter)
+
+	err := uc.Replay(context.Background(), letter.ID)
+	if !errors.Is(err, domain.ErrInvalidOrder) || errors.Is(err, domain.ErrInvalidOrderStatus) {
+		t.Errorf("Expected ErrInvalidOrder, got %v", err)
+	}
+	if len(repo.letters) != 1 {
+		t.Error("Expected dead letter to stay after failed replay")
+	}
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"RWB_L0/internal/domain"
 	"RWB_L0/internal/dto"
@@ -9,13 +10,46 @@ import (
 
 // OrderRepository - интерфейс для работы с БД
 type OrderRepository interface {
-	Save(ctx context.Context, order *domain.Order) error
+	Save(ctx context.Context, order *domain.Order, opts domain.SaveOptions) (domain.SaveOutcome, error)
+	SaveBatch(ctx context.Context, batch []domain.SaveRequest) ([]domain.SaveResult, error)
 	GetByID(ctx context.Context, orderUID string) (*domain.Order, error)
 	GetAll(ctx context.Context) ([]*domain.Order, error)
-	Delete(ctx context.Context, orderUID string) error
+	List(ctx context.Context, query *domain.OrderListQuery) (*domain.OrderPage, error)
+	Delete(ctx context.Context, orderUID string, expectedVersion int64, origin domain.ChangeOrigin) error
+	History(ctx context.Context, orderUID string) ([]*domain.OrderChange, error)
+	ChangeStatus(ctx context.Context, orderUID string, change domain.StatusChange) (*domain.Order, domain.SaveOutcome, error)
+	StatusHistory(ctx context.Context, orderUID string) ([]*domain.StatusTransition, error)
 	Count(ctx context.Context) (int, error)
 }
 
+// ItemRidRepository - дозаполнение rid у товаров, сохранённых без него
+type ItemRidRepository interface {
+	BackfillItemRids(ctx context.Context, order *domain.Order) (int64, error)
+}
+
+// DeadLetterRepository - хранилище сообщений, которые не удалось обработать
+type DeadLetterRepository interface {
+	Save(ctx context.Context, letter *domain.DeadLetter) error
+	List(ctx context.Context, beforeID int64, limit int) ([]*domain.DeadLetter, error)
+	GetByID(ctx context.Context, id int64) (*domain.DeadLetter, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// OutboxRepository - события о заказах, сохранённые вместе с заказами (transactional outbox)
+type OutboxRepository interface {
+	ProcessPending(ctx context.Context, limit int, publish func(*domain.OutboxEvent) error) (int, error)
+}
+
+// ProcessedMessageRepository - отметки об обработанных сообщениях NATS
+type ProcessedMessageRepository interface {
+	DeleteProcessedOlderThan(ctx context.Context, age time.Duration, limit int) (int64, error)
+}
+
+// EventPublisher - отправка событий во внешний брокер (pkgnats.Publisher)
+type EventPublisher interface {
+	PublishBytes(subject string, data []byte) error
+}
+
 // Cache - интерфейс для работы с кэшем
 type Cache interface {
 	Set(orderUID string, order *domain.Order) error
@@ -24,23 +58,82 @@ type Cache interface {
 	LoadAll(orders []*domain.Order) error
 	GetAll() ([]*domain.Order, error)
 	Count() int
+	Capacity() int
 	Clear() error
+	Stats() CacheStats
+}
+
+// CacheStats - счётчики работы кэша с момента запуска
+type CacheStats struct {
+	Hits        uint64 // Get нашёл заказ
+	Misses      uint64 // Get не нашёл заказ (включая устаревшие)
+	Expirations uint64 // Записи, удалённые по TTL
+	Evictions   uint64 // Записи, вытесненные по LRU
+	Sets        uint64 // Вызовы Set и заказы, загруженные через LoadAll
+	Size        int    // Текущее количество записей
+	Capacity    int    // Максимальное количество записей
+	MemoryBytes int64  // Примерный объём памяти, занятой заказами
+}
+
+// HitRatio - доля попаданий среди всех Get (0, если обращений не было)
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
 }
 
 // OrderUseCaseInterface определяет контракт для бизнес-логики заказов
 type OrderUseCaseInterface interface {
-	// Create создаёт новый заказ
-	Create(ctx context.Context, input *dto.CreateOrderInput) error
+	// Create создаёт заказ или обновляет уже сохранённый согласно политике upsert
+	Create(ctx context.Context, input *dto.CreateOrderInput, origin domain.ChangeOrigin) (domain.SaveOutcome, error)
+
+	// CreateBatch создаёт пакет заказов; результаты - в порядке requests
+	CreateBatch(ctx context.Context, requests []CreateRequest) []domain.SaveResult
+
+	// Update заменяет заказ, если его текущая версия равна expectedVersion
+	Update(ctx context.Context, orderUID string, input *dto.CreateOrderInput, expectedVersion int64) (*dto.OrderOutput, error)
+
+	// Delete удаляет заказ, если его текущая версия равна expectedVersion (admin API)
+	Delete(ctx context.Context, orderUID string, expectedVersion int64) error
 
 	// GetByUID получает заказ по UID
 	GetByUID(ctx context.Context, orderUID string) (*dto.OrderOutput, error)
 
+	// History получает историю изменений заказа, от старых к новым
+	History(ctx context.Context, orderUID string) (*dto.OrderHistoryOutput, error)
+
+	// ChangeStatus переводит заказ в другой статус по таблице переходов
+	ChangeStatus(ctx context.Context, input *dto.ChangeStatusInput, expectedVersion int64, origin domain.ChangeOrigin) (*dto.OrderOutput, error)
+
+	// StatusHistory получает текущий статус заказа и историю переходов
+	StatusHistory(ctx context.Context, orderUID string) (*dto.OrderStatusOutput, error)
+
 	// GetAll получает все заказы
 	GetAll(ctx context.Context) ([]*dto.OrderOutput, error)
 
-	// RestoreCache восстанавливает кэш из БД при старте приложения
+	// List получает страницу заказов с фильтрами
+	List(ctx context.Context, input *dto.ListOrdersInput) (*dto.ListOrdersOutput, error)
+
+	// RestoreCache восстанавливает кэш из БД при старте приложения (новые заказы первыми)
 	RestoreCache(ctx context.Context) error
 
 	// GetCacheStats возвращает статистику кэша
 	GetCacheStats() map[string]interface{}
 }
+
+// DeadLetterUseCaseInterface определяет контракт для работы с необработанными сообщениями
+type DeadLetterUseCaseInterface interface {
+	// List получает страницу сообщений, новые первыми
+	List(ctx context.Context, input *dto.ListDeadLettersInput) (*dto.ListDeadLettersOutput, error)
+
+	// Get получает сообщение по id
+	Get(ctx context.Context, id int64) (*dto.DeadLetterOutput, error)
+
+	// Delete удаляет сообщение
+	Delete(ctx context.Context, id int64) error
+
+	// Replay повторно обрабатывает сохранённый payload и удаляет сообщение при успехе
+	Replay(ctx context.Context, id int64) error
+}
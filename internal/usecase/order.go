@@ -6,8 +6,27 @@ import (
 
 	"RWB_L0/internal/domain"
 	"RWB_L0/internal/dto"
+	"RWB_L0/pkg/logger"
 )
 
+const (
+	// DefaultListLimit - размер страницы по умолчанию
+	DefaultListLimit = 20
+	// MaxListLimit - максимальный размер страницы
+	MaxListLimit = 100
+	// DefaultWarmupBatchSize - размер пачки при прогреве кэша по умолчанию
+	DefaultWarmupBatchSize = 500
+)
+
+// Config - настройки OrderUseCase
+type Config struct {
+	WarmupBatchSize int                 // Сколько заказов читать из БД за один запрос при прогреве кэша
+	UpsertPolicy    domain.UpsertPolicy // Что делать с повторно присланным order_uid
+	// ValidationMode - сверять ли суммы платежа и товаров (strict) или нет (lenient);
+	// пусто - domain.DefaultValidationMode
+	ValidationMode domain.ValidationMode
+}
+
 // Проверка на этапе компиляции, что OrderUseCase реализует OrderUseCaseInterface
 var _ OrderUseCaseInterface = (*OrderUseCase)(nil)
 
@@ -15,37 +34,178 @@ var _ OrderUseCaseInterface = (*OrderUseCase)(nil)
 type OrderUseCase struct {
 	repo  OrderRepository
 	cache Cache
+	log   logger.Logger
+	cfg   Config
 }
 
 // NewOrderUseCase создаёт новый экземпляр OrderUseCase
-func NewOrderUseCase(repo OrderRepository, cache Cache) *OrderUseCase {
+func NewOrderUseCase(repo OrderRepository, cache Cache, log logger.Logger, cfg Config) *OrderUseCase {
+	if cfg.WarmupBatchSize <= 0 {
+		cfg.WarmupBatchSize = DefaultWarmupBatchSize
+	}
+	if cfg.UpsertPolicy == "" {
+		cfg.UpsertPolicy = domain.UpsertReplace
+	}
+	if cfg.ValidationMode == "" {
+		cfg.ValidationMode = domain.DefaultValidationMode
+	}
+
 	return &OrderUseCase{
 		repo:  repo,
 		cache: cache,
+		log:   log,
+		cfg:   cfg,
 	}
 }
 
-// Create создаёт новый заказ
-func (uc *OrderUseCase) Create(ctx context.Context, input *dto.CreateOrderInput) error {
-	// Конвертируем DTO в доменную модель
-	order, err := input.ToDomain()
+// CreateRequest - заказ для CreateBatch и источник изменения для истории
+type CreateRequest struct {
+	Input  *dto.CreateOrderInput
+	Origin domain.ChangeOrigin
+}
+
+// Create создаёт заказ. Если order_uid уже сохранён, заказ заменяется или
+// отбрасывается согласно Config.UpsertPolicy - результат сообщает, что произошло.
+// origin записывается в историю заказа
+func (uc *OrderUseCase) Create(ctx context.Context, input *dto.CreateOrderInput, origin domain.ChangeOrigin) (domain.SaveOutcome, error) {
+	order, err := uc.newValidOrder(input)
 	if err != nil {
-		return fmt.Errorf("failed to convert DTO: %w", err)
+		return "", err
 	}
 
-	// Валидация доменной модели
-	if err := order.Validate(); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+	// Сохраняем в БД
+	outcome, err := uc.repo.Save(ctx, order, domain.SaveOptions{Policy: uc.cfg.UpsertPolicy, Origin: origin})
+	if err != nil {
+		return "", fmt.Errorf("failed to create order: %w", err)
 	}
 
-	// Сохраняем в БД
-	if err := uc.repo.Save(ctx, order); err != nil {
-		return fmt.Errorf("failed to create order: %w", err)
+	uc.cacheSaved(ctx, order, outcome)
+	return outcome, nil
+}
+
+// CreateBatch создаёт пакет заказов за одно обращение к БД - по тем же правилам,
+// что и Create. Результаты - в порядке requests: ошибка одного заказа
+// (невалидный заказ, ошибка БД) не мешает сохранить остальные
+func (uc *OrderUseCase) CreateBatch(ctx context.Context, requests []CreateRequest) []domain.SaveResult {
+	results := make([]domain.SaveResult, len(requests))
+
+	// Невалидные заказы в БД не отправляем; positions - индекс заказа batch в requests
+	batch := make([]domain.SaveRequest, 0, len(requests))
+	positions := make([]int, 0, len(requests))
+	for i, req := range requests {
+		order, err := uc.newValidOrder(req.Input)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		batch = append(batch, domain.SaveRequest{
+			Order:   order,
+			Options: domain.SaveOptions{Policy: uc.cfg.UpsertPolicy, Origin: req.Origin},
+		})
+		positions = append(positions, i)
+	}
+	if len(batch) == 0 {
+		return results
+	}
+
+	saved, err := uc.repo.SaveBatch(ctx, batch)
+	if err != nil {
+		for _, i := range positions {
+			results[i].Err = fmt.Errorf("failed to create order: %w", err)
+		}
+		return results
+	}
+
+	for j, res := range saved {
+		i := positions[j]
+		if res.Err != nil {
+			results[i].Err = fmt.Errorf("failed to create order: %w", res.Err)
+			continue
+		}
+		results[i].Outcome = res.Outcome
+		uc.cacheSaved(ctx, batch[j].Order, res.Outcome)
+	}
+
+	return results
+}
+
+// newValidOrder - доменная модель из DTO; невалидный заказ - domain.ErrInvalidOrder
+func (uc *OrderUseCase) newValidOrder(input *dto.CreateOrderInput) (*domain.Order, error) {
+	// Конвертируем DTO в доменную модель; все нарушения - одним domain.ValidationError
+	order, err := input.ToDomainWith(uc.cfg.ValidationMode)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w: %w", domain.ErrInvalidOrder, err)
+	}
+
+	return order, nil
+}
+
+// cacheSaved - кэширование сохранённого заказа (ошибка кэша только логируется)
+func (uc *OrderUseCase) cacheSaved(ctx context.Context, order *domain.Order, outcome domain.SaveOutcome) {
+	if outcome == domain.SaveIgnored || outcome == domain.SaveDuplicate {
+		// В БД остался прежний заказ - кэш не трогаем
+		return
+	}
+
+	if err := uc.cache.Set(order.OrderUID, order); err != nil {
+		logger.FromContext(ctx, uc.log).Warn("Failed to cache order: %v", err)
+	}
+}
+
+// Update заменяет сохранённый заказ присланным (admin API).
+// Изменение применяется, только если версия заказа в БД равна expectedVersion,
+// иначе domain.ErrVersionConflict - клиент должен перечитать заказ и повторить
+func (uc *OrderUseCase) Update(ctx context.Context, orderUID string, input *dto.CreateOrderInput, expectedVersion int64) (*dto.OrderOutput, error) {
+	if orderUID == "" {
+		return nil, domain.ErrEmptyOrderUID
+	}
+	if expectedVersion <= 0 {
+		return nil, domain.ErrVersionConflict
+	}
+	if input.OrderUID == "" {
+		input.OrderUID = orderUID
+	}
+	if input.OrderUID != orderUID {
+		return nil, fmt.Errorf("%w: order_uid %q does not match %q", domain.ErrInvalidOrder, input.OrderUID, orderUID)
+	}
+
+	order, err := uc.newValidOrder(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := domain.SaveOptions{
+		Policy:          domain.UpsertReplace,
+		ExpectedVersion: expectedVersion,
+		Origin:          domain.ChangeOrigin{Source: domain.ChangeSourceAdmin},
+	}
+	if _, err := uc.repo.Save(ctx, order, opts); err != nil {
+		return nil, fmt.Errorf("failed to update order: %w", err)
 	}
 
-	// Сохраняем в кэш (игнорируем ошибку кэша)
 	if err := uc.cache.Set(order.OrderUID, order); err != nil {
-		_ = err // Логируем, но не возвращаем ошибку
+		logger.FromContext(ctx, uc.log).Warn("Failed to cache order: %v", err)
+	}
+
+	return dto.FromDomain(order), nil
+}
+
+// Delete удаляет заказ (admin API). Удаление записывается в историю заказа.
+// Как и Update, удаляет только заказ с версией expectedVersion, иначе domain.ErrVersionConflict
+func (uc *OrderUseCase) Delete(ctx context.Context, orderUID string, expectedVersion int64) error {
+	if orderUID == "" {
+		return domain.ErrEmptyOrderUID
+	}
+	if expectedVersion <= 0 {
+		return domain.ErrVersionConflict
+	}
+
+	if err := uc.repo.Delete(ctx, orderUID, expectedVersion, domain.ChangeOrigin{Source: domain.ChangeSourceAdmin}); err != nil {
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+
+	if err := uc.cache.Delete(orderUID); err != nil {
+		logger.FromContext(ctx, uc.log).Warn("Failed to evict order from cache: %v", err)
 	}
 
 	return nil
@@ -76,6 +236,73 @@ func (uc *OrderUseCase) GetByUID(ctx context.Context, orderUID string) (*dto.Ord
 	return dto.FromDomain(order), nil
 }
 
+// History получает историю изменений заказа, от старых к новым.
+// Заказ без истории считается ненайденным
+func (uc *OrderUseCase) History(ctx context.Context, orderUID string) (*dto.OrderHistoryOutput, error) {
+	if orderUID == "" {
+		return nil, domain.ErrEmptyOrderUID
+	}
+
+	changes, err := uc.repo.History(ctx, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order history: %w", err)
+	}
+	if len(changes) == 0 {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	return dto.FromDomainHistory(orderUID, changes), nil
+}
+
+// ChangeStatus переводит заказ в статус input.Status (admin API, сообщения NATS).
+// Неизвестный статус - domain.ErrInvalidOrderStatus, переход не по таблице переходов -
+// domain.ErrInvalidStatusTransition. Если expectedVersion не 0, статус меняется, только
+// если версия заказа равна ей (иначе domain.ErrVersionConflict).
+// Заказ уже в этом статусе или повторно доставленное сообщение NATS заказ не меняют
+func (uc *OrderUseCase) ChangeStatus(ctx context.Context, input *dto.ChangeStatusInput, expectedVersion int64, origin domain.ChangeOrigin) (*dto.OrderOutput, error) {
+	if input.OrderUID == "" {
+		return nil, domain.ErrEmptyOrderUID
+	}
+	status, err := domain.ParseOrderStatus(input.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	change := domain.StatusChange{
+		To:              status,
+		Reason:          input.Reason,
+		ExpectedVersion: expectedVersion,
+		Origin:          origin,
+	}
+	order, outcome, err := uc.repo.ChangeStatus(ctx, input.OrderUID, change)
+	if err != nil {
+		return nil, fmt.Errorf("failed to change order status: %w", err)
+	}
+
+	if outcome == domain.SaveUpdated {
+		if err := uc.cache.Set(order.OrderUID, order); err != nil {
+			logger.FromContext(ctx, uc.log).Warn("Failed to cache order: %v", err)
+		}
+	}
+
+	return dto.FromDomain(order), nil
+}
+
+// StatusHistory получает текущий статус заказа и историю переходов, от старых к новым
+func (uc *OrderUseCase) StatusHistory(ctx context.Context, orderUID string) (*dto.OrderStatusOutput, error) {
+	order, err := uc.GetByUID(ctx, orderUID)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions, err := uc.repo.StatusHistory(ctx, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order status history: %w", err)
+	}
+
+	return dto.FromDomainStatus(orderUID, domain.OrderStatus(order.Status), transitions), nil
+}
+
 // GetAll получает все заказы
 func (uc *OrderUseCase) GetAll(ctx context.Context) ([]*dto.OrderOutput, error) {
 	orders, err := uc.repo.GetAll(ctx)
@@ -92,24 +319,94 @@ func (uc *OrderUseCase) GetAll(ctx context.Context) ([]*dto.OrderOutput, error)
 	return result, nil
 }
 
-// RestoreCache восстанавливает кэш из БД при старте приложения
-func (uc *OrderUseCase) RestoreCache(ctx context.Context) error {
-	orders, err := uc.repo.GetAll(ctx)
+// List получает страницу заказов с фильтрами (keyset-пагинация)
+func (uc *OrderUseCase) List(ctx context.Context, input *dto.ListOrdersInput) (*dto.ListOrdersOutput, error) {
+	// Нормализуем размер страницы
+	if input.Limit == 0 {
+		input.Limit = DefaultListLimit
+	}
+	if input.Limit > MaxListLimit {
+		input.Limit = MaxListLimit
+	}
+
+	query, err := input.ToQuery()
 	if err != nil {
-		return fmt.Errorf("failed to restore cache: %w", err)
+		return nil, err
 	}
 
-	// Используем LoadAll из Cache интерфейса
-	if err := uc.cache.LoadAll(orders); err != nil {
-		return fmt.Errorf("failed to load cache: %w", err)
+	page, err := uc.repo.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
 	}
 
-	return nil
+	return dto.FromDomainPage(page), nil
+}
+
+// RestoreCache восстанавливает кэш из БД при старте приложения.
+// Заказы читаются пачками от новых к старым, пока кэш не заполнится,
+// пачка не перестанет в него добавляться или заказы не закончатся.
+// Отмена ctx прерывает прогрев
+func (uc *OrderUseCase) RestoreCache(ctx context.Context) error {
+	capacity := uc.cache.Capacity()
+	query := &domain.OrderListQuery{}
+	loaded := 0
+
+	for {
+		free := capacity - uc.cache.Count()
+		if free <= 0 {
+			uc.log.Info("Cache warm-up stopped: capacity %d reached", capacity)
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("cache warm-up interrupted after %d orders: %w", loaded, err)
+		}
+
+		// Не читаем из БД больше, чем поместится в кэш
+		query.Limit = min(uc.cfg.WarmupBatchSize, free)
+
+		page, err := uc.repo.List(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to restore cache: %w", err)
+		}
+
+		before := uc.cache.Count()
+		if err := uc.cache.LoadAll(page.Orders); err != nil {
+			return fmt.Errorf("failed to load cache: %w", err)
+		}
+		cached := uc.cache.Count()
+
+		loaded += len(page.Orders)
+		uc.log.Info("Cache warm-up progress: %d orders read, %d/%d cached", loaded, cached, capacity)
+
+		if page.NextCursor == nil {
+			return nil
+		}
+
+		// Сегменты кэша заполняются неравномерно: Count может так и не дойти до capacity,
+		// а заказы из заполненных сегментов больше не добавляются. Пачка, не добавившая
+		// ни одного заказа, - знак, что дальше читать таблицу бесполезно
+		if cached <= before && len(page.Orders) > 0 {
+			uc.log.Info("Cache warm-up stopped: cache segments are full (%d/%d cached)", cached, capacity)
+			return nil
+		}
+		query.After = page.NextCursor
+	}
 }
 
 // GetCacheStats возвращает статистику кэша
 func (uc *OrderUseCase) GetCacheStats() map[string]interface{} {
+	stats := uc.cache.Stats()
+
 	return map[string]interface{}{
-		"cached_orders": uc.cache.Count(),
+		"cached_orders": stats.Size,
+		"capacity":      stats.Capacity,
+		"hits":          stats.Hits,
+		"misses":        stats.Misses,
+		"hit_ratio":     stats.HitRatio(),
+		"expirations":   stats.Expirations,
+		"evictions":     stats.Evictions,
+		"sets":          stats.Sets,
+		"memory_bytes":  stats.MemoryBytes,
 	}
 }
@@ -2,32 +2,106 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 	"testing"
 	"time"
 
 	"RWB_L0/internal/domain"
 	"RWB_L0/internal/dto"
+	"RWB_L0/pkg/logger"
 )
 
 // MockRepository - мок репозитория для тестов
 type MockRepository struct {
-	orders map[string]*domain.Order
-	err    error
+	orders      map[string]*domain.Order
+	history     []*domain.OrderChange
+	transitions []*domain.StatusTransition
+	processed   map[domain.ChangeOrigin]bool
+	sequences   map[string]uint64 // Номер сообщения NATS, последним записавшего заказ
+	err         error
+	saveErrs    map[string]error // Ошибки SaveBatch для отдельных заказов
 }
 
 func NewMockRepository() *MockRepository {
 	return &MockRepository{
-		orders: make(map[string]*domain.Order),
+		orders:    make(map[string]*domain.Order),
+		processed: make(map[domain.ChangeOrigin]bool),
+		sequences: make(map[string]uint64),
 	}
 }
 
-func (m *MockRepository) Save(_ context.Context, order *domain.Order) error {
+func (m *MockRepository) Save(_ context.Context, order *domain.Order, opts domain.SaveOptions) (domain.SaveOutcome, error) {
 	if m.err != nil {
-		return m.err
+		return "", m.err
+	}
+	if opts.Origin.Subject != "" {
+		if m.processed[opts.Origin] {
+			return domain.SaveDuplicate, nil
+		}
+		m.processed[opts.Origin] = true
+	}
+	stored, ok := m.orders[order.OrderUID]
+	if !ok {
+		if opts.ExpectedVersion != 0 {
+			return "", domain.ErrOrderNotFound
+		}
+		order.Version = 1
+		order.Status = domain.StatusCreated
+		m.orders[order.OrderUID] = order
+		m.sequences[order.OrderUID] = opts.Origin.Sequence
+		m.record(order, domain.ChangeCreated, opts.Origin)
+		return domain.SaveCreated, nil
+	}
+	if opts.ExpectedVersion != 0 && stored.Version != opts.ExpectedVersion {
+		return "", domain.ErrVersionConflict
 	}
+
+	outcome := opts.Policy.Resolve(m.sequences[order.OrderUID], opts.Origin)
+	if outcome == domain.SaveIgnored {
+		order.Version = stored.Version
+		return outcome, nil
+	}
+	order.Version = stored.Version + 1
+	order.Status = stored.Status
 	m.orders[order.OrderUID] = order
-	return nil
+	if opts.Origin.Sequence != 0 {
+		m.sequences[order.OrderUID] = opts.Origin.Sequence
+	}
+	m.record(order, domain.ChangeUpdated, opts.Origin)
+	return outcome, nil
+}
+
+// SaveBatch - Save для каждого заказа, как при откате пакета в postgres.OrderRepository
+func (m *MockRepository) SaveBatch(ctx context.Context, batch []domain.SaveRequest) ([]domain.SaveResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	results := make([]domain.SaveResult, len(batch))
+	for i, req := range batch {
+		if err := m.saveErrs[req.Order.OrderUID]; err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Outcome, results[i].Err = m.Save(ctx, req.Order, req.Options)
+	}
+	return results, nil
+}
+
+// record - запись в историю, как делает postgres.OrderRepository
+func (m *MockRepository) record(order *domain.Order, action domain.ChangeAction, origin domain.ChangeOrigin) {
+	snapshot, _ := json.Marshal(order)
+	m.history = append(m.history, &domain.OrderChange{
+		ID:       int64(len(m.history) + 1),
+		OrderUID: order.OrderUID,
+		Action:   action,
+		Source:   origin.Source,
+		Sequence: origin.Sequence,
+		Version:  order.Version,
+		Snapshot: snapshot,
+	})
 }
 
 func (m *MockRepository) GetByID(_ context.Context, orderUID string) (*domain.Order, error) {
@@ -52,24 +126,144 @@ func (m *MockRepository) GetAll(_ context.Context) ([]*domain.Order, error) {
 	return orders, nil
 }
 
-func (m *MockRepository) Delete(_ context.Context, orderUID string) error {
+func (m *MockRepository) List(_ context.Context, query *domain.OrderListQuery) (*domain.OrderPage, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	orders := make([]*domain.Order, 0, len(m.orders))
+	for _, order := range m.orders {
+		if query.Filter.CustomerID != "" && order.CustomerID != query.Filter.CustomerID {
+			continue
+		}
+		if query.After != nil && !isAfterCursor(order, query.After) {
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	// Тот же порядок, что и в postgres: date_created DESC, order_uid DESC
+	sort.Slice(orders, func(i, j int) bool {
+		return isAfterCursor(orders[j], domain.CursorOf(orders[i]))
+	})
+
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > query.Limit {
+		page.Orders = orders[:query.Limit]
+		page.NextCursor = domain.CursorOf(page.Orders[query.Limit-1])
+	}
+	return page, nil
+}
+
+// isAfterCursor - идёт ли заказ после курсора в порядке (date_created DESC, order_uid DESC)
+func isAfterCursor(order *domain.Order, cursor *domain.OrderCursor) bool {
+	if !order.DateCreated.Equal(cursor.DateCreated) {
+		return order.DateCreated.Before(cursor.DateCreated)
+	}
+	return order.OrderUID < cursor.OrderUID
+}
+
+func (m *MockRepository) Delete(_ context.Context, orderUID string, expectedVersion int64, origin domain.ChangeOrigin) error {
+	order, exists := m.orders[orderUID]
+	if !exists {
+		return domain.ErrOrderNotFound
+	}
+	if expectedVersion > 0 && order.Version != expectedVersion {
+		return domain.ErrVersionConflict
+	}
 	delete(m.orders, orderUID)
+	m.record(order, domain.ChangeDeleted, origin)
 	return nil
 }
 
+func (m *MockRepository) History(_ context.Context, orderUID string) ([]*domain.OrderChange, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	changes := make([]*domain.OrderChange, 0)
+	for _, change := range m.history {
+		if change.OrderUID == orderUID {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// ChangeStatus - те же правила, что у postgres.OrderRepository.ChangeStatus
+func (m *MockRepository) ChangeStatus(_ context.Context, orderUID string, change domain.StatusChange) (*domain.Order, domain.SaveOutcome, error) {
+	if m.err != nil {
+		return nil, "", m.err
+	}
+	stored, exists := m.orders[orderUID]
+	if !exists {
+		return nil, "", domain.ErrOrderNotFound
+	}
+	if change.ExpectedVersion != 0 && stored.Version != change.ExpectedVersion {
+		return nil, "", domain.ErrVersionConflict
+	}
+	if change.Origin.Subject != "" {
+		if m.processed[change.Origin] {
+			return stored, domain.SaveDuplicate, nil
+		}
+		m.processed[change.Origin] = true
+	}
+	if stored.Status == change.To {
+		return stored, domain.SaveIgnored, nil
+	}
+	if err := stored.Status.CheckTransition(change.To); err != nil {
+		delete(m.processed, change.Origin)
+		return nil, "", err
+	}
+
+	order := *stored
+	order.Status = change.To
+	order.Version++
+	m.orders[orderUID] = &order
+	m.transitions = append(m.transitions, &domain.StatusTransition{
+		ID:       int64(len(m.transitions) + 1),
+		OrderUID: orderUID,
+		From:     stored.Status,
+		To:       change.To,
+		Reason:   change.Reason,
+		Source:   change.Origin.Source,
+		Sequence: change.Origin.Sequence,
+		Version:  order.Version,
+	})
+	m.record(&order, domain.ChangeStatusChanged, change.Origin)
+	return &order, domain.SaveUpdated, nil
+}
+
+func (m *MockRepository) StatusHistory(_ context.Context, orderUID string) ([]*domain.StatusTransition, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	transitions := make([]*domain.StatusTransition, 0)
+	for _, transition := range m.transitions {
+		if transition.OrderUID == orderUID {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions, nil
+}
+
 func (m *MockRepository) Count(_ context.Context) (int, error) {
 	return len(m.orders), nil
 }
 
 // MockCache - мок кэша для тестов
 type MockCache struct {
-	orders map[string]*domain.Order
-	err    error
+	orders   map[string]*domain.Order
+	capacity int
+	err      error
+	hits     uint64 // Счётчики Get, как у настоящего кэша
+	misses   uint64
+	sets     uint64
 }
 
 func NewMockCache() *MockCache {
 	return &MockCache{
-		orders: make(map[string]*domain.Order),
+		orders:   make(map[string]*domain.Order),
+		capacity: 1000,
 	}
 }
 
@@ -78,6 +272,7 @@ func (m *MockCache) Set(orderUID string, order *domain.Order) error {
 		return m.err
 	}
 	m.orders[orderUID] = order
+	m.sets++
 	return nil
 }
 
@@ -87,8 +282,10 @@ func (m *MockCache) Get(orderUID string) (*domain.Order, error) {
 	}
 	order, exists := m.orders[orderUID]
 	if !exists {
+		m.misses++
 		return nil, errors.New("not found")
 	}
+	m.hits++
 	return order, nil
 }
 
@@ -99,7 +296,11 @@ func (m *MockCache) Delete(orderUID string) error {
 
 func (m *MockCache) LoadAll(orders []*domain.Order) error {
 	for _, order := range orders {
+		if len(m.orders) >= m.capacity {
+			break
+		}
 		m.orders[order.OrderUID] = order
+		m.sets++
 	}
 	return nil
 }
@@ -116,17 +317,39 @@ func (m *MockCache) Count() int {
 	return len(m.orders)
 }
 
+func (m *MockCache) Capacity() int {
+	return m.capacity
+}
+
+func (m *MockCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:     m.hits,
+		Misses:   m.misses,
+		Sets:     m.sets,
+		Size:     len(m.orders),
+		Capacity: m.capacity,
+	}
+}
+
 func (m *MockCache) Clear() error {
 	m.orders = make(map[string]*domain.Order)
 	return nil
 }
 
+// newTestOrderUseCase - use case с настройками по умолчанию и тихим логером
+func newTestOrderUseCase(repo OrderRepository, cache Cache) *OrderUseCase {
+	return NewOrderUseCase(repo, cache, logger.New("error"), Config{})
+}
+
+// natsOrigin - источник изменения для заказов, созданных в тестах
+var natsOrigin = domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Sequence: 7}
+
 // Тесты
 
 func TestOrderUseCase_GetByUID(t *testing.T) {
 	repo := NewMockRepository()
 	cache := NewMockCache()
-	uc := NewOrderUseCase(repo, cache)
+	uc := newTestOrderUseCase(repo, cache)
 
 	// Создаём тестовый заказ
 	order, _ := domain.NewOrder("test123", "TRACK123", "WBIL")
@@ -139,7 +362,7 @@ func TestOrderUseCase_GetByUID(t *testing.T) {
 	order.Payment = *payment
 
 	// Сохраняем в репозиторий
-	_ = repo.Save(context.Background(), order)
+	_, _ = repo.Save(context.Background(), order, domain.SaveOptions{})
 
 	// Получаем через use case
 	output, err := uc.GetByUID(context.Background(), "test123")
@@ -160,7 +383,7 @@ func TestOrderUseCase_GetByUID(t *testing.T) {
 func TestOrderUseCase_GetByUID_EmptyUID(t *testing.T) {
 	repo := NewMockRepository()
 	cache := NewMockCache()
-	uc := NewOrderUseCase(repo, cache)
+	uc := newTestOrderUseCase(repo, cache)
 
 	// Получаем с пустым UID
 	_, err := uc.GetByUID(context.Background(), "")
@@ -177,14 +400,14 @@ func TestOrderUseCase_GetByUID_EmptyUID(t *testing.T) {
 func TestOrderUseCase_RestoreCache(t *testing.T) {
 	repo := NewMockRepository()
 	cache := NewMockCache()
-	uc := NewOrderUseCase(repo, cache)
+	uc := newTestOrderUseCase(repo, cache)
 
 	// Добавляем заказы в репозиторий
 	order1, _ := domain.NewOrder("order1", "TRACK1", "WBIL")
 	order2, _ := domain.NewOrder("order2", "TRACK2", "WBIL")
 
-	_ = repo.Save(context.Background(), order1)
-	_ = repo.Save(context.Background(), order2)
+	_, _ = repo.Save(context.Background(), order1, domain.SaveOptions{})
+	_, _ = repo.Save(context.Background(), order2, domain.SaveOptions{})
 
 	// Восстанавливаем кэш
 	err := uc.RestoreCache(context.Background())
@@ -198,10 +421,115 @@ func TestOrderUseCase_RestoreCache(t *testing.T) {
 	}
 }
 
+func TestOrderUseCase_RestoreCache_StopsAtCapacity(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	cache.capacity = 5
+	uc := NewOrderUseCase(repo, cache, logger.New("error"), Config{WarmupBatchSize: 2})
+
+	// 12 заказов: order-00 самый старый, order-11 самый новый
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		order, _ := domain.NewOrder(fmt.Sprintf("order-%02d", i), "TRACK", "WBIL")
+		order.DateCreated = base.Add(time.Duration(i) * time.Minute)
+		_, _ = repo.Save(context.Background(), order, domain.SaveOptions{})
+	}
+
+	if err := uc.RestoreCache(context.Background()); err != nil {
+		t.Fatalf("RestoreCache() error = %v", err)
+	}
+
+	// Кэш заполнен ровно до лимита, и в нём самые новые заказы
+	if cache.Count() != 5 {
+		t.Fatalf("Expected 5 orders in cache, got %d", cache.Count())
+	}
+	for i := 7; i < 12; i++ {
+		uid := fmt.Sprintf("order-%02d", i)
+		if _, err := cache.Get(uid); err != nil {
+			t.Errorf("Expected %s in cache", uid)
+		}
+	}
+}
+
+// segmentedCache - кэш, в котором заполнены все сегменты, кроме части одного:
+// Count упирается в accept и до capacity не доходит
+type segmentedCache struct {
+	*MockCache
+	accept int
+}
+
+func (c *segmentedCache) LoadAll(orders []*domain.Order) error {
+	for _, order := range orders {
+		if len(c.orders) >= c.accept {
+			break
+		}
+		c.orders[order.OrderUID] = order
+	}
+	return nil
+}
+
+// listCounter - репозиторий, считающий прочитанные страницы
+type listCounter struct {
+	*MockRepository
+	pages int
+}
+
+func (r *listCounter) List(ctx context.Context, query *domain.OrderListQuery) (*domain.OrderPage, error) {
+	r.pages++
+	return r.MockRepository.List(ctx, query)
+}
+
+// Сегменты кэша заполнены раньше, чем Count дошёл до capacity: прогрев
+// останавливается на первой пачке, которая ничего не добавила, а не читает всю таблицу
+func TestOrderUseCase_RestoreCache_StopsWhenSegmentsFull(t *testing.T) {
+	repo := &listCounter{MockRepository: NewMockRepository()}
+	cache := &segmentedCache{MockCache: NewMockCache(), accept: 3}
+	cache.capacity = 10
+	uc := NewOrderUseCase(repo, cache, logger.New("error"), Config{WarmupBatchSize: 2})
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		order, _ := domain.NewOrder(fmt.Sprintf("order-%02d", i), "TRACK", "WBIL")
+		order.DateCreated = base.Add(time.Duration(i) * time.Minute)
+		_, _ = repo.Save(context.Background(), order, domain.SaveOptions{})
+	}
+
+	if err := uc.RestoreCache(context.Background()); err != nil {
+		t.Fatalf("RestoreCache() error = %v", err)
+	}
+
+	if cache.Count() != 3 {
+		t.Errorf("Expected 3 orders in cache, got %d", cache.Count())
+	}
+	if repo.pages != 3 {
+		t.Errorf("Expected warm-up to stop after 3 pages, got %d", repo.pages)
+	}
+}
+
+func TestOrderUseCase_RestoreCache_Canceled(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	uc := newTestOrderUseCase(repo, cache)
+
+	order, _ := domain.NewOrder("order1", "TRACK1", "WBIL")
+	_, _ = repo.Save(context.Background(), order, domain.SaveOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := uc.RestoreCache(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if cache.Count() != 0 {
+		t.Errorf("Expected empty cache, got %d", cache.Count())
+	}
+}
+
 func TestOrderUseCase_GetByUID_FromCache(t *testing.T) {
 	repo := NewMockRepository()
 	cache := NewMockCache()
-	uc := NewOrderUseCase(repo, cache)
+	uc := newTestOrderUseCase(repo, cache)
 
 	// Создаём заказ и сохраняем только в кэш
 	order, _ := domain.NewOrder("cached123", "TRACK456", "WBIL")
@@ -226,16 +554,16 @@ func TestOrderUseCase_GetByUID_FromCache(t *testing.T) {
 func TestOrderUseCase_GetAll(t *testing.T) {
 	repo := NewMockRepository()
 	cache := NewMockCache()
-	uc := NewOrderUseCase(repo, cache)
+	uc := newTestOrderUseCase(repo, cache)
 
 	// Добавляем заказы
 	order1, _ := domain.NewOrder("order1", "TRACK1", "WBIL")
 	order2, _ := domain.NewOrder("order2", "TRACK2", "WBIL")
 	order3, _ := domain.NewOrder("order3", "TRACK3", "WBIL")
 
-	_ = repo.Save(context.Background(), order1)
-	_ = repo.Save(context.Background(), order2)
-	_ = repo.Save(context.Background(), order3)
+	_, _ = repo.Save(context.Background(), order1, domain.SaveOptions{})
+	_, _ = repo.Save(context.Background(), order2, domain.SaveOptions{})
+	_, _ = repo.Save(context.Background(), order3, domain.SaveOptions{})
 
 	// Получаем все заказы
 	orders, err := uc.GetAll(context.Background())
@@ -248,10 +576,82 @@ func TestOrderUseCase_GetAll(t *testing.T) {
 	}
 }
 
+func TestOrderUseCase_List(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	uc := newTestOrderUseCase(repo, cache)
+
+	// 5 заказов клиента "alice" и 2 заказа клиента "bob"
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, uid := range []string{"a1", "a2", "a3", "a4", "a5"} {
+		order, _ := domain.NewOrder(uid, "TRACK", "WBIL")
+		order.CustomerID = "alice"
+		order.DateCreated = base.Add(time.Duration(i) * time.Hour)
+		_, _ = repo.Save(context.Background(), order, domain.SaveOptions{})
+	}
+	for _, uid := range []string{"b1", "b2"} {
+		order, _ := domain.NewOrder(uid, "TRACK", "WBIL")
+		order.CustomerID = "bob"
+		order.DateCreated = base
+		_, _ = repo.Save(context.Background(), order, domain.SaveOptions{})
+	}
+
+	// Листаем страницы по 2 заказа, пока курсор не закончится
+	var got []string
+	input := &dto.ListOrdersInput{Limit: 2, CustomerID: "alice"}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("List() returned too many pages")
+		}
+
+		page, err := uc.List(context.Background(), input)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		for _, order := range page.Orders {
+			got = append(got, order.OrderUID)
+		}
+		if !page.HasMore {
+			break
+		}
+		input.Cursor = page.NextCursor
+	}
+
+	want := []string{"a5", "a4", "a3", "a2", "a1"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestOrderUseCase_List_InvalidInput(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	uc := newTestOrderUseCase(repo, cache)
+
+	// Невалидный курсор
+	_, err := uc.List(context.Background(), &dto.ListOrdersInput{Cursor: "not-a-cursor"})
+	if !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+
+	// Перевёрнутый диапазон дат
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = uc.List(context.Background(), &dto.ListOrdersInput{DateFrom: &from, DateTo: &to})
+	if !errors.Is(err, domain.ErrInvalidDateRange) {
+		t.Errorf("Expected ErrInvalidDateRange, got %v", err)
+	}
+}
+
 func TestOrderUseCase_Create(t *testing.T) {
 	repo := NewMockRepository()
 	cache := NewMockCache()
-	uc := NewOrderUseCase(repo, cache)
+	uc := newTestOrderUseCase(repo, cache)
 
 	// Создаём входной DTO
 	input := &dto.CreateOrderInput{
@@ -268,13 +668,13 @@ func TestOrderUseCase_Create(t *testing.T) {
 			Email:   "test@test.com",
 		},
 		Payment: dto.PaymentInput{
-			Transaction:  "TX-NEW",
+			Transaction:  "new-order-123",
 			Currency:     "USD",
 			Provider:     "PayPal",
-			Amount:       1000,
+			Amount:       550,
 			Bank:         "Sberbank",
 			DeliveryCost: 100,
-			GoodsTotal:   900,
+			GoodsTotal:   450,
 		},
 		Items: []dto.ItemInput{
 			{
@@ -298,10 +698,13 @@ func TestOrderUseCase_Create(t *testing.T) {
 	}
 
 	// Создаём заказ
-	err := uc.Create(context.Background(), input)
+	outcome, err := uc.Create(context.Background(), input, natsOrigin)
 	if err != nil {
 		t.Fatalf("Create() error = %v", err)
 	}
+	if outcome != domain.SaveCreated {
+		t.Errorf("Expected outcome %q, got %q", domain.SaveCreated, outcome)
+	}
 
 	// Проверяем, что заказ в репозитории
 	if len(repo.orders) != 1 {
@@ -314,10 +717,387 @@ func TestOrderUseCase_Create(t *testing.T) {
 	}
 }
 
+func TestOrderUseCase_Create_UpsertPolicy(t *testing.T) {
+	payload := func(entry string) *dto.CreateOrderInput {
+		var input dto.CreateOrderInput
+		_ = json.Unmarshal(validOrderPayload(t, "dup-order"), &input)
+		input.Entry = entry
+		return &input
+	}
+
+	tests := []struct {
+		policy domain.UpsertPolicy
+		want   domain.SaveOutcome
+		entry  string
+	}{
+		{domain.UpsertReplace, domain.SaveUpdated, "SECOND"},
+		{domain.UpsertReject, domain.SaveIgnored, "FIRST"},
+		{domain.UpsertNewer, domain.SaveIgnored, "FIRST"}, // Тот же номер сообщения - не новее
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			repo := NewMockRepository()
+			cache := NewMockCache()
+			uc := NewOrderUseCase(repo, cache, logger.New("error"), Config{UpsertPolicy: tt.policy})
+
+			if outcome, err := uc.Create(context.Background(), payload("FIRST"), natsOrigin); err != nil || outcome != domain.SaveCreated {
+				t.Fatalf("First Create() = %q, %v", outcome, err)
+			}
+			outcome, err := uc.Create(context.Background(), payload("SECOND"), natsOrigin)
+			if err != nil {
+				t.Fatalf("Second Create() error = %v", err)
+			}
+			if outcome != tt.want {
+				t.Errorf("Expected outcome %q, got %q", tt.want, outcome)
+			}
+
+			// Кэш и БД согласованы: отброшенный дубликат не попадает в кэш
+			if repo.orders["dup-order"].Entry != tt.entry {
+				t.Errorf("Expected stored entry %q, got %q", tt.entry, repo.orders["dup-order"].Entry)
+			}
+			cached, _ := cache.Get("dup-order")
+			if cached == nil || cached.Entry != tt.entry {
+				t.Errorf("Expected cached entry %q, got %+v", tt.entry, cached)
+			}
+		})
+	}
+}
+
+func TestOrderUseCase_Update(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	uc := newTestOrderUseCase(repo, cache)
+
+	var input dto.CreateOrderInput
+	_ = json.Unmarshal(validOrderPayload(t, "versioned"), &input)
+	if _, err := uc.Create(context.Background(), &input, natsOrigin); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Клиент прочитал версию 1 и обновляет заказ
+	input.Entry = "ADMIN"
+	updated, err := uc.Update(context.Background(), "versioned", &input, 1)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Version != 2 || updated.Entry != "ADMIN" {
+		t.Errorf("Expected entry ADMIN at version 2, got %q at %d", updated.Entry, updated.Version)
+	}
+	if cached, _ := cache.Get("versioned"); cached == nil || cached.Version != 2 {
+		t.Errorf("Expected cache to hold version 2, got %+v", cached)
+	}
+
+	// Второй клиент с устаревшей версией не затирает изменения
+	input.Entry = "STALE"
+	_, err = uc.Update(context.Background(), "versioned", &input, 1)
+	if !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, got %v", err)
+	}
+	if repo.orders["versioned"].Entry != "ADMIN" {
+		t.Errorf("Stale update must not be saved, got entry %q", repo.orders["versioned"].Entry)
+	}
+
+	_, err = uc.Update(context.Background(), "missing", &dto.CreateOrderInput{}, 1)
+	if !errors.Is(err, domain.ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder for an empty payload, got %v", err)
+	}
+
+	input.OrderUID = "other"
+	_, err = uc.Update(context.Background(), "versioned", &input, 2)
+	if !errors.Is(err, domain.ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder for mismatched order_uid, got %v", err)
+	}
+}
+
+// Повторно доставленное сообщение NATS не перезаписывает заказ
+func TestOrderUseCase_Create_Redelivery(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	uc := newTestOrderUseCase(repo, cache)
+
+	origin := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders", Sequence: 42}
+	var input dto.CreateOrderInput
+	_ = json.Unmarshal(validOrderPayload(t, "redelivered"), &input)
+
+	if outcome, err := uc.Create(context.Background(), &input, origin); err != nil || outcome != domain.SaveCreated {
+		t.Fatalf("First Create() = %q, %v", outcome, err)
+	}
+	_ = cache.Delete("redelivered")
+
+	outcome, err := uc.Create(context.Background(), &input, origin)
+	if err != nil || outcome != domain.SaveDuplicate {
+		t.Fatalf("Redelivered Create() = %q, %v; want %q", outcome, err, domain.SaveDuplicate)
+	}
+	if repo.orders["redelivered"].Version != 1 || len(repo.history) != 1 {
+		t.Errorf("Redelivery must not save the order again")
+	}
+	if cache.Count() != 0 {
+		t.Errorf("Redelivery must not touch the cache")
+	}
+
+	// Другое сообщение с тем же заказом обрабатывается как обычно
+	origin.Sequence = 43
+	if outcome, err := uc.Create(context.Background(), &input, origin); err != nil || outcome != domain.SaveUpdated {
+		t.Errorf("Next message Create() = %q, %v; want %q", outcome, err, domain.SaveUpdated)
+	}
+}
+
+// Пакет сохраняется за один вызов репозитория, ошибки отдельных заказов не мешают остальным
+func TestOrderUseCase_CreateBatch(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	uc := newTestOrderUseCase(repo, cache)
+
+	input := func(uid string) *dto.CreateOrderInput {
+		var input dto.CreateOrderInput
+		_ = json.Unmarshal(validOrderPayload(t, uid), &input)
+		return &input
+	}
+	origin := func(seq uint64) domain.ChangeOrigin {
+		return domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders", Sequence: seq}
+	}
+
+	dbDown := errors.New("db is down")
+	repo.saveErrs = map[string]error{"broken": dbDown}
+
+	results := uc.CreateBatch(context.Background(), []CreateRequest{
+		{Input: input("first"), Origin: origin(1)},
+		{Input: &dto.CreateOrderInput{OrderUID: "invalid"}, Origin: origin(2)},
+		{Input: input("broken"), Origin: origin(3)},
+		{Input: input("first"), Origin: origin(4)},
+		{Input: input("first"), Origin: origin(4)},
+	})
+
+	want := []domain.SaveOutcome{domain.SaveCreated, "", "", domain.SaveUpdated, domain.SaveDuplicate}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(results))
+	}
+	for i, res := range results {
+		if res.Outcome != want[i] {
+			t.Errorf("Result %d outcome = %q, want %q", i, res.Outcome, want[i])
+		}
+	}
+	if !errors.Is(results[1].Err, domain.ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder for the invalid order, got %v", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, dbDown) {
+		t.Errorf("Expected the repository error for the broken order, got %v", results[2].Err)
+	}
+	if repo.orders["first"].Version != 2 {
+		t.Errorf("Expected version 2 after create and update, got %d", repo.orders["first"].Version)
+	}
+	if cache.Count() != 1 {
+		t.Errorf("Expected only the saved order to be cached, got %d", cache.Count())
+	}
+
+	// Ошибка всего пакета достаётся каждому валидному заказу
+	repo.err = dbDown
+	results = uc.CreateBatch(context.Background(), []CreateRequest{{Input: input("second"), Origin: origin(5)}})
+	if !errors.Is(results[0].Err, dbDown) {
+		t.Errorf("Expected the batch error, got %v", results[0].Err)
+	}
+}
+
+// История содержит каждое создание, изменение и удаление с источником
+func TestOrderUseCase_History(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	uc := newTestOrderUseCase(repo, cache)
+
+	if _, err := uc.History(context.Background(), "audited"); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound before the order exists, got %v", err)
+	}
+
+	var input dto.CreateOrderInput
+	_ = json.Unmarshal(validOrderPayload(t, "audited"), &input)
+	if _, err := uc.Create(context.Background(), &input, natsOrigin); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	input.Entry = "ADMIN"
+	if _, err := uc.Update(context.Background(), "audited", &input, 1); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := uc.Delete(context.Background(), "audited", 1); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict for a stale version, got %v", err)
+	}
+	if err := uc.Delete(context.Background(), "audited", 2); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if cached, _ := cache.Get("audited"); cached != nil {
+		t.Errorf("Deleted order must be evicted from cache")
+	}
+
+	// История переживает удаление заказа
+	history, err := uc.History(context.Background(), "audited")
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+
+	want := []struct {
+		action   string
+		source   string
+		sequence uint64
+		version  int64
+	}{
+		{"created", "nats", 7, 1},
+		{"updated", "admin", 0, 2},
+		{"deleted", "admin", 0, 2},
+	}
+	if len(history.Changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %d", len(want), len(history.Changes))
+	}
+	for i, w := range want {
+		got := history.Changes[i]
+		if got.Action != w.action || got.Source != w.source || got.Sequence != w.sequence || got.Version != w.version {
+			t.Errorf("changes[%d] = %s/%s/%d/v%d, want %s/%s/%d/v%d", i,
+				got.Action, got.Source, got.Sequence, got.Version, w.action, w.source, w.sequence, w.version)
+		}
+	}
+
+	var snapshot domain.Order
+	if err := json.Unmarshal(history.Changes[1].Snapshot, &snapshot); err != nil || snapshot.Entry != "ADMIN" {
+		t.Errorf("Expected update snapshot with entry ADMIN, got %q (%v)", snapshot.Entry, err)
+	}
+
+	if err := uc.Delete(context.Background(), "audited", 2); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound on second delete, got %v", err)
+	}
+}
+
+// Статус меняется только по таблице переходов; каждый переход - новая версия заказа
+func TestOrderUseCase_ChangeStatus(t *testing.T) {
+	repo := NewMockRepository()
+	cache := NewMockCache()
+	uc := newTestOrderUseCase(repo, cache)
+	ctx := context.Background()
+	admin := domain.ChangeOrigin{Source: domain.ChangeSourceAdmin}
+
+	var input dto.CreateOrderInput
+	_ = json.Unmarshal(validOrderPayload(t, "lifecycle"), &input)
+	if _, err := uc.Create(ctx, &input, natsOrigin); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	order, err := uc.ChangeStatus(ctx, &dto.ChangeStatusInput{OrderUID: "lifecycle", Status: "paid", Reason: "payment confirmed"}, 1, admin)
+	if err != nil {
+		t.Fatalf("ChangeStatus(paid) error = %v", err)
+	}
+	if order.Status != "paid" || order.Version != 2 {
+		t.Errorf("Expected paid at version 2, got %q at %d", order.Status, order.Version)
+	}
+	if cached, _ := cache.Get("lifecycle"); cached == nil || cached.Status != domain.StatusPaid {
+		t.Errorf("Expected cache to hold the paid order, got %+v", cached)
+	}
+
+	// Тот же статус ещё раз - не ошибка и не новая версия
+	order, err = uc.ChangeStatus(ctx, &dto.ChangeStatusInput{OrderUID: "lifecycle", Status: "paid"}, 0, admin)
+	if err != nil || order.Version != 2 {
+		t.Errorf("Repeated ChangeStatus(paid) = version %v, %v; want version 2", order, err)
+	}
+
+	// Повторно доставленное сообщение NATS не применяется второй раз
+	origin := domain.ChangeOrigin{Source: domain.ChangeSourceNATS, Subject: "orders.status", Sequence: 7}
+	shipped := &dto.ChangeStatusInput{OrderUID: "lifecycle", Status: "assembling"}
+	if _, err := uc.ChangeStatus(ctx, shipped, 0, origin); err != nil {
+		t.Fatalf("ChangeStatus(assembling) error = %v", err)
+	}
+	if order, err := uc.ChangeStatus(ctx, shipped, 0, origin); err != nil || order.Version != 3 {
+		t.Errorf("Redelivered ChangeStatus = %+v, %v; want version 3", order, err)
+	}
+
+	tests := []struct {
+		name            string
+		input           dto.ChangeStatusInput
+		expectedVersion int64
+		wantErr         error
+	}{
+		{"not allowed", dto.ChangeStatusInput{OrderUID: "lifecycle", Status: "delivered"}, 0, domain.ErrInvalidStatusTransition},
+		{"back to created", dto.ChangeStatusInput{OrderUID: "lifecycle", Status: "created"}, 0, domain.ErrInvalidStatusTransition},
+		{"unknown status", dto.ChangeStatusInput{OrderUID: "lifecycle", Status: "lost"}, 0, domain.ErrInvalidOrderStatus},
+		{"stale version", dto.ChangeStatusInput{OrderUID: "lifecycle", Status: "shipped"}, 1, domain.ErrVersionConflict},
+		{"missing order", dto.ChangeStatusInput{OrderUID: "missing", Status: "paid"}, 0, domain.ErrOrderNotFound},
+		{"empty uid", dto.ChangeStatusInput{Status: "paid"}, 0, domain.ErrEmptyOrderUID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.ChangeStatus(ctx, &tt.input, tt.expectedVersion, admin)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ChangeStatus() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if stored := repo.orders["lifecycle"]; stored.Status != domain.StatusAssembling || stored.Version != 3 {
+		t.Errorf("Rejected changes must not be saved, got %q at version %d", stored.Status, stored.Version)
+	}
+
+	// Замена заказа статус не сбрасывает
+	input.Entry = "ADMIN"
+	updated, err := uc.Update(ctx, "lifecycle", &input, 3)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Status != "assembling" {
+		t.Errorf("Expected Update to keep status assembling, got %q", updated.Status)
+	}
+}
+
+func TestOrderUseCase_StatusHistory(t *testing.T) {
+	repo := NewMockRepository()
+	uc := newTestOrderUseCase(repo, NewMockCache())
+	ctx := context.Background()
+	admin := domain.ChangeOrigin{Source: domain.ChangeSourceAdmin}
+
+	var input dto.CreateOrderInput
+	_ = json.Unmarshal(validOrderPayload(t, "tracked"), &input)
+	if _, err := uc.Create(ctx, &input, natsOrigin); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, status := range []string{"paid", "cancelled"} {
+		if _, err := uc.ChangeStatus(ctx, &dto.ChangeStatusInput{OrderUID: "tracked", Status: status}, 0, admin); err != nil {
+			t.Fatalf("ChangeStatus(%s) error = %v", status, err)
+		}
+	}
+
+	status, err := uc.StatusHistory(ctx, "tracked")
+	if err != nil {
+		t.Fatalf("StatusHistory() error = %v", err)
+	}
+	if status.Status != "cancelled" || len(status.Next) != 0 {
+		t.Errorf("Expected final status cancelled, got %q (next %v)", status.Status, status.Next)
+	}
+	if len(status.Transitions) != 2 || status.Transitions[0].From != "created" || status.Transitions[1].To != "cancelled" {
+		t.Errorf("Unexpected transitions: %+v", status.Transitions)
+	}
+
+	// Смена статуса попадает и в общую историю заказа
+	history, err := uc.History(ctx, "tracked")
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if last := history.Changes[len(history.Changes)-1]; last.Action != string(domain.ChangeStatusChanged) || last.Version != 3 {
+		t.Errorf("Expected status_changed at version 3 in order history, got %+v", last)
+	}
+}
+
+// Невалидный заказ помечается ErrInvalidOrder, чтобы его не обрабатывали повторно
+func TestOrderUseCase_Create_InvalidOrder(t *testing.T) {
+	repo := NewMockRepository()
+	uc := newTestOrderUseCase(repo, NewMockCache())
+
+	_, err := uc.Create(context.Background(), &dto.CreateOrderInput{OrderUID: "bad-order"}, natsOrigin)
+	if !errors.Is(err, domain.ErrInvalidOrder) {
+		t.Errorf("Expected ErrInvalidOrder, got %v", err)
+	}
+	if len(repo.orders) != 0 {
+		t.Errorf("Invalid order must not be saved, got %d orders", len(repo.orders))
+	}
+}
+
 func TestOrderUseCase_GetCacheStats(t *testing.T) {
 	repo := NewMockRepository()
 	cache := NewMockCache()
-	uc := NewOrderUseCase(repo, cache)
+	uc := newTestOrderUseCase(repo, cache)
 
 	// Добавляем заказы в кэш
 	order1, _ := domain.NewOrder("order1", "TRACK1", "WBIL")
@@ -326,10 +1106,61 @@ func TestOrderUseCase_GetCacheStats(t *testing.T) {
 	_ = cache.Set("order1", order1)
 	_ = cache.Set("order2", order2)
 
+	// Три попадания и один промах (заказа нет ни в кэше, ни в БД)
+	for _, uid := range []string{"order1", "order2", "order1", "missing"} {
+		_, _ = uc.GetByUID(context.Background(), uid)
+	}
+
 	// Получаем статистику
 	stats := uc.GetCacheStats()
 
 	if stats["cached_orders"] != 2 {
 		t.Errorf("Expected cached_orders = 2, got %v", stats["cached_orders"])
 	}
+	if stats["capacity"] != 1000 {
+		t.Errorf("Expected capacity = 1000, got %v", stats["capacity"])
+	}
+	if stats["hits"] != uint64(3) || stats["misses"] != uint64(1) {
+		t.Errorf("Expected 3 hits and 1 miss, got %v and %v", stats["hits"], stats["misses"])
+	}
+	if stats["hit_ratio"] != 0.75 {
+		t.Errorf("Expected hit_ratio = 0.75, got %v", stats["hit_ratio"])
+	}
+}
+
+// В lenient режиме заказ с несогласованными суммами сохраняется, остальные правила действуют
+func TestOrderUseCase_Create_ValidationMode(t *testing.T) {
+	input := func(uid string) *dto.CreateOrderInput {
+		var input dto.CreateOrderInput
+		_ = json.Unmarshal(validOrderPayload(t, uid), &input)
+		input.Payment.Amount = 150 // Не равна goods_total + delivery_cost + custom_fee
+		return &input
+	}
+
+	strict := NewOrderUseCase(NewMockRepository(), NewMockCache(), logger.New("error"), Config{ValidationMode: domain.ValidationStrict})
+	_, err := strict.Create(context.Background(), input("totals"), natsOrigin)
+	if !errors.Is(err, domain.ErrInvalidOrder) || !errors.Is(err, domain.ErrPaymentAmountMismatch) {
+		t.Errorf("Expected amount mismatch in strict mode, got %v", err)
+	}
+	if v := domain.Violations(err); len(v) != 1 || v[0].Field != "payment.amount" {
+		t.Errorf("Expected a payment.amount violation, got %+v", v)
+	}
+
+	lenient := NewOrderUseCase(NewMockRepository(), NewMockCache(), logger.New("error"), Config{ValidationMode: domain.ValidationLenient})
+	if outcome, err := lenient.Create(context.Background(), input("totals"), natsOrigin); err != nil || outcome != domain.SaveCreated {
+		t.Errorf("Lenient Create() = %q, %v; want %q", outcome, err, domain.SaveCreated)
+	}
+
+	// Без режима - domain.DefaultValidationMode, как у ORDER_VALIDATION_MODE
+	byDefault := newTestOrderUseCase(NewMockRepository(), NewMockCache())
+	if outcome, err := byDefault.Create(context.Background(), input("totals"), natsOrigin); err != nil || outcome != domain.SaveCreated {
+		t.Errorf("Default Create() = %q, %v; want %q", outcome, err, domain.SaveCreated)
+	}
+
+	mismatched := input("mismatched")
+	mismatched.Payment.Transaction = "other"
+	_, err = lenient.Create(context.Background(), mismatched, natsOrigin)
+	if !errors.Is(err, domain.ErrTransactionMismatch) {
+		t.Errorf("Expected transaction mismatch in lenient mode, got %v", err)
+	}
 }
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/pkg/logger"
+)
+
+const (
+	// DefaultOutboxBatchSize - сколько событий публикуется за один проход по умолчанию
+	DefaultOutboxBatchSize = 100
+	// DefaultOutboxPollInterval - пауза между проходами, когда публиковать нечего
+	DefaultOutboxPollInterval = time.Second
+)
+
+// OutboxConfig - настройки OutboxRelay
+type OutboxConfig struct {
+	BatchSize    int           // Сколько событий публиковать за один проход
+	PollInterval time.Duration // Пауза между проходами, если outbox пуст или брокер недоступен
+}
+
+// OutboxRelay публикует события о заказах из outbox в брокер.
+// Событие считается опубликованным только после успешной отправки, поэтому
+// доставка - не реже одного раза (at-least-once); subject равен типу события
+type OutboxRelay struct {
+	repo      OutboxRepository
+	publisher EventPublisher
+	log       logger.Logger
+	cfg       OutboxConfig
+}
+
+// NewOutboxRelay создаёт новый экземпляр OutboxRelay
+func NewOutboxRelay(repo OutboxRepository, publisher EventPublisher, log logger.Logger, cfg OutboxConfig) *OutboxRelay {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultOutboxBatchSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultOutboxPollInterval
+	}
+
+	return &OutboxRelay{
+		repo:      repo,
+		publisher: publisher,
+		log:       log,
+		cfg:       cfg,
+	}
+}
+
+// RelayOnce публикует одну пачку событий и возвращает число опубликованных
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	return r.repo.ProcessPending(ctx, r.cfg.BatchSize, func(event *domain.OutboxEvent) error {
+		return r.publisher.PublishBytes(string(event.Type), event.Payload)
+	})
+}
+
+// Run публикует события, пока не отменён ctx. Полная пачка означает, что в outbox
+// могут остаться события - следующий проход начинается сразу, без паузы
+func (r *OutboxRelay) Run(ctx context.Context) {
+	r.log.Info("Outbox relay started")
+
+	for {
+		published, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.log.Warn("Outbox relay: %d events published, then failed: %v", published, err)
+		} else if published > 0 {
+			r.log.Debug("Outbox relay: %d events published", published)
+		}
+
+		if err == nil && published == r.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			r.log.Info("Outbox relay stopped")
+			return
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"RWB_L0/internal/domain"
+	"RWB_L0/pkg/logger"
+)
+
+// fakeOutbox - outbox в памяти с той же семантикой, что и postgres.OutboxRepository
+type fakeOutbox struct {
+	mu        sync.Mutex
+	events    []*domain.OutboxEvent
+	published map[int64]bool
+}
+
+func newFakeOutbox() *fakeOutbox {
+	return &fakeOutbox{published: make(map[int64]bool)}
+}
+
+func (o *fakeOutbox) add(eventType domain.OrderEventType, orderUID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	payload, _ := json.Marshal(domain.OrderEvent{Type: eventType, OrderUID: orderUID})
+	o.events = append(o.events, &domain.OutboxEvent{
+		ID:       int64(len(o.events) + 1),
+		Type:     eventType,
+		OrderUID: orderUID,
+		Payload:  payload,
+	})
+}
+
+func (o *fakeOutbox) ProcessPending(ctx context.Context, limit int, publish func(*domain.OutboxEvent) error) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range o.events {
+		if published == limit {
+			break
+		}
+		if o.published[event.ID] {
+			continue
+		}
+		if err := publish(event); err != nil {
+			event.Attempts++
+			return published, err
+		}
+		o.published[event.ID] = true
+		published++
+	}
+	return published, nil
+}
+
+// fakeBroker - брокер в памяти: запоминает сообщения и умеет отказывать
+type fakeBroker struct {
+	mu       sync.Mutex
+	messages []brokerMessage
+	failures map[string]int // order_uid -> сколько раз отказать
+}
+
+type brokerMessage struct {
+	subject string
+	event   domain.OrderEvent
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{failures: make(map[string]int)}
+}
+
+func (b *fakeBroker) PublishBytes(subject string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var event domain.OrderEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	if b.failures[event.OrderUID] > 0 {
+		b.failures[event.OrderUID]--
+		return errors.New("broker unavailable")
+	}
+	b.messages = append(b.messages, brokerMessage{subject: subject, event: event})
+	return nil
+}
+
+func (b *fakeBroker) received() []brokerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]brokerMessage(nil), b.messages...)
+}
+
+func TestOutboxRelay_RelayOnce(t *testing.T) {
+	outbox := newFakeOutbox()
+	outbox.add(domain.OrderEventCreated, "a")
+	outbox.add(domain.OrderEventCreated, "b")
+	outbox.add(domain.OrderEventUpdated, "a")
+
+	broker := newFakeBroker()
+	broker.failures["b"] = 1
+	relay := NewOutboxRelay(outbox, broker, logger.New("error"), OutboxConfig{})
+
+	// Брокер отказал на втором событии: третье не обгоняет его
+	published, err := relay.RelayOnce(context.Background())
+	if err == nil || published != 1 {
+		t.Fatalf("RelayOnce() = %d, %v; want 1 and an error", published, err)
+	}
+	if outbox.events[1].Attempts != 1 {
+		t.Errorf("Expected failed event to count an attempt, got %d", outbox.events[1].Attempts)
+	}
+
+	published, err = relay.RelayOnce(context.Background())
+	if err != nil || published != 2 {
+		t.Fatalf("RelayOnce() = %d, %v; want 2, nil", published, err)
+	}
+
+	want := []struct {
+		subject  string
+		orderUID string
+	}{
+		{"order.created", "a"},
+		{"order.created", "b"},
+		{"order.updated", "a"},
+	}
+	messages := broker.received()
+	if len(messages) != len(want) {
+		t.Fatalf("Expected %d messages, got %d", len(want), len(messages))
+	}
+	for i, w := range want {
+		if messages[i].subject != w.subject || messages[i].event.OrderUID != w.orderUID {
+			t.Errorf("messages[%d] = %s/%s, want %s/%s", i,
+				messages[i].subject, messages[i].event.OrderUID, w.subject, w.orderUID)
+		}
+	}
+
+	// Опубликованные события не отправляются повторно
+	if published, err := relay.RelayOnce(context.Background()); err != nil || published != 0 {
+		t.Errorf("RelayOnce() on drained outbox = %d, %v", published, err)
+	}
+}
+
+func TestOutboxRelay_Run(t *testing.T) {
+	outbox := newFakeOutbox()
+	for i := 0; i < 5; i++ {
+		outbox.add(domain.OrderEventCreated, string(rune('a'+i)))
+	}
+
+	broker := newFakeBroker()
+	relay := NewOutboxRelay(outbox, broker, logger.New("error"), OutboxConfig{
+		BatchSize:    2,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for len(broker.received()) < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := len(broker.received()); got != 5 {
+		t.Errorf("Expected all 5 events published, got %d", got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not stop after context cancellation")
+	}
+}
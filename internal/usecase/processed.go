@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"RWB_L0/pkg/logger"
+)
+
+const (
+	// DefaultProcessedCleanupBatchSize - сколько отметок удаляется одним запросом
+	DefaultProcessedCleanupBatchSize = 1000
+	// DefaultProcessedCleanupInterval - пауза между очистками отметок
+	DefaultProcessedCleanupInterval = time.Hour
+)
+
+// ProcessedCleanerConfig - настройки ProcessedCleaner
+type ProcessedCleanerConfig struct {
+	Retention time.Duration // Сколько хранить отметку (не меньше срока хранения сообщений в stream)
+	BatchSize int           // Сколько отметок удалять одним запросом
+	Interval  time.Duration // Пауза между очистками
+}
+
+// ProcessedCleaner удаляет старые отметки об обработанных сообщениях NATS.
+// Сообщение старше срока хранения stream уже удалено сервером и повторно
+// не доставляется, поэтому его отметка больше не нужна
+type ProcessedCleaner struct {
+	repo ProcessedMessageRepository
+	log  logger.Logger
+	cfg  ProcessedCleanerConfig
+}
+
+// NewProcessedCleaner создаёт новый экземпляр ProcessedCleaner
+func NewProcessedCleaner(repo ProcessedMessageRepository, log logger.Logger, cfg ProcessedCleanerConfig) *ProcessedCleaner {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultProcessedCleanupBatchSize
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultProcessedCleanupInterval
+	}
+
+	return &ProcessedCleaner{
+		repo: repo,
+		log:  log,
+		cfg:  cfg,
+	}
+}
+
+// CleanOnce удаляет все отметки старше Retention пачками по BatchSize
+// и возвращает число удалённых
+func (c *ProcessedCleaner) CleanOnce(ctx context.Context) (int64, error) {
+	var total int64
+	for {
+		deleted, err := c.repo.DeleteProcessedOlderThan(ctx, c.cfg.Retention, c.cfg.BatchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < int64(c.cfg.BatchSize) {
+			return total, nil
+		}
+	}
+}
+
+// Run удаляет старые отметки раз в Interval, пока не отменён ctx
+func (c *ProcessedCleaner) Run(ctx context.Context) {
+	c.log.Info("Processed messages cleaner started (retention %s)", c.cfg.Retention)
+
+	for {
+		deleted, err := c.CleanOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			c.log.Warn("Processed messages cleaner: %d deleted, then failed: %v", deleted, err)
+		} else if deleted > 0 {
+			c.log.Debug("Processed messages cleaner: %d deleted", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			c.log.Info("Processed messages cleaner stopped")
+			return
+		case <-time.After(c.cfg.Interval):
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"RWB_L0/pkg/logger"
+)
+
+// fakeProcessed - отметки в памяти: удаляет не больше limit за вызов
+type fakeProcessed struct {
+	old   int
+	calls int
+	age   time.Duration
+	err   error
+}
+
+func (p *fakeProcessed) DeleteProcessedOlderThan(_ context.Context, age time.Duration, limit int) (int64, error) {
+	p.calls++
+	p.age = age
+	if p.err != nil {
+		return 0, p.err
+	}
+	deleted := min(p.old, limit)
+	p.old -= deleted
+	return int64(deleted), nil
+}
+
+// CleanOnce удаляет пачками, пока пачка не окажется неполной
+func TestProcessedCleaner_CleanOnce(t *testing.T) {
+	repo := &fakeProcessed{old: 25}
+	cleaner := NewProcessedCleaner(repo, logger.New("error"), ProcessedCleanerConfig{Retention: 24 * time.Hour, BatchSize: 10})
+
+	deleted, err := cleaner.CleanOnce(context.Background())
+	if err != nil {
+		t.Fatalf("CleanOnce() error = %v", err)
+	}
+	if deleted != 25 || repo.old != 0 || repo.calls != 3 {
+		t.Errorf("Expected 25 deleted in 3 batches, got %d in %d (%d left)", deleted, repo.calls, repo.old)
+	}
+	if repo.age != 24*time.Hour {
+		t.Errorf("Expected retention to be passed to the repository, got %s", repo.age)
+	}
+}
+
+func TestProcessedCleaner_CleanOnce_Error(t *testing.T) {
+	failed := errors.New("db is down")
+	cleaner := NewProcessedCleaner(&fakeProcessed{old: 5, err: failed}, logger.New("error"), ProcessedCleanerConfig{Retention: time.Hour})
+
+	if _, err := cleaner.CleanOnce(context.Background()); !errors.Is(err, failed) {
+		t.Errorf("Expected repository error, got %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+-- Индексы для постраничной выборки заказов (GET /api/v1/orders)
+
+-- Keyset-пагинация: ORDER BY date_created DESC, order_uid DESC
+CREATE INDEX IF NOT EXISTS idx_orders_date_created_uid ON orders(date_created DESC, order_uid DESC);
+
+-- Фильтры
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_service ON orders(delivery_service);
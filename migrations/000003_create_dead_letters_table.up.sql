@@ -0,0 +1,16 @@
+-- Сообщения NATS, которые не удалось обработать (dead letters)
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id          BIGSERIAL PRIMARY KEY,
+    subject     VARCHAR(255) NOT NULL,
+    sequence    BIGINT NOT NULL,
+    payload     BYTEA NOT NULL,
+    error       TEXT NOT NULL,
+    permanent   BOOLEAN NOT NULL DEFAULT FALSE,
+    attempts    INT NOT NULL DEFAULT 1,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    -- Повторная доставка того же сообщения не создаёт дубликат
+    CONSTRAINT uq_dead_letters_subject_sequence UNIQUE (subject, sequence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_created_at ON dead_letters(created_at DESC);
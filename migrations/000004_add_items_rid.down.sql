@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_items_rid;
+
+ALTER TABLE items DROP COLUMN IF EXISTS rid;
@@ -0,0 +1,4 @@
+-- rid товара (приходит в сообщении, раньше не сохранялся)
+ALTER TABLE items ADD COLUMN IF NOT EXISTS rid VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_items_rid ON items(rid);
@@ -0,0 +1,2 @@
+-- Версия заказа для оптимистичной блокировки (compare-and-swap при обновлении)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
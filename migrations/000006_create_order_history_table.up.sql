@@ -0,0 +1,14 @@
+-- История изменений заказов: строки только добавляются.
+-- Внешнего ключа на orders нет - история переживает удаление заказа
+CREATE TABLE IF NOT EXISTS order_history (
+    id          BIGSERIAL PRIMARY KEY,
+    order_uid   VARCHAR(255) NOT NULL,
+    action      VARCHAR(16) NOT NULL,
+    source      VARCHAR(16) NOT NULL,
+    sequence    BIGINT,
+    version     BIGINT NOT NULL,
+    snapshot    JSONB NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_history_order_uid ON order_history(order_uid, id);
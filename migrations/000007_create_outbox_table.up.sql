@@ -0,0 +1,15 @@
+-- Transactional outbox: события о заказах пишутся в одной транзакции с заказом,
+-- фоновый relay публикует их в NATS и отмечает published_at
+CREATE TABLE IF NOT EXISTS outbox (
+    id            BIGSERIAL PRIMARY KEY,
+    event_type    VARCHAR(64) NOT NULL,
+    order_uid     VARCHAR(255) NOT NULL,
+    payload       JSONB NOT NULL,
+    attempts      INT NOT NULL DEFAULT 0,
+    last_error    TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    published_at  TIMESTAMP
+);
+
+-- Relay выбирает только неопубликованные события в порядке id
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(id) WHERE published_at IS NULL;
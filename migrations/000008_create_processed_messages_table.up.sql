@@ -0,0 +1,9 @@
+-- Обработанные NATS сообщения: отметка пишется в транзакции сохранения заказа,
+-- поэтому повторная доставка после commit, но до ack, ничего не перезаписывает
+CREATE TABLE IF NOT EXISTS processed_messages (
+    subject       VARCHAR(255) NOT NULL,
+    sequence      BIGINT NOT NULL,
+    processed_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (subject, sequence)
+);
@@ -0,0 +1,7 @@
+-- Удалённые отметки не восстанавливаются; dead letters NATS Streaming возвращаются
+-- из архива, кроме совпавших по номеру с dead letters JetStream
+INSERT INTO dead_letters (subject, sequence, payload, error, permanent, attempts, created_at)
+SELECT subject, sequence, payload, error, permanent, attempts, created_at FROM dead_letters_stan
+ON CONFLICT (subject, sequence) DO NOTHING;
+
+DROP TABLE IF EXISTS dead_letters_stan;
@@ -0,0 +1,10 @@
+-- Переход с NATS Streaming на JetStream: номера сообщений JetStream (stream sequence)
+-- начинаются заново и совпали бы с номерами NATS Streaming - новые сообщения
+-- считались бы повторной доставкой. Старые сообщения больше не доставляются, отметки не нужны
+DELETE FROM processed_messages;
+
+-- Dead letters тоже уникальны по (subject, sequence): новый dead letter с тем же номером
+-- обновил бы старый и при повторной отправке ушёл бы старый заказ. Dead letters
+-- NATS Streaming переносятся в архив, admin API их больше не показывает
+CREATE TABLE IF NOT EXISTS dead_letters_stan AS SELECT * FROM dead_letters;
+DELETE FROM dead_letters;
@@ -0,0 +1 @@
+ALTER TABLE dead_letters DROP COLUMN IF EXISTS violations;
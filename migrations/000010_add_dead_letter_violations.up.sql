@@ -0,0 +1,3 @@
+-- Нарушения правил заказа из ValidationError: поле, код и сообщение каждого.
+-- NULL - ошибка не связана с проверкой заказа
+ALTER TABLE dead_letters ADD COLUMN IF NOT EXISTS violations JSONB;
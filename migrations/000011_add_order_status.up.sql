@@ -0,0 +1,18 @@
+-- Статус заказа (жизненный цикл). Уже сохранённые заказы считаются созданными
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'created';
+
+-- История переходов между статусами: строки только добавляются.
+-- Внешнего ключа на orders нет - история переживает удаление заказа
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id           BIGSERIAL PRIMARY KEY,
+    order_uid    VARCHAR(255) NOT NULL,
+    from_status  VARCHAR(16) NOT NULL,
+    to_status    VARCHAR(16) NOT NULL,
+    reason       TEXT NOT NULL DEFAULT '',
+    source       VARCHAR(16) NOT NULL,
+    sequence     BIGINT,
+    version      BIGINT NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_uid ON order_status_history(order_uid, id);
@@ -0,0 +1,10 @@
+-- Не выполнится, если уже сохранены суммы больше INTEGER
+ALTER TABLE items
+    ALTER COLUMN price TYPE INTEGER,
+    ALTER COLUMN total_price TYPE INTEGER;
+
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE INTEGER,
+    ALTER COLUMN delivery_cost TYPE INTEGER,
+    ALTER COLUMN goods_total TYPE INTEGER,
+    ALTER COLUMN custom_fee TYPE INTEGER;
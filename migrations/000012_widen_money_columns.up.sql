@@ -0,0 +1,11 @@
+-- Суммы - int64 минимальных единиц валюты (domain.MinorUnits): INTEGER переполняется
+-- уже на 21 474 836.47 в валютах с копейками
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE BIGINT,
+    ALTER COLUMN delivery_cost TYPE BIGINT,
+    ALTER COLUMN goods_total TYPE BIGINT,
+    ALTER COLUMN custom_fee TYPE BIGINT;
+
+ALTER TABLE items
+    ALTER COLUMN price TYPE BIGINT,
+    ALTER COLUMN total_price TYPE BIGINT;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_processed_messages_processed_at;
@@ -0,0 +1,3 @@
+-- Отметки старше срока хранения stream удаляются в фоне (NATS_PROCESSED_RETENTION):
+-- такие сообщения уже не могут быть доставлены повторно
+CREATE INDEX IF NOT EXISTS idx_processed_messages_processed_at ON processed_messages (processed_at);
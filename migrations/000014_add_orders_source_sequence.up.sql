@@ -0,0 +1,6 @@
+-- Номер сообщения NATS, последним записавшего данные заказа: политика newer
+-- (ORDER_UPSERT_POLICY) заменяет заказ только сообщением с большим номером.
+-- NULL - заказ не записывался из NATS или записан до этой миграции. Из order_history
+-- номер не берётся: там есть номера NATS Streaming, а номера JetStream начинаются
+-- заново (миграция 000009), и с ними newer отбрасывал бы новые сообщения
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS source_sequence BIGINT;
@@ -1,66 +1,144 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
 )
 
-// Logger - интерфейс логера
+// Logger - интерфейс логера.
+// Сообщения форматируются как в fmt.Sprintf, контекст передаётся полями через With
 type Logger interface {
 	Info(msg string, args ...interface{})
 	Warn(msg string, args ...interface{})
 	Error(msg string, args ...interface{})
 	Debug(msg string, args ...interface{})
+	With(fields ...Field) Logger
 }
 
-// SimpleLogger - простая реализация логера
+// Field - именованное поле структурированного лога
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F - создание поля лога
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Config - настройки логера
+type Config struct {
+	Level  string    // debug, info, warn, error
+	Format string    // json или text
+	Output io.Writer // по умолчанию os.Stdout
+}
+
+// SimpleLogger - реализация логера поверх log/slog
 type SimpleLogger struct {
-	level string
+	logger *slog.Logger
 }
 
-// New - создание нового логера
+// New - создание нового логера (текстовый формат)
 func New(level string) Logger {
-	return &SimpleLogger{level: level}
+	return NewWithConfig(Config{Level: level, Format: "text"})
+}
+
+// NewWithConfig - создание логера с выбором формата (LOG_FORMAT)
+func NewWithConfig(cfg Config) Logger {
+	output := cfg.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	opts := &slog.HandlerOptions{Level: parseLevel(cfg.Level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Format, "json") {
+		handler = slog.NewJSONHandler(output, opts)
+	} else {
+		handler = slog.NewTextHandler(output, opts)
+	}
+
+	return &SimpleLogger{logger: slog.New(handler)}
 }
 
 // Info - информационное сообщение
 func (l *SimpleLogger) Info(msg string, args ...interface{}) {
-	if l.shouldLog("info") {
-		log.Printf("[INFO] "+msg, args...)
-	}
+	l.log(slog.LevelInfo, msg, args)
 }
 
 // Warn - предупреждение
 func (l *SimpleLogger) Warn(msg string, args ...interface{}) {
-	if l.shouldLog("warn") {
-		log.Printf("[WARN] "+msg, args...)
-	}
+	l.log(slog.LevelWarn, msg, args)
 }
 
 // Error - ошибка
 func (l *SimpleLogger) Error(msg string, args ...interface{}) {
-	if l.shouldLog("error") {
-		log.Printf("[ERROR] "+msg, args...)
-	}
+	l.log(slog.LevelError, msg, args)
 }
 
 // Debug - отладочное сообщение
 func (l *SimpleLogger) Debug(msg string, args ...interface{}) {
-	if l.shouldLog("debug") {
-		log.Printf("[DEBUG] "+msg, args...)
+	l.log(slog.LevelDebug, msg, args)
+}
+
+// With - логер, добавляющий поля к каждому сообщению
+func (l *SimpleLogger) With(fields ...Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	attrs := make([]interface{}, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
 	}
+
+	return &SimpleLogger{logger: l.logger.With(attrs...)}
 }
 
-// shouldLog - проверка, нужно ли логировать на текущем уровне
-func (l *SimpleLogger) shouldLog(msgLevel string) bool {
-	levels := map[string]int{
-		"debug": 0,
-		"info":  1,
-		"warn":  2,
-		"error": 3,
+// log - форматирование сообщения только если уровень включён
+func (l *SimpleLogger) log(level slog.Level, msg string, args []interface{}) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
 	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	l.logger.Log(ctx, level, msg)
+}
 
-	currentLevel := levels[l.level]
-	messageLevel := levels[msgLevel]
+// parseLevel - уровень логирования из строки (по умолчанию info)
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// --- Логер в контексте запроса/сообщения ---
+
+type contextKey struct{}
 
-	return messageLevel >= currentLevel
+// WithContext - кладёт логер (обычно с полями запроса) в контекст
+func WithContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext - логер из контекста или fallback, если его там нет
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if log, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return log
+	}
+	return fallback
 }
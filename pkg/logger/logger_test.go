@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLogger_JSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewWithConfig(Config{Level: "info", Format: "json", Output: &buf})
+
+	log.With(F("order_uid", "abc"), F("nats_sequence", uint64(42))).Info("Processing order %d", 7)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", buf.String(), err)
+	}
+
+	if entry["msg"] != "Processing order 7" {
+		t.Errorf("Expected formatted message, got %v", entry["msg"])
+	}
+	if entry["level"] != "INFO" {
+		t.Errorf("Expected level INFO, got %v", entry["level"])
+	}
+	if entry["order_uid"] != "abc" {
+		t.Errorf("Expected order_uid field, got %v", entry["order_uid"])
+	}
+	if entry["nats_sequence"] != float64(42) {
+		t.Errorf("Expected nats_sequence field, got %v", entry["nats_sequence"])
+	}
+}
+
+func TestLogger_TextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewWithConfig(Config{Level: "info", Format: "text", Output: &buf})
+
+	log.With(F("request_id", "req-1")).Warn("Slow request")
+
+	line := buf.String()
+	if !strings.Contains(line, "level=WARN") ||
+		!strings.Contains(line, `msg="Slow request"`) ||
+		!strings.Contains(line, "request_id=req-1") {
+		t.Errorf("Unexpected text line: %q", line)
+	}
+}
+
+func TestLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewWithConfig(Config{Level: "warn", Format: "json", Output: &buf})
+
+	log.Debug("debug")
+	log.Info("info")
+	if buf.Len() != 0 {
+		t.Errorf("Expected debug and info to be filtered, got %q", buf.String())
+	}
+
+	log.Error("error")
+	if !strings.Contains(buf.String(), `"msg":"error"`) {
+		t.Errorf("Expected error to be logged, got %q", buf.String())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	fallback := New("info")
+	if FromContext(context.Background(), fallback) != fallback {
+		t.Error("Expected fallback logger for empty context")
+	}
+
+	scoped := fallback.With(F("request_id", "req-1"))
+	ctx := WithContext(context.Background(), scoped)
+	if FromContext(ctx, fallback) != scoped {
+		t.Error("Expected logger stored in context")
+	}
+}
@@ -0,0 +1,32 @@
+package nats
+
+import "errors"
+
+// permanentError - ошибка обработки, которую повторная доставка не исправит
+// (битый JSON, невалидный заказ). Такие сообщения сразу уходят в dead letter
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent - помечает ошибку как постоянную (без повторных попыток)
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent - является ли ошибка постоянной. Все остальные ошибки
+// считаются временными (например, БД недоступна), и сообщение доставляется повторно
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
@@ -0,0 +1,119 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// jetStreamTimeout - сколько ждать ответа JetStream на служебные запросы и публикацию
+const jetStreamTimeout = 5 * time.Second
+
+// JetStreamConfig - конфигурация JetStream
+type JetStreamConfig struct {
+	URL      string
+	Name     string   // Имя соединения (видно в мониторинге сервера)
+	Stream   string   // Stream, в котором хранятся сообщения
+	Subjects []string // Subjects, которые сохраняет stream
+
+	// Лимиты хранения (0 - без лимита). Stream хранит и сообщения, которые никто
+	// не читает (события, dead letters), поэтому без лимитов он растёт бесконечно;
+	// при превышении сервер удаляет самые старые сообщения
+	MaxAge   time.Duration
+	MaxBytes int64
+	MaxMsgs  int64
+}
+
+// JetStream - NATS JetStream клиент
+type JetStream struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	stream string
+}
+
+// NewJetStream - подключение к NATS и создание (или обновление) stream
+func NewJetStream(ctx context.Context, cfg *JetStreamConfig) (*JetStream, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name(cfg.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to init JetStream: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jetStreamTimeout)
+	defer cancel()
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      cfg.Stream,
+		Subjects:  cfg.Subjects,
+		Storage:   jetstream.FileStorage,
+		Retention: jetstream.LimitsPolicy,
+		Discard:   jetstream.DiscardOld,
+		MaxAge:    cfg.MaxAge,
+		MaxBytes:  limit(cfg.MaxBytes),
+		MaxMsgs:   limit(cfg.MaxMsgs),
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", cfg.Stream, err)
+	}
+
+	return &JetStream{conn: conn, js: js, stream: cfg.Stream}, nil
+}
+
+// limit - лимит stream для сервера: без лимита - -1
+func limit(value int64) int64 {
+	if value <= 0 {
+		return -1
+	}
+	return value
+}
+
+// Close - закрытие соединения
+func (c *JetStream) Close() error {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	return nil
+}
+
+// JetStreamPublisher - публикация сообщений в JetStream с подтверждением сервера
+type JetStreamPublisher struct {
+	client *JetStream
+}
+
+// NewJetStreamPublisher - создание publisher
+func NewJetStreamPublisher(client *JetStream) *JetStreamPublisher {
+	return &JetStreamPublisher{client: client}
+}
+
+// Publish - отправка сообщения в subject
+func (p *JetStreamPublisher) Publish(subject string, data interface{}) error {
+	// Сериализуем в JSON
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	return p.PublishBytes(subject, payload)
+}
+
+// PublishBytes - отправка байтов в subject. Ошибки нет, только когда
+// сообщение сохранено в stream
+func (p *JetStreamPublisher) PublishBytes(subject string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), jetStreamTimeout)
+	defer cancel()
+
+	if _, err := p.client.js.Publish(ctx, subject, data); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultAckWait - через сколько JetStream доставляет снова сообщение без ответа,
+// если AckWait не задан
+const DefaultAckWait = 30 * time.Second
+
+// JetStreamSubscriber - подписка на stream через durable pull consumer
+type JetStreamSubscriber struct {
+	settler
+	client *JetStream
+}
+
+// NewJetStreamSubscriber - создание subscriber
+func NewJetStreamSubscriber(client *JetStream, cfg SubscriberConfig) *JetStreamSubscriber {
+	if cfg.MaxInflight <= 0 {
+		cfg.MaxInflight = 1
+	}
+
+	return &JetStreamSubscriber{
+		settler: newSettler(cfg),
+		client:  client,
+	}
+}
+
+// Subscribe - подписка на subject с обработчиком.
+// Сообщения обрабатываются по одному и подтверждаются сразу после handler
+func (s *JetStreamSubscriber) Subscribe(subject string, durableName string, handler MessageHandler) (Subscription, error) {
+	return s.SubscribeAsync(subject, durableName, func(msg Message) {
+		s.Settle(msg, handler(msg))
+	})
+}
+
+// SubscribeAsync - подписка, в которой обработка и подтверждение - забота dispatch
+// (см. Subscriber.SubscribeAsync). Consumer с именем durableName создаётся
+// или обновляется на сервере и продолжает с первого неподтверждённого сообщения
+func (s *JetStreamSubscriber) SubscribeAsync(subject string, durableName string, dispatch func(msg Message)) (Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jetStreamTimeout)
+	defer cancel()
+
+	consumer, err := s.client.js.CreateOrUpdateConsumer(ctx, s.client.stream, s.consumerConfig(subject, durableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer %s: %w", durableName, err)
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		m, err := newJetStreamMessage(msg)
+		if err != nil {
+			// Без метаданных не посчитать повторы - пусть сервер доставит снова
+			_ = msg.Nak()
+			return
+		}
+		dispatch(m)
+	}, jetstream.PullMaxMessages(s.cfg.MaxInflight))
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	return jetStreamSubscription{consumeCtx: consumeCtx}, nil
+}
+
+// consumerConfig - настройки durable consumer: явное подтверждение каждого
+// сообщения, не больше MaxInflight неподтверждённых.
+//
+// BackOff сервера не задаётся: он заменил бы AckWait своей первой паузой, и сообщение,
+// ждущее в очереди обработчика или повторяемое им, сервер доставил бы снова.
+// Паузы Backoff применяются к Nak в Settle, AckWait - только к сообщениям без ответа
+func (s *JetStreamSubscriber) consumerConfig(subject, durableName string) jetstream.ConsumerConfig {
+	cfg := jetstream.ConsumerConfig{
+		Durable:       durableName,
+		FilterSubject: subject,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       s.cfg.AckWait,
+		MaxDeliver:    s.cfg.MaxDeliver,
+		MaxAckPending: s.cfg.MaxInflight,
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = DefaultAckWait
+	}
+	if cfg.MaxDeliver <= 0 {
+		cfg.MaxDeliver = -1
+	}
+	return cfg
+}
+
+// jetStreamSubscription - Subscription поверх jetstream.ConsumeContext
+type jetStreamSubscription struct {
+	consumeCtx jetstream.ConsumeContext
+}
+
+// Close - прекратить получение сообщений. Durable consumer остаётся на сервере,
+// неподтверждённые сообщения будут доставлены снова
+func (s jetStreamSubscription) Close() error {
+	s.consumeCtx.Stop()
+	<-s.consumeCtx.Closed()
+	return nil
+}
+
+// jetStreamMessage - Message поверх сообщения JetStream
+type jetStreamMessage struct {
+	msg  jetstream.Msg
+	meta *jetstream.MsgMetadata
+}
+
+func newJetStreamMessage(msg jetstream.Msg) (jetStreamMessage, error) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return jetStreamMessage{}, err
+	}
+	return jetStreamMessage{msg: msg, meta: meta}, nil
+}
+
+func (m jetStreamMessage) ID() string {
+	return m.meta.Stream + ":" + strconv.FormatUint(m.meta.Sequence.Stream, 10)
+}
+
+func (m jetStreamMessage) Subject() string              { return m.msg.Subject() }
+func (m jetStreamMessage) Sequence() uint64             { return m.meta.Sequence.Stream }
+func (m jetStreamMessage) Data() []byte                 { return m.msg.Data() }
+func (m jetStreamMessage) Headers() map[string][]string { return m.msg.Headers() }
+func (m jetStreamMessage) DeliveryCount() int           { return int(m.meta.NumDelivered) }
+func (m jetStreamMessage) Redelivered() bool            { return m.meta.NumDelivered > 1 }
+func (m jetStreamMessage) Ack() error                   { return m.msg.Ack() }
+func (m jetStreamMessage) InProgress() error            { return m.msg.InProgress() }
+
+func (m jetStreamMessage) Nak(delay time.Duration) error {
+	if delay > 0 {
+		return m.msg.NakWithDelay(delay)
+	}
+	return m.msg.Nak()
+}
@@ -0,0 +1,297 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// runJetStream - встроенный nats-server с JetStream и подключённый к нему клиент
+func runJetStream(t *testing.T) *JetStream {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create NATS server: %v", err)
+	}
+	srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server is not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	client, err := NewJetStream(context.Background(), &JetStreamConfig{
+		URL:      srv.ClientURL(),
+		Name:     "test",
+		Stream:   "ORDERS",
+		Subjects: []string{"orders", "orders.dead-letter"},
+	})
+	if err != nil {
+		t.Fatalf("NewJetStream() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+// delivery - доставка сообщения обработчику
+type delivery struct {
+	data     string
+	sequence uint64
+	count    int
+}
+
+// recorder - обработчик, записывающий доставки и возвращающий заданные ошибки
+type recorder struct {
+	mu         sync.Mutex
+	deliveries []delivery
+	fail       func(data string, count int) error
+	notify     chan struct{}
+}
+
+func newRecorder(fail func(string, int) error) *recorder {
+	return &recorder{fail: fail, notify: make(chan struct{}, 100)}
+}
+
+func (r *recorder) handle(msg Message) error {
+	r.mu.Lock()
+	r.deliveries = append(r.deliveries, delivery{
+		data:     string(msg.Data()),
+		sequence: msg.Sequence(),
+		count:    msg.DeliveryCount(),
+	})
+	r.mu.Unlock()
+	r.notify <- struct{}{}
+
+	if r.fail == nil {
+		return nil
+	}
+	return r.fail(string(msg.Data()), msg.DeliveryCount())
+}
+
+// wait - дождаться n доставок
+func (r *recorder) wait(t *testing.T, n int) []delivery {
+	t.Helper()
+	for {
+		r.mu.Lock()
+		got := append([]delivery(nil), r.deliveries...)
+		r.mu.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		select {
+		case <-r.notify:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %d deliveries, got %d: %v", n, len(got), got)
+		}
+	}
+}
+
+// Сообщения доставляются по порядку, подтверждённые не доставляются снова,
+// в том числе после переподписки того же durable consumer
+func TestJetStream_DeliveryAndAck(t *testing.T) {
+	client := runJetStream(t)
+	publisher := NewJetStreamPublisher(client)
+	subscriber := NewJetStreamSubscriber(client, SubscriberConfig{AckWait: 100 * time.Millisecond})
+
+	for i := 1; i <= 3; i++ {
+		if err := publisher.PublishBytes("orders", []byte(fmt.Sprintf("order-%d", i))); err != nil {
+			t.Fatalf("PublishBytes() error = %v", err)
+		}
+	}
+
+	rec := newRecorder(nil)
+	sub, err := subscriber.Subscribe("orders", "service", rec.handle)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	got := rec.wait(t, 3)
+	for i, d := range got {
+		if d.data != fmt.Sprintf("order-%d", i+1) || d.sequence != uint64(i+1) || d.count != 1 {
+			t.Errorf("Unexpected delivery %d: %+v", i, d)
+		}
+	}
+
+	// Подписка закрыта после ack - после AckWait ничего не доставляется повторно
+	_ = sub.Close()
+	time.Sleep(300 * time.Millisecond)
+
+	if err := publisher.PublishBytes("orders", []byte("order-4")); err != nil {
+		t.Fatalf("PublishBytes() error = %v", err)
+	}
+	rec2 := newRecorder(nil)
+	sub, err = subscriber.Subscribe("orders", "service", rec2.handle)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer func() { _ = sub.Close() }()
+
+	got = rec2.wait(t, 1)
+	time.Sleep(200 * time.Millisecond)
+	rec2.mu.Lock()
+	defer rec2.mu.Unlock()
+	if len(rec2.deliveries) != 1 || got[0].data != "order-4" {
+		t.Errorf("Expected only the new message after resubscribe, got %v", rec2.deliveries)
+	}
+}
+
+// Временная ошибка возвращает сообщение с паузой из Backoff; без ack сообщение
+// доставляется снова через AckWait
+func TestJetStream_Redelivery(t *testing.T) {
+	client := runJetStream(t)
+	publisher := NewJetStreamPublisher(client)
+	subscriber := NewJetStreamSubscriber(client, SubscriberConfig{
+		AckWait: 5 * time.Second,
+		Backoff: []time.Duration{100 * time.Millisecond},
+	})
+
+	transient := errors.New("db is down")
+	rec := newRecorder(func(_ string, count int) error {
+		if count < 3 {
+			return transient
+		}
+		return nil
+	})
+
+	sub, err := subscriber.Subscribe("orders", "service", rec.handle)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer func() { _ = sub.Close() }()
+
+	started := time.Now()
+	if err := publisher.PublishBytes("orders", []byte("order-1")); err != nil {
+		t.Fatalf("PublishBytes() error = %v", err)
+	}
+
+	got := rec.wait(t, 3)
+	for i, d := range got {
+		if d.count != i+1 || d.sequence != 1 {
+			t.Errorf("Unexpected delivery %d: %+v", i, d)
+		}
+	}
+	if elapsed := time.Since(started); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected redeliveries to wait for the backoff, took %v", elapsed)
+	}
+}
+
+// Без ack (например, сервис упал во время обработки) сообщение доставляется снова через AckWait
+func TestJetStream_RedeliveryAfterAckWait(t *testing.T) {
+	client := runJetStream(t)
+	publisher := NewJetStreamPublisher(client)
+	subscriber := NewJetStreamSubscriber(client, SubscriberConfig{AckWait: 200 * time.Millisecond})
+
+	rec := newRecorder(nil)
+	var once sync.Once
+	sub, err := subscriber.SubscribeAsync("orders", "service", func(msg Message) {
+		// Первую доставку не подтверждаем совсем
+		first := false
+		once.Do(func() { first = true })
+		err := rec.handle(msg)
+		if !first {
+			subscriber.Settle(msg, err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("SubscribeAsync() error = %v", err)
+	}
+	defer func() { _ = sub.Close() }()
+
+	if err := publisher.PublishBytes("orders", []byte("order-1")); err != nil {
+		t.Fatalf("PublishBytes() error = %v", err)
+	}
+
+	got := rec.wait(t, 2)
+	if got[1].count != 2 || got[1].data != "order-1" {
+		t.Errorf("Expected the unacked message to be redelivered, got %+v", got[1])
+	}
+}
+
+// С настройками по умолчанию (NATS_ACK_WAIT=30s, NATS_MAX_DELIVER=0, NATS_BACKOFF=1s,5s,30s)
+// сервер не получает BackOff: иначе его первая пауза заменила бы AckWait
+func TestJetStreamSubscriber_ConsumerConfig(t *testing.T) {
+	subscriber := NewJetStreamSubscriber(nil, SubscriberConfig{
+		MaxRedeliveries: 5,
+		AckWait:         30 * time.Second,
+		MaxInflight:     1024,
+		Backoff:         []time.Duration{time.Second, 5 * time.Second, 30 * time.Second},
+	})
+
+	cfg := subscriber.consumerConfig("orders", "service")
+	if cfg.BackOff != nil {
+		t.Errorf("Expected no server BackOff, got %v", cfg.BackOff)
+	}
+	if cfg.AckWait != 30*time.Second || cfg.MaxDeliver != -1 || cfg.MaxAckPending != 1024 {
+		t.Errorf("Unexpected consumer config: AckWait %v, MaxDeliver %d, MaxAckPending %d",
+			cfg.AckWait, cfg.MaxDeliver, cfg.MaxAckPending)
+	}
+
+	// Без AckWait - явное значение по умолчанию, а не выбор сервера
+	if got := NewJetStreamSubscriber(nil, SubscriberConfig{}).consumerConfig("orders", "service").AckWait; got != DefaultAckWait {
+		t.Errorf("Expected AckWait %v by default, got %v", DefaultAckWait, got)
+	}
+}
+
+// Постоянная ошибка и исчерпанные повторы отправляют сообщение в dead letter
+func TestJetStream_DeadLetter(t *testing.T) {
+	client := runJetStream(t)
+	publisher := NewJetStreamPublisher(client)
+
+	var mu sync.Mutex
+	letters := make(map[string]int)
+	subscriber := NewJetStreamSubscriber(client, SubscriberConfig{
+		AckWait:         5 * time.Second,
+		MaxRedeliveries: 2,
+		DeadLetter: func(msg Message, err error) error {
+			mu.Lock()
+			defer mu.Unlock()
+			letters[string(msg.Data())] = msg.DeliveryCount()
+			return nil
+		},
+	})
+
+	rec := newRecorder(func(data string, _ int) error {
+		if data == "broken" {
+			return Permanent(errors.New("invalid JSON"))
+		}
+		return errors.New("db is down")
+	})
+	sub, err := subscriber.Subscribe("orders", "service", rec.handle)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer func() { _ = sub.Close() }()
+
+	for _, data := range []string{"broken", "retried"} {
+		if err := publisher.PublishBytes("orders", []byte(data)); err != nil {
+			t.Fatalf("PublishBytes() error = %v", err)
+		}
+	}
+
+	// broken - одна доставка, retried - первая и 2 повтора
+	rec.wait(t, 4)
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if letters["broken"] != 1 || letters["retried"] != 3 {
+		t.Errorf("Unexpected dead letters (message: delivery): %v", letters)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.deliveries) != 4 {
+		t.Errorf("Dead-lettered messages must be acked, got %d deliveries", len(rec.deliveries))
+	}
+}
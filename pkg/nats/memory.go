@@ -0,0 +1,251 @@
+package nats
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryBroker - брокер в памяти процесса для тестов: те же подписки и правила
+// подтверждения (SubscriberConfig), что у NATS, без внешнего сервера.
+// Сообщения хранятся, пока не подтверждены; подписка получает их все по порядку.
+// Nak доставляет сообщение снова после паузы; неподтверждённое без ответа
+// сообщение само не повторяется (AckWait не поддерживается)
+type MemoryBroker struct {
+	settler
+
+	mu       sync.Mutex
+	sequence uint64
+	messages []*MemoryMessage
+	subs     map[string]*memorySubscription
+}
+
+// NewMemoryBroker - создание брокера в памяти
+func NewMemoryBroker(cfg SubscriberConfig) *MemoryBroker {
+	return &MemoryBroker{
+		settler: newSettler(cfg),
+		subs:    make(map[string]*memorySubscription),
+	}
+}
+
+// PublishBytes - отправка байтов в subject
+func (b *MemoryBroker) PublishBytes(subject string, data []byte) error {
+	b.PublishMsg(subject, data, nil)
+	return nil
+}
+
+// PublishMsg - отправка сообщения с заголовками; возвращает отправленное сообщение
+func (b *MemoryBroker) PublishMsg(subject string, data []byte, headers map[string][]string) *MemoryMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sequence++
+	msg := &MemoryMessage{
+		broker:   b,
+		subject:  subject,
+		sequence: b.sequence,
+		data:     data,
+		headers:  headers,
+	}
+	b.messages = append(b.messages, msg)
+
+	if sub := b.subs[subject]; sub != nil {
+		b.deliverLocked(sub, msg)
+	}
+	return msg
+}
+
+// Subscribe - подписка на subject с обработчиком.
+// Сообщения обрабатываются по одному и подтверждаются сразу после handler
+func (b *MemoryBroker) Subscribe(subject string, durableName string, handler MessageHandler) (Subscription, error) {
+	return b.SubscribeAsync(subject, durableName, func(msg Message) {
+		b.Settle(msg, handler(msg))
+	})
+}
+
+// SubscribeAsync - подписка, в которой обработка и подтверждение - забота dispatch
+// (см. Subscriber.SubscribeAsync). На subject - одна подписка; она получает все
+// неподтверждённые сообщения, в том числе отправленные до неё
+func (b *MemoryBroker) SubscribeAsync(subject string, _ string, dispatch func(msg Message)) (Subscription, error) {
+	sub := &memorySubscription{
+		broker:   b,
+		subject:  subject,
+		dispatch: dispatch,
+		wake:     make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.subs[subject] = sub
+	for _, msg := range b.messages {
+		if msg.subject == subject && !msg.acked {
+			b.deliverLocked(sub, msg)
+		}
+	}
+	b.mu.Unlock()
+
+	go sub.run()
+	return sub, nil
+}
+
+// Messages - все отправленные в subject сообщения в порядке отправки
+func (b *MemoryBroker) Messages(subject string) []*MemoryMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var messages []*MemoryMessage
+	for _, msg := range b.messages {
+		if msg.subject == subject {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// deliverLocked - поставить сообщение в очередь подписки (b.mu захвачен)
+func (b *MemoryBroker) deliverLocked(sub *memorySubscription, msg *MemoryMessage) {
+	msg.deliveries++
+	sub.pending = append(sub.pending, memoryQueued{msg: msg, count: msg.deliveries})
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
+
+// redeliver - повторная доставка после Nak, если подписка ещё активна
+func (b *MemoryBroker) redeliver(msg *MemoryMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if sub := b.subs[msg.subject]; sub != nil && !msg.acked {
+		b.deliverLocked(sub, msg)
+	}
+}
+
+// memoryQueued - доставка сообщения в очереди подписки с её номером
+type memoryQueued struct {
+	msg   *MemoryMessage
+	count int
+}
+
+// memorySubscription - подписка MemoryBroker: доставляет сообщения по одному, по порядку
+type memorySubscription struct {
+	broker   *MemoryBroker
+	subject  string
+	dispatch func(msg Message)
+	pending  []memoryQueued // Защищено broker.mu
+	wake     chan struct{}
+	closed   chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (s *memorySubscription) run() {
+	defer close(s.done)
+
+	for {
+		s.broker.mu.Lock()
+		var next memoryQueued
+		if len(s.pending) > 0 {
+			next = s.pending[0]
+			s.pending = s.pending[1:]
+		}
+		s.broker.mu.Unlock()
+
+		if next.msg != nil {
+			s.dispatch(memoryDelivery{MemoryMessage: next.msg, count: next.count})
+			continue
+		}
+
+		select {
+		case <-s.wake:
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// Close - прекратить доставку; неподтверждённые сообщения получит следующая подписка
+func (s *memorySubscription) Close() error {
+	s.once.Do(func() {
+		s.broker.mu.Lock()
+		if s.broker.subs[s.subject] == s {
+			delete(s.broker.subs, s.subject)
+		}
+		s.pending = nil
+		s.broker.mu.Unlock()
+
+		close(s.closed)
+	})
+	<-s.done
+	return nil
+}
+
+// MemoryMessage - сообщение MemoryBroker; хранит результат подтверждения для проверок в тестах
+type MemoryMessage struct {
+	broker   *MemoryBroker
+	subject  string
+	sequence uint64
+	data     []byte
+	headers  map[string][]string
+
+	// Защищено broker.mu
+	deliveries int
+	acked      bool
+	naks       int
+}
+
+// Acked - сообщение подтверждено
+func (m *MemoryMessage) Acked() bool {
+	m.broker.mu.Lock()
+	defer m.broker.mu.Unlock()
+	return m.acked
+}
+
+// Naks - сколько раз сообщение возвращено для повторной доставки
+func (m *MemoryMessage) Naks() int {
+	m.broker.mu.Lock()
+	defer m.broker.mu.Unlock()
+	return m.naks
+}
+
+// Deliveries - сколько раз сообщение доставлено
+func (m *MemoryMessage) Deliveries() int {
+	m.broker.mu.Lock()
+	defer m.broker.mu.Unlock()
+	return m.deliveries
+}
+
+// memoryDelivery - Message для одной доставки MemoryMessage
+type memoryDelivery struct {
+	*MemoryMessage
+	count int
+}
+
+func (d memoryDelivery) ID() string                   { return d.subject + ":" + strconv.FormatUint(d.sequence, 10) }
+func (d memoryDelivery) Subject() string              { return d.subject }
+func (d memoryDelivery) Sequence() uint64             { return d.sequence }
+func (d memoryDelivery) Data() []byte                 { return d.data }
+func (d memoryDelivery) Headers() map[string][]string { return d.headers }
+func (d memoryDelivery) DeliveryCount() int           { return d.count }
+func (d memoryDelivery) Redelivered() bool            { return d.count > 1 }
+
+// InProgress - AckWait в памяти не поддерживается, продлевать нечего
+func (d memoryDelivery) InProgress() error { return nil }
+
+func (d memoryDelivery) Ack() error {
+	d.broker.mu.Lock()
+	defer d.broker.mu.Unlock()
+	d.acked = true
+	return nil
+}
+
+func (d memoryDelivery) Nak(delay time.Duration) error {
+	d.broker.mu.Lock()
+	d.naks++
+	d.broker.mu.Unlock()
+
+	time.AfterFunc(delay, func() { d.broker.redeliver(d.MemoryMessage) })
+	return nil
+}
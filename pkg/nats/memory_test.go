@@ -0,0 +1,79 @@
+package nats
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Сообщения, отправленные до подписки, доставляются по порядку; Nak доставляет
+// сообщение снова, неподтверждённые сообщения получает следующая подписка
+func TestMemoryBroker_Redelivery(t *testing.T) {
+	broker := NewMemoryBroker(SubscriberConfig{Backoff: []time.Duration{10 * time.Millisecond}})
+
+	first := broker.PublishMsg("orders", []byte("order-1"), map[string][]string{"Nats-Msg-Id": {"1"}})
+	second := broker.PublishMsg("orders", []byte("order-2"), nil)
+
+	rec := newRecorder(func(data string, count int) error {
+		if data == "order-2" && count < 2 {
+			return errors.New("db is down")
+		}
+		return nil
+	})
+	sub, err := broker.Subscribe("orders", "service", rec.handle)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	got := rec.wait(t, 3)
+	want := []delivery{{"order-1", 1, 1}, {"order-2", 2, 1}, {"order-2", 2, 2}}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Delivery %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	_ = sub.Close()
+
+	if !first.Acked() || !second.Acked() || second.Naks() != 1 {
+		t.Errorf("Unexpected settlement: first acked=%v, second acked=%v naks=%d",
+			first.Acked(), second.Acked(), second.Naks())
+	}
+
+	// Неподтверждённое сообщение переходит к следующей подписке
+	third := broker.PublishMsg("orders", []byte("order-3"), nil)
+	var redelivered []Message
+	received := make(chan struct{})
+	sub, err = broker.SubscribeAsync("orders", "service", func(msg Message) {
+		redelivered = append(redelivered, msg)
+		close(received)
+	})
+	if err != nil {
+		t.Fatalf("SubscribeAsync() error = %v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("order-3 was not delivered")
+	}
+	_ = sub.Close()
+
+	sub, err = broker.SubscribeAsync("orders", "service", func(msg Message) {
+		redelivered = append(redelivered, msg)
+		_ = msg.Ack()
+	})
+	if err != nil {
+		t.Fatalf("SubscribeAsync() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !third.Acked() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	_ = sub.Close()
+
+	if len(redelivered) != 2 || !redelivered[1].Redelivered() || redelivered[1].ID() != "orders:3" {
+		t.Fatalf("Expected order-3 to be redelivered to the next subscription, got %d deliveries", len(redelivered))
+	}
+	if first.Deliveries() != 1 {
+		t.Errorf("Acked messages must not be redelivered, got %d deliveries", first.Deliveries())
+	}
+}
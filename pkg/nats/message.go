@@ -0,0 +1,55 @@
+package nats
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nats-io/stan.go"
+)
+
+// Message - сообщение брокера независимо от транспорта (NATS Streaming, JetStream, память)
+type Message interface {
+	ID() string // Уникальный в брокере идентификатор сообщения (для логов)
+	Subject() string
+	Sequence() uint64 // Номер сообщения в канале (stream sequence для JetStream)
+	Data() []byte
+	Headers() map[string][]string // Заголовки (nil, если брокер их не поддерживает)
+	DeliveryCount() int           // Номер доставки, начиная с 1
+	Redelivered() bool            // Доставка не первая
+
+	// Ack - подтвердить обработку
+	Ack() error
+	// Nak - вернуть сообщение для повторной доставки через delay
+	// (брокеры без отрицательного подтверждения доставят его по AckWait)
+	Nak(delay time.Duration) error
+	// InProgress - сообщение ещё обрабатывается: отсрочить повторную доставку на AckWait
+	// (брокеры без продления AckWait ничего не делают)
+	InProgress() error
+}
+
+// Subscription - активная подписка. Close прекращает доставку,
+// но сохраняет позицию durable подписки до следующего запуска
+type Subscription interface {
+	Close() error
+}
+
+// stanMessage - Message поверх сообщения NATS Streaming
+type stanMessage struct {
+	msg *stan.Msg
+}
+
+func (m stanMessage) ID() string                   { return m.msg.Subject + ":" + strconv.FormatUint(m.msg.Sequence, 10) }
+func (m stanMessage) Subject() string              { return m.msg.Subject }
+func (m stanMessage) Sequence() uint64             { return m.msg.Sequence }
+func (m stanMessage) Data() []byte                 { return m.msg.Data }
+func (m stanMessage) Headers() map[string][]string { return nil }
+func (m stanMessage) DeliveryCount() int           { return int(m.msg.RedeliveryCount) + 1 }
+func (m stanMessage) Redelivered() bool            { return m.msg.Redelivered }
+func (m stanMessage) Ack() error                   { return m.msg.Ack() }
+
+// Nak - в NATS Streaming нет отрицательного подтверждения: сообщение
+// остаётся неподтверждённым и доставляется снова через AckWait
+func (m stanMessage) Nak(time.Duration) error { return nil }
+
+// InProgress - NATS Streaming не умеет продлевать AckWait
+func (m stanMessage) InProgress() error { return nil }
@@ -2,43 +2,138 @@ package nats
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/nats-io/stan.go"
+
+	"RWB_L0/pkg/logger"
 )
 
 // MessageHandler - функция-обработчик сообщений
-type MessageHandler func(msg *stan.Msg) error
+type MessageHandler func(msg Message) error
+
+// DeadLetterHandler - сохраняет сообщение, которое не удалось обработать.
+// Если он вернёт ошибку, сообщение не подтверждается и будет доставлено снова
+type DeadLetterHandler func(msg Message, err error) error
+
+// SubscriberConfig - настройки подписки
+type SubscriberConfig struct {
+	MaxRedeliveries int               // Сколько раз повторять временные ошибки до dead letter (0 - без лимита)
+	AckWait         time.Duration     // Через сколько неподтверждённое сообщение доставляется снова
+	MaxInflight     int               // Сколько неподтверждённых сообщений брокер отдаёт сразу (0 - одно)
+	DeadLetter      DeadLetterHandler // Куда отправлять необрабатываемые сообщения (nil - только повторы)
+	Log             logger.Logger     // Куда писать о сообщениях, отброшенных без DeadLetter (nil - stdout, уровень error)
+
+	// Только JetStream
+	MaxDeliver int             // Сколько раз сервер доставляет сообщение (0 - без лимита)
+	Backoff    []time.Duration // Паузы перед повторами временных ошибок (последняя - для всех следующих)
+}
+
+// settler - подтверждение сообщений по результату обработки, общее для всех брокеров
+type settler struct {
+	cfg SubscriberConfig
+}
+
+func newSettler(cfg SubscriberConfig) settler {
+	if cfg.Log == nil {
+		cfg.Log = logger.New("error")
+	}
+	return settler{cfg: cfg}
+}
+
+// Settle - подтверждает сообщение или оставляет его для повторной доставки.
+//
+// Успешно обработанное сообщение подтверждается. Постоянная ошибка или
+// исчерпанный лимит повторов отправляют сообщение в dead letter и подтверждают
+// его, чтобы оно не блокировало durable подписку; без DeadLetter сообщение
+// отбрасывается с записью в лог уровня error. Временная ошибка возвращает
+// сообщение брокеру - он доставит его снова после паузы из Backoff (или через AckWait)
+func (s settler) Settle(msg Message, err error) {
+	if err == nil {
+		// Подтверждаем успешную обработку
+		_ = msg.Ack()
+		return
+	}
 
-// Subscriber - подписка на каналы NATS
+	if !s.shouldDeadLetter(msg, err) {
+		_ = msg.Nak(s.retryDelay(msg))
+		return
+	}
+
+	if s.cfg.DeadLetter == nil {
+		s.cfg.Log.Error("Dropping message %s (subject %s, sequence %d, delivery %d) without dead letter: %v",
+			msg.ID(), msg.Subject(), msg.Sequence(), msg.DeliveryCount(), err)
+	} else if dlErr := s.cfg.DeadLetter(msg, err); dlErr != nil {
+		// Не смогли сохранить - не теряем сообщение, ждём повторной доставки
+		_ = msg.Nak(s.retryDelay(msg))
+		return
+	}
+
+	_ = msg.Ack()
+}
+
+// shouldDeadLetter - пора ли перестать повторять сообщение
+func (s settler) shouldDeadLetter(msg Message, err error) bool {
+	if IsPermanent(err) {
+		return true
+	}
+	return s.cfg.MaxRedeliveries > 0 && msg.DeliveryCount()-1 >= s.cfg.MaxRedeliveries
+}
+
+// retryDelay - пауза перед следующей доставкой сообщения
+func (s settler) retryDelay(msg Message) time.Duration {
+	if len(s.cfg.Backoff) == 0 {
+		return 0
+	}
+	attempt := min(msg.DeliveryCount(), len(s.cfg.Backoff)) - 1
+	return s.cfg.Backoff[max(attempt, 0)]
+}
+
+// Subscriber - подписка на каналы NATS Streaming
 type Subscriber struct {
+	settler
 	client *Client
 }
 
 // NewSubscriber - создание subscriber
-func NewSubscriber(client *Client) *Subscriber {
-	return &Subscriber{client: client}
+func NewSubscriber(client *Client, cfg SubscriberConfig) *Subscriber {
+	if cfg.MaxInflight <= 0 {
+		cfg.MaxInflight = 1
+	}
+
+	return &Subscriber{
+		settler: newSettler(cfg),
+		client:  client,
+	}
 }
 
-// Subscribe - подписка на канал с обработчиком
-func (s *Subscriber) Subscribe(subject string, durableName string, handler MessageHandler) (stan.Subscription, error) {
-	sub, err := s.client.conn.Subscribe(
-		subject,
-		func(msg *stan.Msg) {
-			// Вызываем пользовательский обработчик
-			if err := handler(msg); err != nil {
-				// Логируем ошибку, но не подтверждаем сообщение
-				// NATS попробует доставить повторно
-				return
-			}
-			// Подтверждаем успешную обработку
-			_ = msg.Ack()
-		},
-		stan.DurableName(durableName), // Durable subscription
-		stan.SetManualAckMode(),       // Ручное подтверждение
-		stan.DeliverAllAvailable(),    // Получить все непрочитанные сообщения
-		stan.MaxInflight(1),           // Обрабатывать по одному
-	)
+// Subscribe - подписка на канал с обработчиком.
+// Сообщения обрабатываются по одному и подтверждаются сразу после handler
+func (s *Subscriber) Subscribe(subject string, durableName string, handler MessageHandler) (Subscription, error) {
+	return s.SubscribeAsync(subject, durableName, func(msg Message) {
+		// Вызываем пользовательский обработчик
+		s.Settle(msg, handler(msg))
+	})
+}
+
+// SubscribeAsync - подписка, в которой обработка и подтверждение - забота dispatch.
+// dispatch вызывается последовательно в порядке доставки и может передать сообщение
+// в другую горутину; по окончании обработки для сообщения нужно вызвать Settle.
+// Одновременно в обработке не больше SubscriberConfig.MaxInflight сообщений
+func (s *Subscriber) SubscribeAsync(subject string, durableName string, dispatch func(msg Message)) (Subscription, error) {
+	opts := []stan.SubscriptionOption{
+		stan.DurableName(durableName),       // Durable subscription
+		stan.SetManualAckMode(),             // Ручное подтверждение
+		stan.DeliverAllAvailable(),          // Получить все непрочитанные сообщения
+		stan.MaxInflight(s.cfg.MaxInflight), // Сколько сообщений может ждать подтверждения
+	}
+	if s.cfg.AckWait > 0 {
+		opts = append(opts, stan.AckWait(s.cfg.AckWait))
+	}
 
+	sub, err := s.client.conn.Subscribe(subject, func(msg *stan.Msg) {
+		dispatch(stanMessage{msg: msg})
+	}, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to subscribe: %w", err)
 	}
@@ -47,18 +142,23 @@ func (s *Subscriber) Subscribe(subject string, durableName string, handler Messa
 }
 
 // SubscribeFromLatest - подписка только на новые сообщения
-func (s *Subscriber) SubscribeFromLatest(subject string, durableName string, handler MessageHandler) (stan.Subscription, error) {
+func (s *Subscriber) SubscribeFromLatest(subject string, durableName string, handler MessageHandler) (Subscription, error) {
+	opts := []stan.SubscriptionOption{
+		stan.DurableName(durableName),
+		stan.SetManualAckMode(),
+		stan.StartWithLastReceived(), // Только новые сообщения
+	}
+	if s.cfg.AckWait > 0 {
+		opts = append(opts, stan.AckWait(s.cfg.AckWait))
+	}
+
 	sub, err := s.client.conn.Subscribe(
 		subject,
 		func(msg *stan.Msg) {
-			if err := handler(msg); err != nil {
-				return
-			}
-			_ = msg.Ack()
+			m := stanMessage{msg: msg}
+			s.Settle(m, handler(m))
 		},
-		stan.DurableName(durableName),
-		stan.SetManualAckMode(),
-		stan.StartWithLastReceived(), // Только новые сообщения
+		opts...,
 	)
 
 	if err != nil {
@@ -0,0 +1,98 @@
+package nats
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/stan.go"
+	"github.com/nats-io/stan.go/pb"
+
+	"RWB_L0/pkg/logger"
+)
+
+func TestPermanent(t *testing.T) {
+	base := errors.New("bad payload")
+	err := fmt.Errorf("handle: %w", Permanent(base))
+
+	if !IsPermanent(err) {
+		t.Error("Expected wrapped permanent error to be permanent")
+	}
+	if !errors.Is(err, base) {
+		t.Error("Permanent must keep the original error in the chain")
+	}
+	if IsPermanent(base) {
+		t.Error("Plain errors must be transient")
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) must be nil")
+	}
+}
+
+func TestSubscriber_ShouldDeadLetter(t *testing.T) {
+	s := NewSubscriber(nil, SubscriberConfig{MaxRedeliveries: 3})
+	transient := errors.New("db is down")
+
+	tests := []struct {
+		name        string
+		redelivered uint32
+		err         error
+		want        bool
+	}{
+		{"permanent on first delivery", 0, Permanent(transient), true},
+		{"transient is retried", 2, transient, false},
+		{"transient after max redeliveries", 3, transient, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := stanMessage{msg: &stan.Msg{MsgProto: pb.MsgProto{RedeliveryCount: tt.redelivered}}}
+			if got := s.shouldDeadLetter(msg, tt.err); got != tt.want {
+				t.Errorf("shouldDeadLetter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Без лимита временные ошибки повторяются бесконечно
+	unlimited := NewSubscriber(nil, SubscriberConfig{})
+	msg := stanMessage{msg: &stan.Msg{MsgProto: pb.MsgProto{RedeliveryCount: 100}}}
+	if unlimited.shouldDeadLetter(msg, transient) {
+		t.Error("Expected transient errors to be retried without a limit")
+	}
+}
+
+// Без DeadLetter необрабатываемое сообщение подтверждается, но не молча:
+// в лог уровня error попадают его subject и sequence
+func TestSettler_DropWithoutDeadLetterIsLogged(t *testing.T) {
+	var logs bytes.Buffer
+	broker := NewMemoryBroker(SubscriberConfig{
+		Log: logger.NewWithConfig(logger.Config{Level: "error", Output: &logs}),
+	})
+	msg := broker.PublishMsg("orders", []byte("{"), nil)
+
+	sub, err := broker.Subscribe("orders", "service", func(Message) error {
+		return Permanent(errors.New("invalid JSON"))
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer func() { _ = sub.Close() }()
+
+	deadline := time.Now().Add(time.Second)
+	for !msg.Acked() {
+		if time.Now().After(deadline) {
+			t.Fatal("Message was not acked")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	line := logs.String()
+	for _, want := range []string{"level=ERROR", "subject orders", "sequence 1", "invalid JSON"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected %q in the log, got %q", want, line)
+		}
+	}
+}